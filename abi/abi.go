@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"hash"
 	"io"
-	"regexp"
 	"strings"
 	"sync"
 
@@ -18,9 +17,12 @@ import (
 // ABI represents the ethereum abi format
 type ABI struct {
 	Constructor  *Method
+	Fallback     *Method
+	Receive      *Method
 	Methods      map[string]*Method
 	MethodsBySig map[string]*Method
 	Events       map[string]*Event
+	EventsBySig  map[string]*Event
 	Errors       map[string]*Error
 }
 
@@ -29,6 +31,28 @@ func (a *ABI) addEvent(e *Event) {
 		a.Events = map[string]*Event{}
 	}
 	a.Events[e.Name] = e
+	// overloaded events share the name, keep all of them by signature
+	if len(a.EventsBySig) == 0 {
+		a.EventsBySig = map[string]*Event{}
+	}
+	a.EventsBySig[e.Sig()] = e
+}
+
+// GetMethod returns the method by name or by signature (ie. transfer(address,uint256)).
+// The signature is required to get an overloaded method other than the last one declared.
+func (a *ABI) GetMethod(nameOrSig string) *Method {
+	if m, ok := a.Methods[nameOrSig]; ok {
+		return m
+	}
+	return a.MethodsBySig[nameOrSig]
+}
+
+// GetEvent returns the event by name or by signature (ie. Transfer(address,address,uint256))
+func (a *ABI) GetEvent(nameOrSig string) *Event {
+	if e, ok := a.Events[nameOrSig]; ok {
+		return e
+	}
+	return a.EventsBySig[nameOrSig]
 }
 
 func (a *ABI) addMethod(m *Method) {
@@ -73,6 +97,7 @@ func (a *ABI) UnmarshalJSON(data []byte) error {
 		Type            string
 		Name            string
 		Constant        bool
+		Payable         bool
 		Anonymous       bool
 		StateMutability string
 		Inputs          arguments
@@ -88,13 +113,20 @@ func (a *ABI) UnmarshalJSON(data []byte) error {
 	a.Errors = make(map[string]*Error)
 
 	for _, field := range fields {
+		mutability := field.StateMutability
+		if mutability == "" && field.Payable {
+			// legacy abi without stateMutability
+			mutability = "payable"
+		}
+
 		switch field.Type {
 		case "constructor":
 			if a.Constructor != nil {
 				return fmt.Errorf("multiple constructor declaration")
 			}
 			a.Constructor = &Method{
-				Inputs: field.Inputs.Type(),
+				Inputs:          field.Inputs.Type(),
+				StateMutability: mutability,
 			}
 
 		case "function", "":
@@ -104,23 +136,34 @@ func (a *ABI) UnmarshalJSON(data []byte) error {
 			}
 
 			m := &Method{
-				Name:    field.Name,
-				Const:   c,
-				Inputs:  field.Inputs.Type(),
-				Outputs: field.Outputs.Type(),
+				Name:            field.Name,
+				Const:           c,
+				Inputs:          field.Inputs.Type(),
+				Outputs:         field.Outputs.Type(),
+				StateMutability: mutability,
 			}
 			a.addMethod(m)
 
 		case "event":
-			a.Events[field.Name] = &Event{
+			a.addEvent(&Event{
 				Name:      field.Name,
 				Anonymous: field.Anonymous,
 				Inputs:    field.Inputs.Type(),
-			}
+			})
 
 		case "fallback":
+			a.Fallback = &Method{
+				Inputs:          field.Inputs.Type(),
+				Outputs:         field.Outputs.Type(),
+				StateMutability: mutability,
+			}
+
 		case "receive":
-			// do nothing
+			a.Receive = &Method{
+				Inputs:          field.Inputs.Type(),
+				Outputs:         field.Outputs.Type(),
+				StateMutability: mutability,
+			}
 		case "error":
 			a.Errors[field.Name] = &Error{
 				field.Name,
@@ -193,15 +236,19 @@ type Method struct {
 	Const   bool
 	Inputs  *Type
 	Outputs *Type
+
+	// StateMutability is one of pure, view, nonpayable or payable
+	StateMutability string
 }
 
 // Copy is lightly copy inside inputs, do not modify inner pointer objects.
 func (m *Method) Copy() *Method {
 	return &Method{
-		m.Name,
-		m.Const,
-		m.Inputs.Copy(),
-		m.Outputs.Copy(),
+		Name:            m.Name,
+		Const:           m.Const,
+		Inputs:          m.Inputs.Copy(),
+		Outputs:         m.Outputs.Copy(),
+		StateMutability: m.StateMutability,
 	}
 }
 
@@ -225,11 +272,12 @@ func (m *Method) ID() []byte {
 }
 
 func NewMethod(sig string) (*Method, error) {
-	name, inputs, outputs, err := parseMethodSignature(sig)
+	name, inputs, outputs, mutability, err := parseMethodSignature(sig)
 	if err != nil {
 		return nil, err
 	}
-	m := &Method{Name: name, Inputs: inputs, Outputs: outputs}
+	m := &Method{Name: name, Inputs: inputs, Outputs: outputs, StateMutability: mutability}
+	m.Const = mutability == "view" || mutability == "pure"
 	return m, nil
 }
 
@@ -257,30 +305,71 @@ func (self *Method) MustEncodeIDAndInput(args ...interface{}) []byte {
 	return data
 }
 
-var funcRegexp = regexp.MustCompile("(.*)\\((.*)\\)(.*) returns \\((.*)\\)")
+// splitSignature splits 'name(args) rest' into its parts, args may contain nested parenthesis
+func splitSignature(sig string) (string, string, string, error) {
+	indx := strings.Index(sig, "(")
+	if indx == -1 {
+		return "", "", "", fmt.Errorf("failed to parse input, expected 'name(types)'")
+	}
+	depth := 0
+	for i := indx; i < len(sig); i++ {
+		switch sig[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(sig[:indx]), sig[indx+1 : i], strings.TrimSpace(sig[i+1:]), nil
+			}
+		}
+	}
+	return "", "", "", fmt.Errorf("failed to parse input, unbalanced parenthesis")
+}
+
+// parseMethodSignature parses 'name(inputs) [modifiers] [returns (outputs)]'
+func parseMethodSignature(name string) (string, *Type, *Type, string, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "function ")
 
-func parseMethodSignature(name string) (string, *Type, *Type, error) {
-	name = strings.TrimPrefix(name, "function ")
+	funcName, inputArgs, rest, err := splitSignature(name)
+	if err != nil {
+		return "", nil, nil, "", err
+	}
 
-	matches := funcRegexp.FindAllStringSubmatch(name, -1)
-	if len(matches) == 0 {
-		return "", nil, nil, fmt.Errorf("no matches found")
+	modifiers, outputArgs := rest, ""
+	if indx := strings.Index(rest, "returns"); indx != -1 {
+		modifiers = rest[:indx]
+		prefix, args, tail, err := splitSignature(rest[indx+len("returns"):])
+		if err != nil {
+			return "", nil, nil, "", err
+		}
+		if prefix != "" || tail != "" {
+			return "", nil, nil, "", fmt.Errorf("failed to parse outputs '%s'", rest)
+		}
+		outputArgs = args
 	}
-	match := matches[0]
 
-	funcName := strings.TrimSpace(match[1])
-	inputArgs := strings.TrimSpace(match[2])
-	outputArgs := strings.TrimSpace(match[4])
+	mutability := "nonpayable"
+	for _, modifier := range strings.Fields(modifiers) {
+		switch modifier {
+		case "view", "pure", "payable", "nonpayable":
+			mutability = modifier
+		case "constant":
+			mutability = "view"
+		case "external", "public":
+		default:
+			return "", nil, nil, "", fmt.Errorf("unknown modifier '%s'", modifier)
+		}
+	}
 
-	input, err := NewType("tuple(" + inputArgs + ")")
+	input, err := NewType("tuple(" + strings.TrimSpace(inputArgs) + ")")
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, nil, "", err
 	}
-	output, err := NewType("tuple(" + outputArgs + ")")
+	output, err := NewType("tuple(" + strings.TrimSpace(outputArgs) + ")")
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, nil, "", err
 	}
-	return funcName, input, output, nil
+	return funcName, input, output, mutability, nil
 }
 
 // Event is a triggered log mechanism
@@ -329,31 +418,32 @@ func MustNewEvent(name string) *Event {
 
 // NewEvent creates a new solidity event object using the signature
 func NewEvent(name string) (*Event, error) {
-	name, typ, err := parseEventSignature(name)
+	name, typ, anonymous, err := parseEventSignature(name)
 	if err != nil {
 		return nil, err
 	}
-	return NewEventFromType(name, typ), nil
+	evnt := NewEventFromType(name, typ)
+	evnt.Anonymous = anonymous
+	return evnt, nil
 }
 
-func parseEventSignature(name string) (string, *Type, error) {
-	name = strings.TrimPrefix(name, "event ")
-	if !strings.HasSuffix(name, ")") {
-		return "", nil, fmt.Errorf("failed to parse input, expected 'name(types)'")
+// parseEventSignature parses 'name(types) [anonymous]'
+func parseEventSignature(name string) (string, *Type, bool, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "event ")
+
+	funcName, args, rest, err := splitSignature(name)
+	if err != nil {
+		return "", nil, false, err
 	}
-	indx := strings.Index(name, "(")
-	if indx == -1 {
-		return "", nil, fmt.Errorf("failed to parse input, expected 'name(types)'")
+	if rest != "" && rest != "anonymous" {
+		return "", nil, false, fmt.Errorf("failed to parse input, expected 'name(types)'")
 	}
 
-	funcName, signature := name[:indx], name[indx:]
-	signature = "tuple" + signature
-
-	typ, err := NewType(signature)
+	typ, err := NewType("tuple(" + args + ")")
 	if err != nil {
-		return "", nil, err
+		return "", nil, false, err
 	}
-	return funcName, typ, nil
+	return funcName, typ, rest == "anonymous", nil
 }
 
 // NewEventFromType creates a new solidity event object using the name and type
@@ -397,9 +487,10 @@ func buildHumanSignature(name string, typ *Type) string {
 }
 
 type argument struct {
-	Name    string
-	Type    *Type
-	Indexed bool
+	Name         string
+	Type         *Type
+	InternalType string
+	Indexed      bool
 }
 
 type arguments []*argument
@@ -408,9 +499,10 @@ func (a *arguments) Type() *Type {
 	inputs := []*TupleElem{}
 	for _, i := range *a {
 		inputs = append(inputs, &TupleElem{
-			Name:    i.Name,
-			Elem:    i.Type,
-			Indexed: i.Indexed,
+			Name:         i.Name,
+			Elem:         i.Type,
+			InternalType: i.InternalType,
+			Indexed:      i.Indexed,
 		})
 	}
 
@@ -438,8 +530,11 @@ func (a *argument) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	setInternalTypes(t, arg)
+
 	a.Type = t
 	a.Name = arg.Name
+	a.InternalType = arg.InternalType
 	a.Indexed = arg.Indexed
 	return nil
 }
@@ -468,9 +563,36 @@ func releaseKeccak(k hash.Hash) {
 	keccakPool.Put(k)
 }
 
+// NewError creates a new solidity error object using the signature
+func NewError(sig string) (*Error, error) {
+	sig = strings.TrimPrefix(strings.TrimSpace(sig), "error ")
+	name, args, rest, err := splitSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("failed to parse input, expected 'name(types)'")
+	}
+	typ, err := NewType("tuple(" + args + ")")
+	if err != nil {
+		return nil, err
+	}
+	return &Error{Name: name, Inputs: typ}, nil
+}
+
+// MustNewError creates a new solidity error object or panics
+func MustNewError(sig string) *Error {
+	e, err := NewError(sig)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
 func NewABIFromList(humanReadableAbi []string) (*ABI, error) {
 	res := &ABI{}
 	for _, c := range humanReadableAbi {
+		c = strings.TrimSpace(c)
 		if strings.HasPrefix(c, "function ") {
 			method, err := NewMethod(c)
 			if err != nil {
@@ -483,8 +605,33 @@ func NewABIFromList(humanReadableAbi []string) (*ABI, error) {
 				return nil, err
 			}
 			res.addEvent(evnt)
+		} else if strings.HasPrefix(c, "error ") {
+			e, err := NewError(c)
+			if err != nil {
+				return nil, err
+			}
+			if len(res.Errors) == 0 {
+				res.Errors = map[string]*Error{}
+			}
+			res.Errors[e.Name] = e
+		} else if strings.HasPrefix(c, "constructor") || strings.HasPrefix(c, "fallback") || strings.HasPrefix(c, "receive") {
+			method, err := NewMethod(c)
+			if err != nil {
+				return nil, err
+			}
+			switch method.Name {
+			case "constructor":
+				res.Constructor = method
+			case "fallback":
+				res.Fallback = method
+			case "receive":
+				res.Receive = method
+			default:
+				return nil, fmt.Errorf("unknown fragment '%s'", c)
+			}
+			method.Name = ""
 		} else {
-			return nil, fmt.Errorf("either event or function expected")
+			return nil, fmt.Errorf("either event, error or function expected")
 		}
 	}
 	return res, nil
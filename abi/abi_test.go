@@ -54,3 +54,30 @@ func TestAbi_HumanReadable(t *testing.T) {
 
 	fmt.Println(vv.Methods["symbol"].Inputs.String())
 }
+
+func TestAbi_Overloaded(t *testing.T) {
+	abi, err := NewABI(`[
+		{"type": "function", "name": "safeTransferFrom", "inputs": [{"type": "address"}, {"type": "address"}, {"type": "uint256"}]},
+		{"type": "function", "name": "safeTransferFrom", "inputs": [{"type": "address"}, {"type": "address"}, {"type": "uint256"}, {"type": "bytes"}]},
+		{"type": "event", "name": "Transfer", "inputs": [{"type": "address", "indexed": true}, {"type": "uint256"}]},
+		{"type": "event", "name": "Transfer", "inputs": [{"type": "address", "indexed": true}, {"type": "uint256", "indexed": true}, {"type": "bytes"}]}
+	]`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "safeTransferFrom(address,address,uint256,bytes)", abi.GetMethod("safeTransferFrom").Sig())
+	assert.Equal(t, "safeTransferFrom(address,address,uint256)", abi.GetMethod("safeTransferFrom(address,address,uint256)").Sig())
+	assert.Nil(t, abi.GetMethod("safeTransferFrom(address)"))
+
+	assert.Len(t, abi.EventsBySig, 2)
+	assert.Equal(t, "Transfer(address,uint256,bytes)", abi.GetEvent("Transfer").Sig())
+	assert.Len(t, abi.GetEvent("Transfer(address,uint256)").Inputs.TupleElems(), 2)
+	assert.Nil(t, abi.GetEvent("Approval"))
+
+	// both overloads are kept when marshaled
+	data, err := abi.MarshalJSON()
+	assert.NoError(t, err)
+	abi2, err := NewABI(string(data))
+	assert.NoError(t, err)
+	assert.Len(t, abi2.MethodsBySig, 2)
+	assert.Len(t, abi2.EventsBySig, 2)
+}
@@ -7,7 +7,6 @@ import (
 	"reflect"
 
 	"github.com/laizy/web3"
-	"github.com/mitchellh/mapstructure"
 )
 
 // Decode decodes the input with a given type
@@ -19,16 +18,14 @@ func Decode(t *Type, input []byte) (interface{}, error) {
 	return val, err
 }
 
-// DecodeStruct decodes the input with a type to a struct
+// DecodeStruct decodes the input with a type to a struct, the fields are matched as in
+// DecodeInto so the 'mapstructure' tags keep working
 func DecodeStruct(t *Type, input []byte, out interface{}) error {
 	val, err := Decode(t, input)
 	if err != nil {
 		return err
 	}
-	if err := mapstructure.Decode(val, out); err != nil {
-		return err
-	}
-	return nil
+	return DecodeInto(val, out)
 }
 
 func decode(t *Type, input []byte) (interface{}, []byte, error) {
@@ -84,6 +81,9 @@ func decode(t *Type, input []byte) (interface{}, []byte, error) {
 	case KindFunction:
 		val, err = readFunctionType(t, data)
 
+	case KindFixedPoint:
+		val = readFixedPoint(t, data)
+
 	default:
 		return nil, nil, fmt.Errorf("decoding not available for type '%s'", t.kind)
 	}
@@ -150,15 +150,24 @@ func readInteger(t *Type, b []byte) interface{} {
 	}
 }
 
-func readFunctionType(t *Type, word []byte) ([24]byte, error) {
-	res := [24]byte{}
+func readFunctionType(t *Type, word []byte) (Function, error) {
+	res := Function{}
 	if !allZeros(word[24:32]) {
 		return res, fmt.Errorf("function type expects the last 8 bytes to be empty but found: %b", word[24:32])
 	}
-	copy(res[:], word[0:24])
+	copy(res.Address[:], word[0:20])
+	copy(res.Selector[:], word[20:24])
 	return res, nil
 }
 
+func readFixedPoint(t *Type, word []byte) *big.Rat {
+	num := new(big.Int).SetBytes(word)
+	if t.isSigned() && num.Cmp(maxInt256) > 0 {
+		num.Sub(num, tt256)
+	}
+	return new(big.Rat).SetFrac(num, pow10(t.decimals))
+}
+
 func readFixedBytes(t *Type, word []byte) (interface{}, error) {
 	array := reflect.New(t.t).Elem()
 	reflect.Copy(array, reflect.ValueOf(word[0:t.size]))
@@ -45,9 +45,15 @@ func encode(v reflect.Value, t *Type) ([]byte, error) {
 	case KindBytes:
 		return encodeBytes(v)
 
-	case KindFixedBytes, KindFunction:
+	case KindFixedPoint:
+		return encodeFixedPoint(v, t)
+
+	case KindFixedBytes:
 		return encodeFixedBytes(v)
 
+	case KindFunction:
+		return encodeFunction(v)
+
 	default:
 		return nil, fmt.Errorf("encoding not available for type '%s'", t.kind)
 	}
@@ -196,6 +202,84 @@ func encodeFixedBytes(v reflect.Value) ([]byte, error) {
 	return rightPad(v.Bytes(), 32), nil
 }
 
+func encodeFunction(v reflect.Value) ([]byte, error) {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Type() == functionT {
+		return rightPad(v.Interface().(Function).Bytes(), 32), nil
+	}
+	if v.Kind() == reflect.Array {
+		v = convertArrayToBytes(v)
+	}
+	if v.Kind() != reflect.Slice || v.Len() != 24 {
+		return nil, encodeErr(v, "function")
+	}
+	return rightPad(v.Bytes(), 32), nil
+}
+
+func encodeFixedPoint(v reflect.Value, t *Type) ([]byte, error) {
+	rat, err := toRat(v)
+	if err != nil {
+		return nil, err
+	}
+	scaled := new(big.Rat).Mul(rat, new(big.Rat).SetInt(pow10(t.decimals)))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("value %s has more than %d decimals", rat.RatString(), t.decimals)
+	}
+	num := scaled.Num()
+	if !fitsInBits(num, t.size, t.isSigned()) {
+		return nil, fmt.Errorf("value %s out of range for %s", rat.RatString(), t.raw)
+	}
+	return toU256(num), nil
+}
+
+func toRat(v reflect.Value) (*big.Rat, error) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch obj := v.Interface().(type) {
+	case *big.Rat:
+		return obj, nil
+	case big.Rat:
+		return &obj, nil
+	case *big.Int:
+		return new(big.Rat).SetInt(obj), nil
+	case string:
+		rat, ok := new(big.Rat).SetString(obj)
+		if !ok {
+			return nil, fmt.Errorf("invalid decimal '%s'", obj)
+		}
+		return rat, nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		rat := new(big.Rat).SetFloat64(v.Float())
+		if rat == nil {
+			return nil, fmt.Errorf("invalid float %v", v.Float())
+		}
+		return rat, nil
+	}
+	return nil, encodeErr(v, "fixed point")
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// fitsInBits checks whether the number can be represented with the given bits
+func fitsInBits(num *big.Int, bits int, signed bool) bool {
+	if !signed {
+		return num.Sign() >= 0 && num.BitLen() <= bits
+	}
+	limit := new(big.Int).Lsh(one, uint(bits-1))
+	return num.Cmp(limit) < 0 && num.Cmp(new(big.Int).Neg(limit)) >= 0
+}
+
 func encodeAddress(v reflect.Value) ([]byte, error) {
 	if v.Kind() == reflect.Array {
 		v = convertArrayToBytes(v)
@@ -14,6 +14,7 @@ import (
 
 	"github.com/mitchellh/mapstructure"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/laizy/web3"
@@ -409,7 +410,7 @@ func testEncodeDecode(t *testing.T, server *testutil.TestServer, tt *Type, input
 	if !reflect.DeepEqual(res2, input) {
 		return fmt.Errorf("bad")
 	}
-	if tt.kind == KindTuple {
+	if tt.kind == KindTuple && supportedBySolc(tt) {
 		if err := testTypeWithContract(t, server, tt); err != nil {
 			return err
 		}
@@ -646,3 +647,69 @@ func TestEncodeDecodeCall(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodingFixedPoint(t *testing.T) {
+	cases := []struct {
+		Type    string
+		Input   interface{}
+		Encoded string
+		Output  string
+	}{
+		{"ufixed128x18", "1.5", "0x" + fmt.Sprintf("%064x", 1500000000000000000), "3/2"},
+		{"fixed128x18", big.NewRat(-1, 4), "0xfffffffffffffffffffffffffffffffffffffffffffffffffc87d25316270000", "-1/4"},
+		{"fixed", int64(2), "0x" + fmt.Sprintf("%064x", 2000000000000000000), "2"},
+		{"fixed8x1", "-12.8", "0x" + strings.Repeat("f", 62) + "80", "-64/5"},
+	}
+	for _, c := range cases {
+		typ, err := NewType(c.Type)
+		assert.NoError(t, err)
+
+		encoded, err := typ.Encode(c.Input)
+		assert.NoError(t, err)
+		assert.Equal(t, c.Encoded, encodeHex(encoded))
+
+		decoded, err := typ.Decode(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, c.Output, decoded.(*big.Rat).RatString())
+	}
+
+	// too many decimals
+	_, err := MustNewType("fixed128x2").Encode("1.001")
+	assert.Error(t, err)
+
+	// out of range
+	_, err = MustNewType("fixed8x1").Encode("12.8")
+	assert.Error(t, err)
+	_, err = MustNewType("ufixed8x1").Encode("-0.1")
+	assert.Error(t, err)
+
+	// invalid types
+	for _, str := range []string{"fixed7x1", "fixed128", "ufixed128x81", "uint256x18"} {
+		_, err := NewType(str)
+		assert.Error(t, err, str)
+	}
+}
+
+func TestEncodingFunction(t *testing.T) {
+	typ := MustNewType("tuple(function a, function[] b)")
+	f := Function{Address: web3.Address{0x1}, Selector: [4]byte{0xa9, 0x05, 0x9c, 0xbb}}
+
+	encoded, err := typ.Encode(map[string]interface{}{
+		"a": f,
+		"b": []Function{f, {}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x0100000000000000000000000000000000000000a9059cbb0000000000000000", encodeHex(encoded[:32]))
+
+	decoded, err := typ.Decode(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, f, decoded.(map[string]interface{})["a"])
+	assert.Equal(t, []Function{f, {}}, decoded.(map[string]interface{})["b"])
+
+	// raw 24 bytes are accepted too
+	var raw [24]byte
+	copy(raw[:], f.Bytes())
+	encoded2, err := MustNewType("function").Encode(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, encoded[:32], encoded2)
+}
@@ -0,0 +1,253 @@
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MarshalJSON implements json.Marshaler interface
+func (a *ABI) MarshalJSON() ([]byte, error) {
+	fields := []map[string]interface{}{}
+
+	if a.Constructor != nil {
+		fields = append(fields, map[string]interface{}{
+			"type":            "constructor",
+			"inputs":          marshalArguments(a.Constructor.Inputs, false),
+			"stateMutability": a.Constructor.mutability(),
+		})
+	}
+	for _, m := range a.sortedMethods() {
+		fields = append(fields, map[string]interface{}{
+			"type":            "function",
+			"name":            m.Name,
+			"inputs":          marshalArguments(m.Inputs, false),
+			"outputs":         marshalArguments(m.Outputs, false),
+			"stateMutability": m.mutability(),
+		})
+	}
+	for _, e := range a.sortedEvents() {
+		fields = append(fields, map[string]interface{}{
+			"type":      "event",
+			"name":      e.Name,
+			"inputs":    marshalArguments(e.Inputs, true),
+			"anonymous": e.Anonymous,
+		})
+	}
+	for _, e := range a.sortedErrors() {
+		fields = append(fields, map[string]interface{}{
+			"type":   "error",
+			"name":   e.Name,
+			"inputs": marshalArguments(e.Inputs, false),
+		})
+	}
+	if a.Fallback != nil {
+		fields = append(fields, map[string]interface{}{
+			"type":            "fallback",
+			"stateMutability": a.Fallback.mutability(),
+		})
+	}
+	if a.Receive != nil {
+		fields = append(fields, map[string]interface{}{
+			"type":            "receive",
+			"stateMutability": "payable",
+		})
+	}
+	return json.Marshal(fields)
+}
+
+// HumanReadable returns the abi in the human readable format used by ethers
+func (a *ABI) HumanReadable() []string {
+	res := []string{}
+	if a.Constructor != nil {
+		res = append(res, "constructor("+formatArguments(a.Constructor.Inputs)+")"+formatPayable(a.Constructor))
+	}
+	for _, m := range a.sortedMethods() {
+		res = append(res, m.HumanReadable())
+	}
+	for _, e := range a.sortedEvents() {
+		res = append(res, e.HumanReadable())
+	}
+	for _, e := range a.sortedErrors() {
+		res = append(res, e.HumanReadable())
+	}
+	if a.Fallback != nil {
+		res = append(res, "fallback()"+formatPayable(a.Fallback))
+	}
+	if a.Receive != nil {
+		res = append(res, "receive() external payable")
+	}
+	return res
+}
+
+func formatPayable(m *Method) string {
+	if m.mutability() == "payable" {
+		return " payable"
+	}
+	return ""
+}
+
+// HumanReadable returns the method in the human readable format used by ethers
+func (m *Method) HumanReadable() string {
+	str := "function " + m.Name + "(" + formatArguments(m.Inputs) + ")"
+	if mutability := m.mutability(); mutability != "nonpayable" {
+		str += " " + mutability
+	}
+	if m.Outputs != nil && len(m.Outputs.tuple) != 0 {
+		str += " returns (" + formatArguments(m.Outputs) + ")"
+	}
+	return str
+}
+
+// HumanReadable returns the event in the human readable format used by ethers
+func (e *Event) HumanReadable() string {
+	str := "event " + e.Name + "(" + formatArguments(e.Inputs) + ")"
+	if e.Anonymous {
+		str += " anonymous"
+	}
+	return str
+}
+
+// HumanReadable returns the error in the human readable format used by ethers
+func (e *Error) HumanReadable() string {
+	return "error " + e.Name + "(" + formatArguments(e.Inputs) + ")"
+}
+
+func (m *Method) mutability() string {
+	if m.StateMutability != "" {
+		return m.StateMutability
+	}
+	if m.Const {
+		return "view"
+	}
+	return "nonpayable"
+}
+
+func (a *ABI) sortedMethods() []*Method {
+	methods := make([]*Method, 0, len(a.MethodsBySig))
+	for _, m := range a.MethodsBySig {
+		methods = append(methods, m)
+	}
+	// methods created without addMethod are only found by name
+	for _, m := range a.Methods {
+		if _, ok := a.MethodsBySig[m.Sig()]; !ok {
+			methods = append(methods, m)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Sig() < methods[j].Sig()
+	})
+	return methods
+}
+
+func (a *ABI) sortedEvents() []*Event {
+	events := make([]*Event, 0, len(a.EventsBySig))
+	for _, e := range a.EventsBySig {
+		events = append(events, e)
+	}
+	// events created without addEvent are only found by name
+	for _, e := range a.Events {
+		if _, ok := a.EventsBySig[e.Sig()]; !ok {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Sig() < events[j].Sig()
+	})
+	return events
+}
+
+func (a *ABI) sortedErrors() []*Error {
+	errors := make([]*Error, 0, len(a.Errors))
+	for _, e := range a.Errors {
+		errors = append(errors, e)
+	}
+	sort.Slice(errors, func(i, j int) bool {
+		return errors[i].Sig() < errors[j].Sig()
+	})
+	return errors
+}
+
+func marshalArguments(t *Type, event bool) []map[string]interface{} {
+	res := []map[string]interface{}{}
+	if t == nil {
+		return res
+	}
+	for _, elem := range t.tuple {
+		arg := marshalArgument(elem)
+		if event {
+			arg["indexed"] = elem.Indexed
+		}
+		res = append(res, arg)
+	}
+	return res
+}
+
+func marshalArgument(elem *TupleElem) map[string]interface{} {
+	arg := map[string]interface{}{
+		"name": elem.Name,
+		"type": elem.Elem.jsonType(),
+	}
+	if elem.InternalType != "" {
+		arg["internalType"] = elem.InternalType
+	}
+	if tuple := elem.Elem.baseElem(); tuple.kind == KindTuple {
+		arg["components"] = marshalArguments(tuple, false)
+	}
+	return arg
+}
+
+// jsonType returns the type as used in the json abi, tuples are represented with the 'tuple' keyword
+func (t *Type) jsonType() string {
+	switch t.kind {
+	case KindTuple:
+		return "tuple"
+	case KindSlice:
+		return t.elem.jsonType() + "[]"
+	case KindArray:
+		return fmt.Sprintf("%s[%d]", t.elem.jsonType(), t.size)
+	default:
+		return t.raw
+	}
+}
+
+// humanType returns the type with the tuple components expanded
+func (t *Type) humanType() string {
+	switch t.kind {
+	case KindTuple:
+		return "tuple(" + formatArguments(t) + ")"
+	case KindSlice:
+		return t.elem.humanType() + "[]"
+	case KindArray:
+		return fmt.Sprintf("%s[%d]", t.elem.humanType(), t.size)
+	default:
+		return t.raw
+	}
+}
+
+// baseElem returns the innermost element of slices and arrays
+func (t *Type) baseElem() *Type {
+	for t.kind == KindSlice || t.kind == KindArray {
+		t = t.elem
+	}
+	return t
+}
+
+func formatArguments(t *Type) string {
+	if t == nil {
+		return ""
+	}
+	args := make([]string, len(t.tuple))
+	for i, elem := range t.tuple {
+		str := elem.Elem.humanType()
+		if elem.Indexed {
+			str += " indexed"
+		}
+		if elem.Name != "" {
+			str += " " + elem.Name
+		}
+		args[i] = str
+	}
+	return strings.Join(args, ", ")
+}
@@ -0,0 +1,71 @@
+package abi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var abiFullStr = `[
+	{"inputs":[{"internalType":"string","name":"symbol","type":"string"}],"stateMutability":"payable","type":"constructor"},
+	{"inputs":[{"internalType":"uint256","name":"have","type":"uint256"},{"internalType":"uint256","name":"want","type":"uint256"}],"name":"InsufficientBalance","type":"error"},
+	{"anonymous":true,"inputs":[{"indexed":true,"internalType":"address","name":"from","type":"address"},{"indexed":false,"internalType":"struct Sample.Point[2]","name":"points","type":"tuple[2]","components":[{"internalType":"int64","name":"x","type":"int64"},{"internalType":"int64","name":"y","type":"int64"}]}],"name":"Moved","type":"event"},
+	{"inputs":[{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"id","type":"uint256"}],"name":"safeTransferFrom","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"id","type":"uint256"},{"internalType":"bytes","name":"data","type":"bytes"}],"name":"safeTransferFrom","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[],"name":"get","outputs":[{"internalType":"struct Sample.Nested[]","name":"","type":"tuple[]","components":[{"internalType":"struct Sample.Point","name":"p","type":"tuple","components":[{"internalType":"int64","name":"x","type":"int64"},{"internalType":"int64","name":"y","type":"int64"}]},{"internalType":"string","name":"label","type":"string"}]}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"deposit","outputs":[],"stateMutability":"payable","type":"function"},
+	{"inputs":[],"name":"version","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"pure","type":"function"},
+	{"stateMutability":"payable","type":"fallback"},
+	{"stateMutability":"payable","type":"receive"}
+]`
+
+func TestABI_MarshalJSON(t *testing.T) {
+	for _, str := range []string{abiFullStr, abiSampleStr} {
+		abi, err := NewABI(str)
+		assert.NoError(t, err)
+
+		data, err := json.Marshal(abi)
+		assert.NoError(t, err)
+
+		abi2, err := NewABI(string(data))
+		assert.NoError(t, err)
+		assert.Equal(t, abi, abi2)
+
+		// the output matches the input modulo the order of the fragments
+		var expected, found []interface{}
+		assert.NoError(t, json.Unmarshal([]byte(str), &expected))
+		assert.NoError(t, json.Unmarshal(data, &found))
+		assert.ElementsMatch(t, expected, found)
+	}
+}
+
+func TestABI_HumanReadable(t *testing.T) {
+	abi, err := NewABI(abiFullStr)
+	assert.NoError(t, err)
+
+	human := abi.HumanReadable()
+	assert.Equal(t, []string{
+		"constructor(string symbol) payable",
+		"function deposit() payable",
+		"function get() view returns (tuple(tuple(int64 x, int64 y) p, string label)[])",
+		"function safeTransferFrom(address to, uint256 id)",
+		"function safeTransferFrom(address to, uint256 id, bytes data)",
+		"function version() pure returns (uint8)",
+		"event Moved(address indexed from, tuple(int64 x, int64 y)[2] points) anonymous",
+		"error InsufficientBalance(uint256 have, uint256 want)",
+		"fallback() payable",
+		"receive() external payable",
+	}, human)
+
+	abi2, err := NewABIFromList(human)
+	assert.NoError(t, err)
+	assert.Equal(t, human, abi2.HumanReadable())
+
+	for sig, m := range abi.MethodsBySig {
+		assert.Equal(t, m.ID(), abi2.MethodsBySig[sig].ID())
+		assert.Equal(t, m.Const, abi2.MethodsBySig[sig].Const)
+	}
+	assert.Equal(t, abi.Events["Moved"].ID(), abi2.Events["Moved"].ID())
+	assert.Equal(t, abi.Errors["InsufficientBalance"].ID(), abi2.Errors["InsufficientBalance"].ID())
+}
@@ -0,0 +1,160 @@
+package abi
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/laizy/web3"
+)
+
+// EncodePacked encodes a value using the non-standard packed mode of
+// abi.encodePacked. A top level tuple is handled as the list of arguments.
+func EncodePacked(v interface{}, t *Type) ([]byte, error) {
+	val := reflect.ValueOf(v)
+	if t.kind == KindTuple {
+		return encodePackedArgs(val, t)
+	}
+	return encodePacked(val, t, false)
+}
+
+// EncodePacked encodes an object using this type in packed mode
+func (t *Type) EncodePacked(v interface{}) ([]byte, error) {
+	return EncodePacked(v, t)
+}
+
+// SolidityPack encodes the values with abi.encodePacked semantics
+func SolidityPack(types []string, values []interface{}) ([]byte, error) {
+	if len(types) != len(values) {
+		return nil, fmt.Errorf("types and values length mismatch: %d != %d", len(types), len(values))
+	}
+	var ret []byte
+	for i, typ := range types {
+		t, err := NewType(typ)
+		if err != nil {
+			return nil, err
+		}
+		if t.kind == KindTuple {
+			return nil, fmt.Errorf("tuple not supported in packed mode")
+		}
+		val, err := encodePacked(reflect.ValueOf(values[i]), t, false)
+		if err != nil {
+			return nil, fmt.Errorf("arg %d: %v", i, err)
+		}
+		ret = append(ret, val...)
+	}
+	return ret, nil
+}
+
+// SolidityKeccak returns keccak256(abi.encodePacked(values...))
+func SolidityKeccak(types []string, values []interface{}) (web3.Hash, error) {
+	data, err := SolidityPack(types, values)
+	if err != nil {
+		return web3.Hash{}, err
+	}
+	k := acquireKeccak()
+	k.Write(data)
+	var res web3.Hash
+	copy(res[:], k.Sum(nil))
+	releaseKeccak(k)
+	return res, nil
+}
+
+func encodePackedArgs(v reflect.Value, t *Type) ([]byte, error) {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, encodeErr(v, "packed arguments")
+	}
+	if v.Len() != len(t.tuple) {
+		return nil, fmt.Errorf("expected %d arguments but found %d", len(t.tuple), v.Len())
+	}
+	var ret []byte
+	for i, elem := range t.tuple {
+		if elem.Elem.kind == KindTuple {
+			return nil, fmt.Errorf("tuple not supported in packed mode")
+		}
+		val, err := encodePacked(v.Index(i), elem.Elem, false)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, val...)
+	}
+	return ret, nil
+}
+
+// encodePacked encodes a single value, the elements inside of an array
+// are padded to 32 bytes as in the standard encoding
+func encodePacked(v reflect.Value, t *Type, inArray bool) ([]byte, error) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	switch t.kind {
+	case KindSlice, KindArray:
+		if t.elem.kind == KindTuple || t.elem.isDynamicType() {
+			return nil, fmt.Errorf("type '%s' not supported in packed mode", t.raw)
+		}
+		if v.Kind() != reflect.Array && v.Kind() != reflect.Slice {
+			return nil, encodeErr(v, t.kind.String())
+		}
+		if t.kind == KindArray && t.size != v.Len() {
+			return nil, fmt.Errorf("array len incompatible")
+		}
+		var ret []byte
+		for i := 0; i < v.Len(); i++ {
+			val, err := encodePacked(v.Index(i), t.elem, true)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, val...)
+		}
+		return ret, nil
+
+	case KindString:
+		if v.Kind() != reflect.String {
+			return nil, encodeErr(v, "string")
+		}
+		return []byte(v.String()), nil
+
+	case KindBytes:
+		if v.Kind() == reflect.Array {
+			v = convertArrayToBytes(v)
+		}
+		if v.Kind() != reflect.Slice {
+			return nil, encodeErr(v, "bytes")
+		}
+		return append([]byte{}, v.Bytes()...), nil
+
+	case KindTuple:
+		return nil, fmt.Errorf("tuple not supported in packed mode")
+	}
+
+	val, err := encode(v, t)
+	if err != nil {
+		return nil, err
+	}
+	if inArray {
+		return val, nil
+	}
+
+	switch t.kind {
+	case KindBool:
+		return val[31:], nil
+
+	case KindAddress:
+		return val[12:], nil
+
+	case KindInt, KindUInt, KindFixedPoint:
+		return val[32-t.size/8:], nil
+
+	case KindFixedBytes:
+		return val[:t.size], nil
+
+	case KindFunction:
+		return val[:24], nil
+
+	default:
+		return nil, fmt.Errorf("type '%s' not supported in packed mode", t.raw)
+	}
+}
@@ -0,0 +1,229 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/compiler"
+	"github.com/laizy/web3/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodePacked(t *testing.T) {
+	cases := []struct {
+		Types  []string
+		Values []interface{}
+		Packed string
+	}{
+		{
+			[]string{"int16", "uint48"},
+			[]interface{}{int16(-1), big.NewInt(12)},
+			"0xffff00000000000c",
+		},
+		{
+			[]string{"string", "uint8"},
+			[]interface{}{"Hello", uint8(3)},
+			"0x48656c6c6f03",
+		},
+		{
+			[]string{"int8", "bytes1", "uint16", "bytes4", "bool", "string"},
+			[]interface{}{int8(-1), [1]byte{0x42}, uint16(0x03), [4]byte{0x12, 0x34, 0x56, 0x78}, true, "Hello, world!"},
+			"0xff42000312345678" + "01" + "48656c6c6f2c20776f726c6421",
+		},
+		{
+			[]string{"address", "bytes"},
+			[]interface{}{web3.Address{19: 0x1}, []byte{0xaa, 0xbb}},
+			"0x0000000000000000000000000000000000000001aabb",
+		},
+		{
+			// array elements are padded to 32 bytes
+			[]string{"uint8[]", "bool[2]"},
+			[]interface{}{[]uint8{1, 2}, [2]bool{true, false}},
+			"0x" + strings.Repeat("00", 31) + "01" + strings.Repeat("00", 31) + "02" +
+				strings.Repeat("00", 31) + "01" + strings.Repeat("00", 32),
+		},
+		{
+			// nested static arrays are flattened
+			[]string{"int8[2][]"},
+			[]interface{}{[][2]int8{{-1, 1}}},
+			"0x" + strings.Repeat("ff", 32) + strings.Repeat("00", 31) + "01",
+		},
+		{
+			[]string{"bytes2[]"},
+			[]interface{}{[][2]byte{{0x1, 0x2}}},
+			"0x0102" + strings.Repeat("00", 30),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			packed, err := SolidityPack(c.Types, c.Values)
+			assert.NoError(t, err)
+			assert.Equal(t, c.Packed, encodeHex(packed))
+
+			// same result using a tuple as argument list
+			typ := MustNewType("tuple(" + strings.Join(c.Types, ",") + ")")
+			packed2, err := typ.EncodePacked(c.Values)
+			assert.NoError(t, err)
+			assert.Equal(t, packed, packed2)
+		})
+	}
+}
+
+func TestEncodePacked_Unsupported(t *testing.T) {
+	cases := []struct {
+		Type  string
+		Value interface{}
+	}{
+		{"string[]", []string{"a"}},
+		{"bytes[1]", [1][]byte{{0x1}}},
+		{"uint8[][2]", [2][]uint8{{1}, {2}}},
+		{"tuple(uint8 a)", map[string]interface{}{"a": uint8(1)}},
+		{"tuple(uint8 a)[]", []map[string]interface{}{{"a": uint8(1)}}},
+	}
+	for _, c := range cases {
+		_, err := SolidityPack([]string{c.Type}, []interface{}{c.Value})
+		assert.Error(t, err, c.Type)
+	}
+}
+
+func TestSolidityKeccak(t *testing.T) {
+	hash, err := SolidityKeccak([]string{"int16", "uint48"}, []interface{}{int16(-1), big.NewInt(12)})
+	assert.NoError(t, err)
+	assert.Equal(t, web3.HexToHash("0x81da7abb5c9c7515f57dab2fc946f01217ab52f3bd8958bc36bd55894451a93c"), hash)
+}
+
+var randomPackedTypes = []string{
+	"bool",
+	"int",
+	"uint",
+	"address",
+	"string",
+	"bytes",
+	"fixedBytes",
+	"array",
+	"slice",
+}
+
+func pickRandomPackedType(d int) string {
+	t := randomPackedTypes[rand.Intn(len(randomPackedTypes))]
+	if d > 1 && (t == "string" || t == "bytes") {
+		// dynamic types can not be array elements in packed mode
+		t = "bool"
+	}
+	switch t {
+	case "int":
+		return fmt.Sprintf("int%d", randomNumberBits())
+	case "uint":
+		return fmt.Sprintf("uint%d", randomNumberBits())
+	case "fixedBytes":
+		return fmt.Sprintf("bytes%d", randomInt(1, 32))
+	case "array":
+		if d > 2 {
+			return "address"
+		}
+		return fmt.Sprintf("%s[%d]", pickRandomPackedType(d+1), randomInt(1, 3))
+	case "slice":
+		if d > 1 {
+			// nested dynamic arrays are not supported
+			return "uint256"
+		}
+		return fmt.Sprintf("%s[]", pickRandomPackedType(d+1))
+	}
+	return t
+}
+
+func TestEncodePackedWithContract(t *testing.T) {
+	rand.Seed(time.Now().UTC().UnixNano())
+
+	server := testutil.NewTestServer(t, nil)
+	defer server.Close()
+
+	for i := 0; i < 20; i++ {
+		t.Run("", func(t *testing.T) {
+			elems := []string{}
+			for j := 0; j < randomInt(1, 5); j++ {
+				elems = append(elems, pickRandomPackedType(1))
+			}
+			typ := MustNewType("tuple(" + strings.Join(elems, ",") + ")")
+			if err := testPackedWithContract(t, server, typ); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func testPackedWithContract(t *testing.T, server *testutil.TestServer, typ *Type) error {
+	g := &generateContractImpl{}
+
+	var input, body []string
+	for indx, i := range typ.tuple {
+		val := g.getValue(i.Elem)
+		memory := ""
+		if val == "bytes" || strings.Contains(val, "[") || strings.Contains(val, "string") {
+			memory = " memory"
+		}
+		input = append(input, fmt.Sprintf("%s%s arg%d", val, memory, indx))
+		body = append(body, fmt.Sprintf("arg%d", indx))
+	}
+
+	source := fmt.Sprintf(`pragma solidity >0.5.0;
+
+contract Sample {
+	function hash(%s) public pure returns (bytes32) {
+		return keccak256(abi.encodePacked(%s));
+	}
+}`, strings.Join(input, ","), strings.Join(body, ","))
+
+	output, err := compiler.NewSolidityCompiler("solc").(*compiler.Solidity).CompileCode(source)
+	if err != nil {
+		return err
+	}
+	solcContract, ok := output["<stdin>:Sample"]
+	if !ok {
+		return fmt.Errorf("Expected the contract to be called Sample")
+	}
+	abi, err := NewABI(string(solcContract.Abi))
+	if err != nil {
+		return err
+	}
+	receipt, err := server.SendTxn(&web3.Transaction{Input: decodeHex(solcContract.Bin)})
+	if err != nil {
+		return err
+	}
+
+	method := abi.Methods["hash"]
+	vals := generateRandomType(method.Inputs).(map[string]interface{})
+	args := make([]interface{}, len(typ.tuple))
+	types := make([]string, len(typ.tuple))
+	for indx, elem := range typ.tuple {
+		args[indx] = vals[method.Inputs.tuple[indx].Name]
+		types[indx] = elem.Elem.String()
+	}
+
+	data, err := Encode(vals, method.Inputs)
+	if err != nil {
+		return err
+	}
+	res, err := server.Call(&web3.CallMsg{
+		To:   &receipt.ContractAddress,
+		Data: append(method.ID(), data...),
+	})
+	if err != nil {
+		return err
+	}
+
+	expected, err := SolidityKeccak(types, args)
+	if err != nil {
+		return err
+	}
+	if res != expected.String() {
+		return fmt.Errorf("bad: %s %s", res, expected.String())
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/laizy/web3"
+)
+
+// DecodeError is returned by the strict decoder when the input is not
+// the canonical encoding of the type
+type DecodeError struct {
+	// Offset is the position in the input of the offending word
+	Offset int
+	// Path is the location of the value inside the type (i.e. 'orders[1].amount')
+	Path string
+	// Type is the abi type of the value
+	Type string
+	Msg  string
+}
+
+func (e *DecodeError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "<root>"
+	}
+	return fmt.Sprintf("offset %d: %s (%s): %s", e.Offset, path, e.Type, e.Msg)
+}
+
+// DecodeStrict decodes the input with a given type and fails unless the input
+// is the canonical encoding of the value, the one produced by solc. Dirty padding
+// bits, offsets that do not point to the next tail position and trailing bytes
+// are rejected with a *DecodeError.
+func DecodeStrict(t *Type, input []byte) (interface{}, error) {
+	if len(input) == 0 {
+		return nil, fmt.Errorf("empty input")
+	}
+	d := &strictDecoder{input: input}
+	val, end, err := d.decode(t, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	if end != len(input) {
+		return nil, &DecodeError{Offset: end, Type: t.String(), Msg: fmt.Sprintf("%d trailing bytes", len(input)-end)}
+	}
+	return val, nil
+}
+
+// DecodeStrict decodes the input with this type in strict mode
+func (t *Type) DecodeStrict(input []byte) (interface{}, error) {
+	return DecodeStrict(t, input)
+}
+
+// ParseLogStrict parses an event log and fails if either the topics or the
+// data are not canonically encoded
+func ParseLogStrict(args *Type, log *web3.Log) (map[string]interface{}, error) {
+	return parseLog(args, log, true)
+}
+
+// ParseLogStrict parses a log with this event in strict mode
+func (e *Event) ParseLogStrict(log *web3.Log) (map[string]interface{}, error) {
+	if !e.Match(log) {
+		return nil, fmt.Errorf("log does not match this event")
+	}
+	return ParseLogStrict(e.Inputs, log)
+}
+
+type strictDecoder struct {
+	input []byte
+}
+
+func (d *strictDecoder) errorf(offset int, t *Type, path string, format string, args ...interface{}) error {
+	return &DecodeError{Offset: offset, Path: path, Type: t.String(), Msg: fmt.Sprintf(format, args...)}
+}
+
+// word returns the 32 bytes word at the given position
+func (d *strictDecoder) word(t *Type, pos int, path string) ([]byte, error) {
+	if pos < 0 || pos+32 > len(d.input) {
+		return nil, d.errorf(pos, t, path, "input too short, expected 32 bytes but found %d", len(d.input)-pos)
+	}
+	return d.input[pos : pos+32], nil
+}
+
+// readUint reads a word as an integer that must fit in an int and be
+// lower or equal than max
+func (d *strictDecoder) readUint(t *Type, pos int, path string, name string, max int) (int, error) {
+	word, err := d.word(t, pos, path)
+	if err != nil {
+		return 0, err
+	}
+	num := new(big.Int).SetBytes(word)
+	if !num.IsInt64() || num.Int64() > int64(max) {
+		return 0, d.errorf(pos, t, path, "%s %s out of range, at most %d", name, num, max)
+	}
+	return int(num.Int64()), nil
+}
+
+// decode decodes the type whose encoding starts at pos and returns the
+// position right after the encoding
+func (d *strictDecoder) decode(t *Type, pos int, path string) (interface{}, int, error) {
+	switch t.kind {
+	case KindTuple:
+		elems := make([]*Type, len(t.tuple))
+		for i, elem := range t.tuple {
+			elems[i] = elem.Elem
+		}
+		vals, end, err := d.decodeSequence(elems, pos, func(i int) string {
+			name := t.tuple[i].Name
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			if path == "" {
+				return name
+			}
+			return path + "." + name
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		res := make(map[string]interface{}, len(vals))
+		for i, elem := range t.tuple {
+			key := NameToKey(elem.Name, i)
+			if _, ok := res[key]; ok {
+				return nil, 0, d.errorf(pos, t, path, "tuple with repeated values")
+			}
+			res[key] = vals[i]
+		}
+		return res, end, nil
+
+	case KindSlice, KindArray:
+		size, start := t.size, pos
+		if t.kind == KindSlice {
+			// every element takes at least one word
+			var err error
+			if size, err = d.readUint(t, pos, path, "length", (len(d.input)-pos-32)/32); err != nil {
+				return nil, 0, err
+			}
+			start = pos + 32
+		}
+		elems := make([]*Type, size)
+		for i := range elems {
+			elems[i] = t.elem
+		}
+		vals, end, err := d.decodeSequence(elems, start, func(i int) string {
+			return path + "[" + strconv.Itoa(i) + "]"
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		var res reflect.Value
+		if t.kind == KindSlice {
+			res = reflect.MakeSlice(t.t, size, size)
+		} else {
+			res = reflect.New(t.t).Elem()
+		}
+		for i, val := range vals {
+			res.Index(i).Set(reflect.ValueOf(val))
+		}
+		return res.Interface(), end, nil
+
+	case KindString, KindBytes:
+		length, err := d.readUint(t, pos, path, "length", len(d.input)-pos-32)
+		if err != nil {
+			return nil, 0, err
+		}
+		start := pos + 32
+		end := start + (length+31)/32*32
+		if end > len(d.input) {
+			return nil, 0, d.errorf(start, t, path, "input too short, expected %d bytes but found %d", end-start, len(d.input)-start)
+		}
+		if !allZeros(d.input[start+length : end]) {
+			return nil, 0, d.errorf(start+length, t, path, "dirty padding bytes")
+		}
+		data := d.input[start : start+length]
+		if t.kind == KindString {
+			return string(data), end, nil
+		}
+		return append([]byte{}, data...), end, nil
+	}
+
+	word, err := d.word(t, pos, path)
+	if err != nil {
+		return nil, 0, err
+	}
+	val, err := decodeStrictWord(t, word)
+	if err != nil {
+		return nil, 0, d.errorf(pos, t, path, "%v", err)
+	}
+	return val, pos + 32, nil
+}
+
+// decodeSequence decodes a list of types encoded as a tuple starting at pos. The
+// offsets of the dynamic elements must point to the end of the previous tail.
+func (d *strictDecoder) decodeSequence(elems []*Type, pos int, pathFn func(i int) string) ([]interface{}, int, error) {
+	tail := pos
+	for _, elem := range elems {
+		if elem.isDynamicType() {
+			tail += 32
+		} else {
+			tail += getTypeSize(elem)
+		}
+	}
+	if tail > len(d.input) {
+		return nil, 0, &DecodeError{Offset: pos, Path: pathFn(0), Type: elems[0].String(), Msg: fmt.Sprintf("input too short, expected %d bytes but found %d", tail-pos, len(d.input)-pos)}
+	}
+
+	vals := make([]interface{}, len(elems))
+	head := pos
+	for i, elem := range elems {
+		path := pathFn(i)
+		if !elem.isDynamicType() {
+			val, end, err := d.decode(elem, head, path)
+			if err != nil {
+				return nil, 0, err
+			}
+			vals[i], head = val, end
+			continue
+		}
+
+		offset, err := d.readUint(elem, head, path, "offset", len(d.input))
+		if err != nil {
+			return nil, 0, err
+		}
+		if pos+offset != tail {
+			return nil, 0, d.errorf(head, elem, path, "offset %d is not canonical, expected %d", offset, tail-pos)
+		}
+		val, end, err := d.decode(elem, tail, path)
+		if err != nil {
+			return nil, 0, err
+		}
+		vals[i], tail = val, end
+		head += 32
+	}
+	return vals, tail, nil
+}
+
+// decodeStrictWord decodes a static value checking that the unused bits are clean
+func decodeStrictWord(t *Type, word []byte) (interface{}, error) {
+	switch t.kind {
+	case KindBool:
+		if !allZeros(word[:31]) {
+			return nil, fmt.Errorf("dirty high bits")
+		}
+		return decodeBool(word)
+
+	case KindInt, KindUInt, KindFixedPoint:
+		n := t.size / 8
+		ext := byte(0)
+		if t.isSigned() && word[32-n]&0x80 != 0 {
+			ext = 0xff
+		}
+		for _, b := range word[:32-n] {
+			if b != ext {
+				return nil, fmt.Errorf("dirty high bits")
+			}
+		}
+		if t.kind == KindFixedPoint {
+			return readFixedPoint(t, word), nil
+		}
+		return readInteger(t, word), nil
+
+	case KindAddress:
+		if !allZeros(word[:12]) {
+			return nil, fmt.Errorf("dirty high bits")
+		}
+		return readAddr(word)
+
+	case KindFixedBytes:
+		if !allZeros(word[t.size:]) {
+			return nil, fmt.Errorf("dirty low bits")
+		}
+		return readFixedBytes(t, word)
+
+	case KindFunction:
+		return readFunctionType(t, word)
+
+	default:
+		return nil, fmt.Errorf("decoding not available for type '%s'", t.kind)
+	}
+}
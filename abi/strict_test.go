@@ -0,0 +1,129 @@
+package abi
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/laizy/web3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeStrict_RoundTrip(t *testing.T) {
+	rand.Seed(time.Now().UTC().UnixNano())
+
+	for i := 0; i < 500; i++ {
+		typ := generateRandomArgs(randomInt(1, 4))
+		input := generateRandomType(typ)
+
+		data, err := Encode(input, typ)
+		require.NoError(t, err)
+
+		expected, err := Decode(typ, data)
+		require.NoError(t, err)
+
+		found, err := DecodeStrict(typ, data)
+		require.NoError(t, err, typ.String())
+		assert.Equal(t, expected, found)
+	}
+}
+
+func TestDecodeStrict_NonCanonical(t *testing.T) {
+	typ := MustNewType("tuple(uint8 a, bytes b, address c, int16[] d)")
+	data, err := typ.Encode(map[string]interface{}{
+		"a": uint8(1),
+		"b": []byte{0x1, 0x2},
+		"c": web3.Address{0x1},
+		"d": []int16{-1},
+	})
+	require.NoError(t, err)
+
+	_, err = DecodeStrict(typ, data)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		mutate func(b []byte) []byte
+		offset int
+		path   string
+	}{
+		{
+			"dirty uint8",
+			func(b []byte) []byte { b[30] = 0x1; return b },
+			0, "a",
+		},
+		{
+			"dirty address",
+			func(b []byte) []byte { b[64] = 0x1; return b },
+			64, "c",
+		},
+		{
+			"backward offset",
+			func(b []byte) []byte { b[63] = 0x0; return b },
+			32, "b",
+		},
+		{
+			"dirty bytes padding",
+			func(b []byte) []byte { b[160+5] = 0x1; return b },
+			160 + 2, "b",
+		},
+		{
+			"dirty int16 sign extension",
+			func(b []byte) []byte { b[224] = 0x0; return b },
+			224, "d[0]",
+		},
+		{
+			"trailing garbage",
+			func(b []byte) []byte { return append(b, make([]byte, 32)...) },
+			len(data), "",
+		},
+		{
+			"truncated",
+			func(b []byte) []byte { return b[:len(b)-32] },
+			192, "d",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			input := c.mutate(append([]byte{}, data...))
+
+			// the lenient decoder accepts the non canonical inputs
+			if c.name != "truncated" {
+				_, err := Decode(typ, input)
+				assert.NoError(t, err)
+			}
+
+			_, err := DecodeStrict(typ, input)
+			require.Error(t, err)
+
+			decodeErr, ok := err.(*DecodeError)
+			require.True(t, ok, err.Error())
+			assert.Equal(t, c.offset, decodeErr.Offset)
+			assert.Equal(t, c.path, decodeErr.Path)
+		})
+	}
+}
+
+func TestParseLogStrict(t *testing.T) {
+	event := MustNewEvent("event Transfer(address indexed from, uint8 indexed kind, uint256 value)")
+
+	data, err := Encode([]interface{}{big.NewInt(10)}, MustNewType("tuple(uint256)"))
+	require.NoError(t, err)
+
+	log := &web3.Log{
+		Topics: []web3.Hash{event.ID(), {31: 0x1}, {31: 0x2}},
+		Data:   data,
+	}
+	_, err = event.ParseLogStrict(log)
+	assert.NoError(t, err)
+
+	// dirty topic
+	log.Topics[2][0] = 0x1
+	_, err = event.ParseLog(log)
+	assert.NoError(t, err)
+	_, err = event.ParseLogStrict(log)
+	assert.Error(t, err)
+}
@@ -0,0 +1,318 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/utils/common/uint256"
+)
+
+var uint256T = reflect.TypeOf(uint256.Int{})
+
+// DecodeInto copies a decoded value (as returned by Decode or ParseLog) into out.
+// Tuple elements are matched with the struct fields by the 'abi' tag, then the
+// 'mapstructure' tag, or by the case insensitive field name. Numbers are converted into big.Int, uint256.Int
+// or native integers, failing if the value overflows the target.
+func DecodeInto(val interface{}, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("out must be a non nil pointer but found %T", out)
+	}
+	return decodeInto(val, v.Elem(), "")
+}
+
+// ParseLogInto parses a log with this event into the out param
+func (e *Event) ParseLogInto(log *web3.Log, out interface{}) error {
+	val, err := e.ParseLog(log)
+	if err != nil {
+		return err
+	}
+	return DecodeInto(val, out)
+}
+
+// DecodeOutputInto decodes the output data of the method into the out param.
+// If the method has a single output, out may also point to that value directly.
+func (m *Method) DecodeOutputInto(data []byte, out interface{}) error {
+	val, err := Decode(m.Outputs, data)
+	if err != nil {
+		return err
+	}
+	return decodeTupleInto(m.Outputs, val, out)
+}
+
+// DecodeInputInto decodes the input arguments (without the method id) into the out param
+func (m *Method) DecodeInputInto(data []byte, out interface{}) error {
+	val, err := Decode(m.Inputs, data)
+	if err != nil {
+		return err
+	}
+	return decodeTupleInto(m.Inputs, val, out)
+}
+
+func decodeTupleInto(t *Type, val interface{}, out interface{}) error {
+	if len(t.tuple) == 1 {
+		v := reflect.ValueOf(out)
+		if v.Kind() == reflect.Ptr && !isStructTarget(v.Type().Elem()) {
+			return DecodeInto(val.(map[string]interface{})[NameToKey(t.tuple[0].Name, 0)], out)
+		}
+	}
+	return DecodeInto(val, out)
+}
+
+// isStructTarget returns whether the type receives a tuple and not a single value
+func isStructTarget(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Map {
+		return true
+	}
+	switch typ {
+	case bigIntT.Elem(), bigRatT.Elem(), uint256T, functionT:
+		return false
+	}
+	return typ.Kind() == reflect.Struct
+}
+
+func decodeErrorf(path string, format string, args ...interface{}) error {
+	if path == "" {
+		return fmt.Errorf(format, args...)
+	}
+	return fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...))
+}
+
+func decodeInto(val interface{}, out reflect.Value, path string) error {
+	if val == nil {
+		return nil
+	}
+	src := reflect.ValueOf(val)
+
+	switch out.Type() {
+	case bigIntT:
+		num, err := toBig(src, path)
+		if err != nil {
+			return err
+		}
+		out.Set(reflect.ValueOf(num))
+		return nil
+
+	case bigIntT.Elem():
+		num, err := toBig(src, path)
+		if err != nil {
+			return err
+		}
+		out.Set(reflect.ValueOf(*num))
+		return nil
+
+	case bigRatT, bigRatT.Elem():
+		rat, ok := val.(*big.Rat)
+		if !ok {
+			num, err := toBig(src, path)
+			if err != nil {
+				return err
+			}
+			rat = new(big.Rat).SetInt(num)
+		}
+		rat = new(big.Rat).Set(rat)
+		if out.Type() == bigRatT {
+			out.Set(reflect.ValueOf(rat))
+		} else {
+			out.Set(reflect.ValueOf(*rat))
+		}
+		return nil
+
+	case uint256T:
+		num, err := toBig(src, path)
+		if err != nil {
+			return err
+		}
+		if num.Sign() < 0 {
+			return decodeErrorf(path, "negative value %s can not be decoded into uint256.Int", num)
+		}
+		var res uint256.Int
+		if res.SetFromBig(num) {
+			return decodeErrorf(path, "value %s overflows uint256.Int", num)
+		}
+		out.Set(reflect.ValueOf(res))
+		return nil
+	}
+
+	switch out.Kind() {
+	case reflect.Interface:
+		out.Set(src)
+		return nil
+
+	case reflect.Ptr:
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		return decodeInto(val, out.Elem(), path)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !isNumber(src) {
+			break
+		}
+		num, err := toBig(src, path)
+		if err != nil {
+			return err
+		}
+		if !num.IsInt64() || out.OverflowInt(num.Int64()) {
+			return decodeErrorf(path, "value %s overflows %s", num, out.Type())
+		}
+		out.SetInt(num.Int64())
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !isNumber(src) {
+			break
+		}
+		num, err := toBig(src, path)
+		if err != nil {
+			return err
+		}
+		if num.Sign() < 0 || !num.IsUint64() || out.OverflowUint(num.Uint64()) {
+			return decodeErrorf(path, "value %s overflows %s", num, out.Type())
+		}
+		out.SetUint(num.Uint64())
+		return nil
+
+	case reflect.Struct:
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			break
+		}
+		return decodeStructInto(obj, out, path)
+
+	case reflect.Slice:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			break
+		}
+		if out.Type().Elem().Kind() == reflect.Uint8 && src.Type().Elem().Kind() == reflect.Uint8 {
+			buf := reflect.MakeSlice(out.Type(), src.Len(), src.Len())
+			reflect.Copy(buf, src)
+			out.Set(buf)
+			return nil
+		}
+		if src.Len() == 0 {
+			out.Set(reflect.Zero(out.Type()))
+			return nil
+		}
+		res := reflect.MakeSlice(out.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := decodeInto(src.Index(i).Interface(), res.Index(i), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		out.Set(res)
+		return nil
+
+	case reflect.Array:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			break
+		}
+		if src.Len() != out.Len() {
+			return decodeErrorf(path, "expected %d elements but found %d", out.Len(), src.Len())
+		}
+		for i := 0; i < src.Len(); i++ {
+			if err := decodeInto(src.Index(i).Interface(), out.Index(i), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if src.Type().AssignableTo(out.Type()) {
+		out.Set(src)
+		return nil
+	}
+	if src.Kind() == out.Kind() && src.Type().ConvertibleTo(out.Type()) {
+		out.Set(src.Convert(out.Type()))
+		return nil
+	}
+	return decodeErrorf(path, "can not decode %s into %s", src.Type(), out.Type())
+}
+
+func decodeStructInto(obj map[string]interface{}, out reflect.Value, path string) error {
+	// keys are normalized to match the field names
+	values := map[string]interface{}{}
+	for k, v := range obj {
+		values[normalizeKey(k)] = v
+		if _, err := strconv.Atoi(k); err == nil {
+			values["arg"+k] = v
+		}
+	}
+
+	typ := out.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+		val, ok := values[normalizeKey(name)]
+		if !ok {
+			continue
+		}
+		fieldPath := f.Name
+		if path != "" {
+			fieldPath = path + "." + f.Name
+		}
+		if err := decodeInto(val, out.Field(i), fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldName returns the tuple element name of the field, the 'mapstructure' tag used by
+// DecodeStruct before is honored when there is no 'abi' tag
+func fieldName(f reflect.StructField) (string, bool) {
+	tag, ok := f.Tag.Lookup("abi")
+	if !ok {
+		tag = strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+	}
+	switch tag {
+	case "-":
+		return "", false
+	case "":
+		return f.Name, true
+	}
+	return tag, true
+}
+
+func normalizeKey(name string) string {
+	return strings.ToLower(strings.Trim(name, "_"))
+}
+
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return v.Type() == bigIntT || v.Type() == bigIntT.Elem()
+}
+
+func toBig(v reflect.Value, path string) (*big.Int, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(v.Uint()), nil
+	}
+	if v.Type() == bigIntT {
+		return new(big.Int).Set(v.Interface().(*big.Int)), nil
+	}
+	if v.Type() == bigIntT.Elem() {
+		num := v.Interface().(big.Int)
+		return new(big.Int).Set(&num), nil
+	}
+	return nil, decodeErrorf(path, "can not decode %s into a number", v.Type())
+}
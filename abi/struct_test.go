@@ -0,0 +1,121 @@
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/utils/common/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent_ParseLogInto(t *testing.T) {
+	evnt := MustNewEvent("event Transfer(address indexed _from, address indexed to, uint256 value, bytes data)")
+
+	fromTopic, _ := EncodeTopic(MustNewType("address"), web3.Address{0x1})
+	toTopic, _ := EncodeTopic(MustNewType("address"), web3.Address{0x2})
+	data, err := MustNewType("tuple(uint256 value, bytes data)").Encode(map[string]interface{}{
+		"value": big.NewInt(1000),
+		"data":  []byte{0x1, 0x2},
+	})
+	assert.NoError(t, err)
+
+	log := &web3.Log{
+		Topics: []web3.Hash{evnt.ID(), fromTopic, toTopic},
+		Data:   data,
+	}
+
+	type transfer struct {
+		Sender  web3.Address `abi:"from"`
+		TO      web3.Address
+		Value   uint256.Int
+		Payload []byte `abi:"data"`
+		Ignored string `abi:"-"`
+	}
+	var out transfer
+	assert.NoError(t, evnt.ParseLogInto(log, &out))
+	assert.Equal(t, web3.Address{0x1}, out.Sender)
+	assert.Equal(t, web3.Address{0x2}, out.TO)
+	assert.Equal(t, uint64(1000), out.Value.Uint64())
+	assert.Equal(t, []byte{0x1, 0x2}, out.Payload)
+
+	// native integers with overflow checks
+	var native struct {
+		Value uint16
+	}
+	assert.NoError(t, evnt.ParseLogInto(log, &native))
+	assert.Equal(t, uint16(1000), native.Value)
+
+	var small struct {
+		Value int8
+	}
+	assert.Error(t, evnt.ParseLogInto(log, &small))
+}
+
+func TestMethod_DecodeOutputInto(t *testing.T) {
+	method := MustNewMethod("function get() view returns (uint256 amount, int16, tuple(address owner, uint8[] ids) info)")
+	data, err := method.Outputs.Encode(map[string]interface{}{
+		"amount": big.NewInt(7),
+		"1":      int16(-3),
+		"info": map[string]interface{}{
+			"owner": web3.Address{0x3},
+			"ids":   []uint8{4, 5},
+		},
+	})
+	assert.NoError(t, err)
+
+	type info struct {
+		Owner web3.Address
+		IDs   []uint64
+	}
+	var out struct {
+		Amount *big.Int
+		Delta  int64 `abi:"1"`
+		Info   *info
+	}
+	assert.NoError(t, method.DecodeOutputInto(data, &out))
+	assert.Equal(t, big.NewInt(7), out.Amount)
+	assert.Equal(t, int64(-3), out.Delta)
+	assert.Equal(t, &info{Owner: web3.Address{0x3}, IDs: []uint64{4, 5}}, out.Info)
+
+	// negative value into an unsigned integer
+	var bad struct {
+		Arg1 uint64
+	}
+	assert.Error(t, method.DecodeOutputInto(data, &bad))
+
+	// single output decoded directly into the value
+	single := MustNewMethod("function balanceOf(address) view returns (uint256)")
+	data, err = single.Outputs.Encode([]interface{}{big.NewInt(42)})
+	assert.NoError(t, err)
+
+	var balance big.Int
+	assert.NoError(t, single.DecodeOutputInto(data, &balance))
+	assert.Equal(t, int64(42), balance.Int64())
+
+	var balance2 uint256.Int
+	assert.NoError(t, single.DecodeOutputInto(data, &balance2))
+	assert.Equal(t, uint64(42), balance2.Uint64())
+}
+
+func TestDecodeStruct_MapstructureTags(t *testing.T) {
+	typ := MustNewType("tuple(address owner, uint256 amount, string memo)")
+	data, err := typ.Encode(map[string]interface{}{
+		"owner":  web3.Address{0x1},
+		"amount": big.NewInt(5),
+		"memo":   "hi",
+	})
+	assert.NoError(t, err)
+
+	var out struct {
+		Holder web3.Address `mapstructure:"owner"`
+		Value  *big.Int     `mapstructure:"amount,omitempty"`
+		Memo   string       `abi:"-" mapstructure:"memo"`
+		Skip   string       `mapstructure:"-"`
+	}
+	assert.NoError(t, DecodeStruct(typ, data, &out))
+	assert.Equal(t, web3.Address{0x1}, out.Holder)
+	assert.Equal(t, big.NewInt(5), out.Value)
+	// the abi tag takes precedence
+	assert.Empty(t, out.Memo)
+}
@@ -25,6 +25,9 @@ var randomTypes = []string{
 	"string",
 	"bytes",
 	"fixedBytes",
+	"fixed",
+	"ufixed",
+	"function",
 }
 
 func randomNumberBits() int {
@@ -53,6 +56,9 @@ PICK:
 
 	case "fixedBytes":
 		return fmt.Sprintf("bytes%d", randomInt(1, 32))
+
+	case "fixed", "ufixed":
+		return fmt.Sprintf("%s%dx%d", t, randomNumberBits(), randomInt(1, 80))
 	}
 
 	if d > 3 {
@@ -125,7 +131,23 @@ func generateRandomType(t *Type) interface{} {
 		rand.Read(buf)
 		return buf
 
-	case KindFixedBytes, KindFunction:
+	case KindFixedPoint:
+		b := make([]byte, t.size/8)
+		rand.Read(b)
+		num := new(big.Int).SetBytes(b)
+		if t.isSigned() {
+			// use the most significant bit as the sign
+			num.Sub(num, new(big.Int).Lsh(big.NewInt(1), uint(t.size-1)))
+		}
+		return new(big.Rat).SetFrac(num, pow10(t.decimals))
+
+	case KindFunction:
+		f := Function{}
+		rand.Read(f.Address[:])
+		rand.Read(f.Selector[:])
+		return f
+
+	case KindFixedBytes:
 		buf := make([]byte, t.size)
 		rand.Read(buf)
 
@@ -214,6 +236,24 @@ contract Sample {
 	return contract
 }
 
+// supportedBySolc returns whether solc can generate code for the type,
+// fixed point numbers are not implemented in the compiler yet
+func supportedBySolc(t *Type) bool {
+	switch t.kind {
+	case KindFixedPoint:
+		return false
+	case KindSlice, KindArray:
+		return supportedBySolc(t.elem)
+	case KindTuple:
+		for _, elem := range t.tuple {
+			if !supportedBySolc(elem.Elem) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (g *generateContractImpl) getValue(t *Type) string {
 	switch t.kind {
 	case KindTuple:
@@ -232,6 +272,9 @@ func (g *generateContractImpl) getValue(t *Type) string {
 	case KindArray:
 		return fmt.Sprintf("%s[%d]", g.getValue(t.elem), t.size)
 
+	case KindFunction:
+		return "function() external"
+
 	default:
 		return t.raw
 	}
@@ -10,6 +10,10 @@ import (
 
 // ParseLog parses an event log
 func ParseLog(args *Type, log *web3.Log) (map[string]interface{}, error) {
+	return parseLog(args, log, false)
+}
+
+func parseLog(args *Type, log *web3.Log, strict bool) (map[string]interface{}, error) {
 	var indexed, nonIndexed []*TupleElem
 
 	for _, arg := range args.TupleElems() {
@@ -25,10 +29,21 @@ func ParseLog(args *Type, log *web3.Log) (map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	if strict {
+		for indx, arg := range indexed {
+			if _, err := decodeStrictWord(arg.Elem, log.Topics[indx+1][:]); err != nil {
+				return nil, &DecodeError{Path: arg.Name, Type: arg.Elem.String(), Msg: fmt.Sprintf("topic %d: %v", indx+1, err)}
+			}
+		}
+	}
 
 	var nonIndexedObjs map[string]interface{}
 	if len(nonIndexed) > 0 {
-		nonIndexedRaw, err := Decode(&Type{kind: KindTuple, tuple: nonIndexed}, log.Data)
+		decodeFn := Decode
+		if strict {
+			decodeFn = DecodeStrict
+		}
+		nonIndexedRaw, err := decodeFn(&Type{kind: KindTuple, tuple: nonIndexed}, log.Data)
 		if err != nil {
 			return nil, err
 		}
@@ -95,6 +110,12 @@ func ParseTopic(t *Type, topic web3.Hash) (interface{}, error) {
 	case KindFixedBytes:
 		return readFixedBytes(t, topic[:])
 
+	case KindFixedPoint:
+		return readFixedPoint(t, topic[:]), nil
+
+	case KindFunction:
+		return readFunctionType(t, topic[:])
+
 	default:
 		return nil, fmt.Errorf("Topic parsing for type %s not supported", t.String())
 	}
@@ -118,6 +139,12 @@ func encodeTopic(t *Type, val reflect.Value) (web3.Hash, error) {
 	case KindFixedBytes:
 		return encodeTopicBytes(val)
 
+	case KindFixedPoint, KindFunction:
+		b, err := encode(val, t)
+		if err != nil {
+			return web3.Hash{}, err
+		}
+		return web3.BytesToHash(b), nil
 	}
 	return web3.Hash{}, fmt.Errorf("not found")
 }
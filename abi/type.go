@@ -25,11 +25,29 @@ var (
 	addressT      = reflect.TypeOf(web3.Address{})
 	stringT       = reflect.TypeOf("")
 	dynamicBytesT = reflect.SliceOf(reflect.TypeOf(byte(0)))
-	functionT     = reflect.ArrayOf(24, reflect.TypeOf(byte(0)))
+	functionT     = reflect.TypeOf(Function{})
 	tupleT        = reflect.TypeOf(map[string]interface{}{})
 	bigIntT       = reflect.TypeOf(new(big.Int))
+	bigRatT       = reflect.TypeOf(new(big.Rat))
 )
 
+// Function is an external function reference, the address of
+// the contract followed by the selector of the method
+type Function struct {
+	Address  web3.Address
+	Selector [4]byte
+}
+
+// Bytes returns the 24 bytes representation of the function
+func (f Function) Bytes() []byte {
+	return append(f.Address.Bytes(), f.Selector[:]...)
+}
+
+// String implements the fmt.Stringer interface
+func (f Function) String() string {
+	return fmt.Sprintf("%s%x", f.Address.String(), f.Selector[:])
+}
+
 // Kind represents the kind of abi type
 type Kind int
 
@@ -92,15 +110,17 @@ func (k Kind) String() string {
 
 // TupleElem is an element of a tuple
 type TupleElem struct {
-	Name    string
-	Elem    *Type
-	Indexed bool
+	Name         string
+	Elem         *Type
+	InternalType string
+	Indexed      bool
 }
 
 // Type is an ABI type
 type Type struct {
 	kind      Kind
 	size      int
+	decimals  int
 	elem      *Type
 	raw       string
 	tupleName string
@@ -117,6 +137,7 @@ func (t *Type) Copy() *Type {
 	a := Type{
 		kind:      t.kind,
 		size:      t.size,
+		decimals:  t.decimals,
 		elem:      t.elem,
 		raw:       t.raw,
 		tupleName: t.tupleName,
@@ -162,6 +183,11 @@ func (t *Type) Size() int {
 	return t.size
 }
 
+// Decimals returns the number of decimals of a fixed point type
+func (t *Type) Decimals() int {
+	return t.decimals
+}
+
 // TupleElems returns the elems of the tuple
 func (t *Type) TupleElems() []*TupleElem {
 	return t.tuple
@@ -250,6 +276,20 @@ func NewTypeFromArgument(arg *ArgumentStr) (*Type, error) {
 	return ty, nil
 }
 
+// setInternalTypes copies the internal types of the components into the tuple elems
+func setInternalTypes(t *Type, arg *ArgumentStr) {
+	for t.kind == KindSlice || t.kind == KindArray {
+		t = t.elem
+	}
+	if t.kind != KindTuple || len(t.tuple) != len(arg.Components) {
+		return
+	}
+	for i, comp := range arg.Components {
+		t.tuple[i].InternalType = comp.InternalType
+		setInternalTypes(t.tuple[i].Elem, comp)
+	}
+}
+
 // NewType parses a type in string format
 func NewType(s string) (*Type, error) {
 	l := newLexer(s)
@@ -283,7 +323,7 @@ func getTypeSize(t *Type) int {
 	return 32
 }
 
-var typeRegexp = regexp.MustCompile("^([[:alpha:]]+)([[:digit:]]*)$")
+var typeRegexp = regexp.MustCompile("^([[:alpha:]]+)([[:digit:]]*)(?:x([[:digit:]]+))?$")
 
 func expectedToken(t tokenType) error {
 	return fmt.Errorf("expected token %s", t.String())
@@ -428,6 +468,13 @@ func decodeSimpleType(str string) (*Type, error) {
 	var err error
 	t := match[0]
 
+	if t == "fixed" || t == "ufixed" {
+		return decodeFixedPointType(t, match[1], match[2])
+	}
+	if match[2] != "" {
+		return nil, fmt.Errorf("type %s does not expect decimals", t)
+	}
+
 	bytes := 0
 	ok := false
 
@@ -515,6 +562,34 @@ func decodeSimpleType(str string) (*Type, error) {
 	}
 }
 
+func decodeFixedPointType(t string, bitsStr, decimalsStr string) (*Type, error) {
+	bits, decimals := 128, 18
+	if bitsStr != "" || decimalsStr != "" {
+		if bitsStr == "" || decimalsStr == "" {
+			return nil, fmt.Errorf("%s expects both bits and decimals, '%sMxN'", t, t)
+		}
+		var err error
+		if bits, err = strconv.Atoi(bitsStr); err != nil {
+			return nil, fmt.Errorf("failed to parse bits '%s': %v", bitsStr, err)
+		}
+		if decimals, err = strconv.Atoi(decimalsStr); err != nil {
+			return nil, fmt.Errorf("failed to parse decimals '%s': %v", decimalsStr, err)
+		}
+	}
+	if bits < 8 || bits > 256 || bits%8 != 0 {
+		return nil, fmt.Errorf("number of bits of %s has to be M mod 8 between 8 and 256", t)
+	}
+	if decimals < 0 || decimals > 80 {
+		return nil, fmt.Errorf("number of decimals of %s has to be between 0 and 80", t)
+	}
+	return &Type{kind: KindFixedPoint, size: bits, decimals: decimals, t: bigRatT, raw: fmt.Sprintf("%s%dx%d", t, bits, decimals)}, nil
+}
+
+// isSigned returns whether the numeric type is signed
+func (t *Type) isSigned() bool {
+	return t.kind == KindInt || (t.kind == KindFixedPoint && !strings.HasPrefix(t.raw, "u"))
+}
+
 type tokenType int
 
 const (
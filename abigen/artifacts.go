@@ -0,0 +1,237 @@
+package abigen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/laizy/web3/compiler"
+	"github.com/laizy/web3/hardhat"
+	"github.com/laizy/web3/utils/common/hexutil"
+)
+
+// ArtifactFilter selects the contracts to generate by glob patterns. A pattern matches
+// the contract name, the source file or 'source:name', '*' does not match '/' while
+// '**' does, ie. 'contracts/**' or '**/mocks/*'.
+type ArtifactFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// Match returns whether the contract is included and not excluded
+func (f *ArtifactFilter) Match(source, name string) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Include) != 0 && !matchAny(f.Include, source, name) {
+		return false
+	}
+	return !matchAny(f.Exclude, source, name)
+}
+
+func matchAny(patterns []string, source, name string) bool {
+	for _, pattern := range patterns {
+		re := globToRegexp(pattern)
+		if re.MatchString(name) || re.MatchString(source+":"+name) || (source != "" && re.MatchString(source)) {
+			return true
+		}
+	}
+	return false
+}
+
+func globToRegexp(pattern string) *regexp.Regexp {
+	var buf bytes.Buffer
+	buf.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				buf.WriteString(".*")
+				i++
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.MustCompile(buf.String())
+}
+
+// LoadArtifacts loads the contracts of Hardhat 'artifacts', Foundry 'out' and solc
+// standard-json output directories (or files), keyed by contract name. The build-info
+// files and debug files are skipped.
+func LoadArtifacts(filter *ArtifactFilter, paths ...string) (map[string]*compiler.Artifact, error) {
+	res := map[string]*compiler.Artifact{}
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if path != root && (info.Name() == "build-info" || info.Name() == "cache") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			base := info.Name()
+			if filepath.Ext(base) != ".json" || strings.HasSuffix(base, ".dbg.json") || strings.HasSuffix(base, ".metadata.json") {
+				return nil
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			artifacts, err := decodeArtifactFile(path, data)
+			if errors.Is(err, errNotArtifact) && path != root {
+				// other json files of the directories, ie. the package.json
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			names := make([]string, 0, len(artifacts))
+			for name := range artifacts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				artifact := artifacts[name]
+				if !filter.Match(artifact.SourceName, name) {
+					continue
+				}
+				if err := addArtifact(res, name, artifact); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func addArtifact(artifacts map[string]*compiler.Artifact, name string, artifact *compiler.Artifact) error {
+	name = strings.Title(name)
+	old, ok := artifacts[name]
+	if !ok {
+		artifacts[name] = artifact
+		return nil
+	}
+	if old.Abi == artifact.Abi && old.Bin == artifact.Bin {
+		return nil
+	}
+	return fmt.Errorf("contract %s is defined in %s and %s, exclude one of them", name, old.SourceName, artifact.SourceName)
+}
+
+var errNotArtifact = errors.New("not a hardhat, foundry or solc standard-json artifact")
+
+// decodeArtifactFile decodes the contracts of a json file, the error wraps errNotArtifact
+// if the json is not an artifact, ie. a plain abi list
+func decodeArtifactFile(path string, data []byte) (map[string]*compiler.Artifact, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, errNotArtifact)
+	}
+	if _, ok := fields["abi"]; ok {
+		artifact, name, err := decodeArtifact(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+		}
+		if name == "" {
+			// foundry names the file after the contract, with the compiler version if duplicated
+			name = strings.Split(strings.TrimSuffix(filepath.Base(path), ".json"), ".")[0]
+		}
+		if artifact.SourceName == "" && strings.HasSuffix(filepath.Dir(path), ".sol") {
+			artifact.SourceName = filepath.Base(filepath.Dir(path))
+		}
+		return map[string]*compiler.Artifact{name: artifact}, nil
+	}
+	if _, ok := fields["contracts"]; ok {
+		artifacts, err := decodeStandardOutput(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+		}
+		return artifacts, nil
+	}
+	return nil, fmt.Errorf("failed to decode %s: %w", path, errNotArtifact)
+}
+
+// decodeArtifact decodes a hardhat or foundry artifact with hardhat.DecodeArtifact, the
+// contract name is empty if the artifact does not have it
+func decodeArtifact(data []byte) (*compiler.Artifact, string, error) {
+	value, err := hardhat.DecodeArtifact(data)
+	if err != nil {
+		return nil, "", err
+	}
+	artifact := &compiler.Artifact{
+		Abi:                    value.Abi,
+		Bin:                    bytecodeString(value.Bytecode, value.UnlinkedBytecode),
+		BinRuntime:             bytecodeString(value.DeployedBytecode, value.UnlinkedDeployedBytecode),
+		SourceName:             value.SourceName,
+		LinkReferences:         value.LinkReferences,
+		DeployedLinkReferences: value.DeployedLinkReferences,
+	}
+	return artifact, value.ContractName, nil
+}
+
+// bytecodeString returns the unlinked bytecode if any, the empty bytecode of interfaces and
+// abstract contracts is returned as an empty string
+func bytecodeString(linked hexutil.Bytes, unlinked string) string {
+	if unlinked != "" {
+		return unlinked
+	}
+	if len(linked) == 0 {
+		return ""
+	}
+	return linked.String()
+}
+
+// decodeStandardOutput decodes the contracts of a solc standard-json output
+func decodeStandardOutput(data []byte) (map[string]*compiler.Artifact, error) {
+	output, err := compiler.ParseStandardOutput(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := output.Err(); err != nil {
+		return nil, err
+	}
+	artifacts, err := output.Artifacts()
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]*compiler.Artifact{}
+	for key, artifact := range artifacts {
+		name := strings.TrimPrefix(key, artifact.SourceName+":")
+		artifact.Bin = normalizeBytecode(artifact.Bin)
+		artifact.BinRuntime = normalizeBytecode(artifact.BinRuntime)
+		if old, ok := res[name]; ok && (old.Abi != artifact.Abi || old.Bin != artifact.Bin) {
+			return nil, fmt.Errorf("contract %s is defined in %s and %s, exclude one of them", name, old.SourceName, artifact.SourceName)
+		}
+		res[name] = artifact
+	}
+	return res, nil
+}
+
+// normalizeBytecode adds the 0x prefix, the empty bytecode of interfaces and abstract
+// contracts is returned as an empty string
+func normalizeBytecode(code string) string {
+	code = strings.TrimPrefix(code, "0x")
+	if code == "" {
+		return ""
+	}
+	return "0x" + code
+}
@@ -0,0 +1,115 @@
+package abigen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/laizy/web3/compiler"
+	"github.com/stretchr/testify/require"
+)
+
+func artifactNames(artifacts map[string]*compiler.Artifact) []string {
+	var names []string
+	for name := range artifacts {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestLoadArtifacts(t *testing.T) {
+	assert := require.New(t)
+
+	hardhat, err := LoadArtifacts(nil, "testdata/hardhat")
+	assert.Nil(err)
+	assert.ElementsMatch([]string{"Token", "TokenMock", "Vault"}, artifactNames(hardhat))
+	assert.Equal("contracts/Token.sol", hardhat["Token"].SourceName)
+	assert.Equal("0x6080", hardhat["Token"].Bin)
+	assert.Equal("0x6081", hardhat["Token"].BinRuntime)
+	assert.False(hardhat["Token"].HasLinkReferences())
+
+	vault := hardhat["Vault"]
+	assert.True(vault.HasLinkReferences())
+	assert.Equal([]compiler.LinkReference{{Start: 1, Length: 20}}, vault.LinkReferences["contracts/lib/Math.sol"]["Math"])
+	assert.Equal([]compiler.LinkReference{{Start: 1, Length: 20}}, vault.DeployedLinkReferences["contracts/lib/Math.sol"]["Math"])
+
+	foundry, err := LoadArtifacts(nil, "testdata/foundry")
+	assert.Nil(err)
+	assert.ElementsMatch([]string{"IERC20", "Math"}, artifactNames(foundry))
+	assert.Equal("contracts/lib/Math.sol", foundry["Math"].SourceName)
+	assert.Equal("0x6084", foundry["Math"].Bin)
+	assert.Equal("0x6085", foundry["Math"].BinRuntime)
+	assert.Equal("IERC20.sol", foundry["IERC20"].SourceName)
+	assert.Equal("", foundry["IERC20"].Bin)
+
+	solc, err := LoadArtifacts(nil, "testdata/solc/output.json")
+	assert.Nil(err)
+	assert.ElementsMatch([]string{"Token", "Vault"}, artifactNames(solc))
+	assert.Equal(hardhat["Token"].Abi, solc["Token"].Abi)
+	assert.Equal(vault.Bin, solc["Vault"].Bin)
+	assert.Equal(vault.LinkReferences, solc["Vault"].LinkReferences)
+
+	// the same contracts of several outputs are merged
+	all, err := LoadArtifacts(nil, "testdata/hardhat", "testdata/foundry", "testdata/solc")
+	assert.Nil(err)
+	assert.Len(all, 5)
+}
+
+func TestLoadArtifacts_NotArtifact(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "abi.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`[{"type":"function","name":"f","inputs":[]}]`), 0644))
+
+	// a file given explicitly has to be an artifact
+	_, err := LoadArtifacts(nil, path)
+	require.EqualError(t, err, "failed to decode "+path+": not a hardhat, foundry or solc standard-json artifact")
+
+	// the other json files of a directory are skipped
+	artifacts, err := LoadArtifacts(nil, dir)
+	require.NoError(t, err)
+	require.Empty(t, artifacts)
+}
+
+func TestLoadArtifacts_Filter(t *testing.T) {
+	assert := require.New(t)
+
+	cases := []struct {
+		filter *ArtifactFilter
+		names  []string
+	}{
+		{&ArtifactFilter{Exclude: []string{"**/mocks/*"}}, []string{"Token", "Vault"}},
+		{&ArtifactFilter{Include: []string{"Token*"}}, []string{"Token", "TokenMock"}},
+		{&ArtifactFilter{Include: []string{"contracts/*.sol"}}, []string{"Token", "Vault"}},
+		{&ArtifactFilter{Include: []string{"contracts/**"}, Exclude: []string{"**:Vault"}}, []string{"Token", "TokenMock"}},
+	}
+	for _, c := range cases {
+		artifacts, err := LoadArtifacts(c.filter, "testdata/hardhat")
+		assert.Nil(err)
+		assert.ElementsMatch(c.names, artifactNames(artifacts))
+	}
+}
+
+func TestLoadArtifacts_Duplicated(t *testing.T) {
+	artifacts := map[string]*compiler.Artifact{}
+	require.Nil(t, addArtifact(artifacts, "Token", &compiler.Artifact{Abi: "[]", Bin: "0x01", SourceName: "a.sol"}))
+	require.Nil(t, addArtifact(artifacts, "Token", &compiler.Artifact{Abi: "[]", Bin: "0x01", SourceName: "b.sol"}))
+	err := addArtifact(artifacts, "Token", &compiler.Artifact{Abi: "[]", Bin: "0x02", SourceName: "c.sol"})
+	require.EqualError(t, err, "contract Token is defined in a.sol and c.sol, exclude one of them")
+}
+
+func TestGenLinkedArtifact(t *testing.T) {
+	assert := require.New(t)
+	artifacts, err := LoadArtifacts(&ArtifactFilter{Include: []string{"Vault"}}, "testdata/hardhat")
+	assert.Nil(err)
+
+	res, err := NewGenerator(&Config{Package: "binding", Name: "Vault"}, artifacts).Gen()
+	assert.Nil(err)
+
+	// the unlinked bytecode is kept and linked when deployed
+	assert.True(strings.Contains(string(res.AbiFiles[0].Code), "func DeployVault(provider *jsonrpc.Client, from web3.Address, libraries map[string]web3.Address) (*contract.Txn, error)"))
+	bin := string(res.BinFiles[0].Code)
+	assert.True(strings.Contains(bin, "func VaultUnlinkedBin() string"))
+	assert.True(strings.Contains(bin, "func VaultLinkReferences() (compiler.LinkReferences, compiler.LinkReferences)"))
+	assert.True(strings.Contains(bin, artifacts["Vault"].Bin))
+}
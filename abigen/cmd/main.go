@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -12,9 +13,9 @@ import (
 
 	"github.com/laizy/web3/utils"
 
+	"github.com/laizy/web3/abi"
 	"github.com/laizy/web3/abigen"
 	"github.com/laizy/web3/compiler"
-	"github.com/laizy/web3/hardhat"
 )
 
 const (
@@ -27,12 +28,20 @@ func main() {
 	var output string
 	var name string
 	var onlyAbi bool
+	var humanAbi bool
+	var artifactDirs string
+	var include string
+	var exclude string
 
 	flag.StringVar(&source, "source", "", "List of abi files")
 	flag.StringVar(&pckg, "package", "main", "Name of the package")
 	flag.StringVar(&output, "output", "", "Output directory")
 	flag.StringVar(&name, "name", "", "name of the contract")
 	flag.BoolVar(&onlyAbi, "abi", false, "only extract abi")
+	flag.BoolVar(&humanAbi, "human", false, "extract the abi in human readable format too, requires -abi")
+	flag.StringVar(&artifactDirs, "artifacts", "", "List of hardhat artifacts, foundry out or solc standard-json output directories")
+	flag.StringVar(&include, "include", "", "List of globs of the contract names or sources to generate, requires -artifacts")
+	flag.StringVar(&exclude, "exclude", "", "List of globs of the contract names or sources to skip, requires -artifacts")
 
 	flag.Parse()
 
@@ -47,6 +56,24 @@ func main() {
 		utils.Ensure(err)
 	}
 
+	if artifactDirs != "" {
+		filter := &abigen.ArtifactFilter{Include: splitList(include), Exclude: splitList(exclude)}
+		artifacts, err := abigen.LoadArtifacts(filter, splitList(artifactDirs)...)
+		if err != nil {
+			fmt.Printf("Failed to load artifacts: %v", err)
+			os.Exit(1)
+		}
+		if len(artifacts) == 0 {
+			fmt.Printf("ERROR: Have no contract at: %v", artifactDirs)
+			os.Exit(1)
+		}
+		if err := abigen.GenCode(artifacts, config); err != nil {
+			fmt.Printf("Failed to generate sources: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	if source == "" {
 		fmt.Println(version)
 		os.Exit(0)
@@ -83,11 +110,26 @@ func main() {
 						fmt.Printf("No abi from %s\n", name)
 						os.Exit(1)
 					}
+					parsed, err := abi.NewABI(v.Abi)
+					if err != nil {
+						fmt.Printf("Failed to parse abi from %s: %v\n", name, err)
+						os.Exit(1)
+					}
+					content, err := json.MarshalIndent(parsed, "", "  ")
+					utils.Ensure(err)
 					filename := filepath.Join(output, name+"_abi.json")
 					fmt.Println("write abi to: ", filename)
-					if err := ioutil.WriteFile(filename, []byte(v.Abi), 0644); err != nil {
+					if err := ioutil.WriteFile(filename, content, 0644); err != nil {
 						panic(err)
 					}
+					if humanAbi {
+						filename := filepath.Join(output, name+"_abi.txt")
+						fmt.Println("write human readable abi to: ", filename)
+						human := strings.Join(parsed.HumanReadable(), "\n") + "\n"
+						if err := ioutil.WriteFile(filename, []byte(human), 0644); err != nil {
+							panic(err)
+						}
+					}
 				}
 			} else {
 				if err := abigen.GenCode(artifacts, config); err != nil {
@@ -99,6 +141,16 @@ func main() {
 	}
 }
 
+func splitList(list string) []string {
+	var res []string
+	for _, elem := range strings.Split(list, ",") {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			res = append(res, elem)
+		}
+	}
+	return res
+}
+
 const (
 	vyExt   = 0
 	solExt  = 1
@@ -217,23 +269,24 @@ func processAbi(sources []string, config *abigen.Config) (map[string]*compiler.A
 }
 
 func processJson(sources []string) (map[string]*compiler.Artifact, error) {
+	// hardhat, foundry and solc standard-json outputs are decoded as in -artifacts
 	artifacts := map[string]*compiler.Artifact{}
 	for _, jsonPath := range sources {
-		content, err := ioutil.ReadFile(jsonPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read abi file (%s): %v", jsonPath, err)
-		}
-
-		// Use the name of the file to name the contract
-		_, name := filepath.Split(jsonPath)
-		name = strings.TrimSuffix(name, ".json")
-
-		art, err := hardhat.DecodeArtifact(content)
+		res, err := abigen.LoadArtifacts(nil, jsonPath)
 		if err != nil {
 			return nil, err
 		}
-
-		artifacts[strings.Title(name)] = compiler.NewArtifact(string(art.Abi), art.Bytecode.String(), art.DeployedBytecode.String())
+		if len(res) == 1 {
+			// Use the name of the file to name the contract of an artifact
+			_, name := filepath.Split(jsonPath)
+			name = strings.TrimSuffix(name, ".json")
+			for _, artifact := range res {
+				res = map[string]*compiler.Artifact{strings.Title(name): artifact}
+			}
+		}
+		for name, artifact := range res {
+			artifacts[name] = artifact
+		}
 	}
 	return artifacts, nil
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/laizy/web3/abi"
 	"github.com/laizy/web3/compiler"
+	"github.com/laizy/web3/utils"
 )
 
 type Config struct {
@@ -68,7 +69,7 @@ func encodeSimpleArg(typ *abi.Type) string {
 	case abi.KindInt:
 		return typ.GoType().String()
 
-	case abi.KindUInt:
+	case abi.KindUInt, abi.KindFixedPoint:
 		return typ.GoType().String()
 
 	case abi.KindFixedBytes:
@@ -164,6 +165,9 @@ func FuncMap() template.FuncMap {
 		"getTopicFilterParam": getTopicFilterParam,
 		"getTopicFilterInput": getTopicFilterInput,
 		"getFilterEventParam": getFilterEventParam,
+		"getWatchEventParam":  getWatchEventParam,
+		"errorType":           errorTypeName,
+		"toJson":              utils.JsonStr,
 	}
 }
 
@@ -220,6 +224,16 @@ func getFilterEventParam(event *abi.Event) string {
 	return strings.Join(params, ",")
 }
 
+func getWatchEventParam(goName string, event *abi.Event) string {
+	params := []string{"opts *contract.WatchOpts", fmt.Sprintf("sink chan<- *%sEvent", goName)}
+	for _, v := range event.Inputs.TupleElems() {
+		if v.Indexed {
+			params = append(params, fmt.Sprintf("%s []%s", cleanName(v.Name), encodeTopicArg(v)))
+		}
+	}
+	return strings.Join(params, ",")
+}
+
 func isNil(c interface{}) bool {
 	return c == nil || (reflect.ValueOf(c).Kind() == reflect.Ptr && reflect.ValueOf(c).IsNil())
 }
@@ -261,18 +275,22 @@ func GenCode(artifacts map[string]*compiler.Artifact, config *Config) error {
 var templateAbiStr = `package {{.Config.Package}}
 
 import (
+	"context"
     "encoding/json"
 	"fmt"
 	"math/big"
 
-	"github.com/laizy/web3"
+	"github.com/laizy/web3"{{if .Contract.HasLinkReferences}}
+	"github.com/laizy/web3/compiler"{{end}}
 	"github.com/laizy/web3/contract"
 	"github.com/laizy/web3/jsonrpc"
-	"github.com/laizy/web3/utils"
+	"github.com/laizy/web3/utils"{{if .Contract.HasLinkReferences}}
+	"github.com/laizy/web3/utils/common/hexutil"{{end}}
 	"github.com/mitchellh/mapstructure"
 )
 
 var (
+	_ = context.Background
 	_ = json.Unmarshal
 	_ = big.NewInt
 	_ = fmt.Printf
@@ -285,30 +303,54 @@ var (
 type {{.Name}} struct {
 	c *contract.Contract
 }
-{{if .Contract.Bin}}
+{{if and .Contract.Bin (not .Contract.HasLinkReferences)}}
 // Deploy{{.Name}} deploys a new {{.Name}} contract
 func Deploy{{.Name}}(provider *jsonrpc.Client, from web3.Address {{if .Abi.Constructor}}{{range $index, $val := tupleElems .Abi.Constructor.Inputs}}, {{if .Name}}{{clean .Name}}{{else}}val{{$index}}{{end}} {{arg .}} {{end}}{{end}}) *contract.Txn {
 	return contract.DeployContract(provider, from, abi{{.Name}}, bin{{.Name}}{{if .Abi.Constructor}} {{range $index, $val := tupleElems .Abi.Constructor.Inputs}}, {{if .Name}}{{clean .Name}}{{else}}val{{$index}}{{end}}{{end}}{{end}})
 }
+{{else if .Contract.HasLinkReferences}}
+// Deploy{{.Name}} links the libraries, keyed by fully qualified name or by name, and deploys a new {{.Name}} contract
+func Deploy{{.Name}}(provider *jsonrpc.Client, from web3.Address, libraries map[string]web3.Address {{if .Abi.Constructor}}{{range $index, $val := tupleElems .Abi.Constructor.Inputs}}, {{if .Name}}{{clean .Name}}{{else}}val{{$index}}{{end}} {{arg .}} {{end}}{{end}}) (*contract.Txn, error) {
+	bin, err := compiler.LinkBytecode(bin{{.Name}}Str, linkReferences{{.Name}}, libraries)
+	if err != nil {
+		return nil, err
+	}
+	return contract.DeployContract(provider, from, abi{{.Name}}, hexutil.MustDecode(bin){{if .Abi.Constructor}} {{range $index, $val := tupleElems .Abi.Constructor.Inputs}}, {{if .Name}}{{clean .Name}}{{else}}val{{$index}}{{end}}{{end}}{{end}}), nil
+}
 {{end}}
 // New{{.Name}} creates a new instance of the contract at a specific address
 func New{{.Name}}(addr web3.Address, provider *jsonrpc.Client) *{{.Name}} {
+{{- if .Abi.Errors}}
+	c := contract.NewContract(addr, abi{{.Name}}, provider)
+	c.SetErrorFactory(new{{.Name}}Error)
+	return &{{.Name}}{c: c}
+{{- else}}
 	return &{{.Name}}{c: contract.NewContract(addr, abi{{.Name}}, provider)}
+{{- end}}
+}
+{{if .Abi.Errors}}
+// new{{.Name}}Error returns the go type of the {{.Name}} custom error by name
+func new{{.Name}}Error(name string) error {
+	switch name { {{range $key, $value := .Abi.Errors}}
+	case "{{$key}}":
+		return &{{errorType $key}}{}{{end}}
+	}
+	return nil
 }
-
+{{end}}
 // Contract returns the contract object
 func ({{.Ptr}} *{{.Name}}) Contract() *contract.Contract {
 	return {{.Ptr}}.c
 }
 
 // calls
-{{range $key, $value := .Abi.Methods}}{{if .Const}}
-// {{funcName $key}} calls the {{$key}} method in the solidity contract
-func ({{$.Ptr}} *{{$.Name}}) {{funcName $key}}({{range $index, $val := tupleElems .Inputs}}{{if .Name}}{{clean .Name}}{{else}}val{{$index}}{{end}} {{arg .}}, {{end}}block ...web3.BlockNumber) ({{range $index, $val := tupleElems .Outputs}}retval{{$index}} {{arg .}}, {{end}}err error) {
+{{range .Methods}}{{if .Const}}
+// {{.GoName}} calls the {{.Key}} method in the solidity contract
+func ({{$.Ptr}} *{{$.Name}}) {{.GoName}}({{range $index, $val := tupleElems .Inputs}}{{if .Name}}{{clean .Name}}{{else}}val{{$index}}{{end}} {{arg .}}, {{end}}block ...web3.BlockNumber) ({{range $index, $val := tupleElems .Outputs}}retval{{$index}} {{arg .}}, {{end}}err error) {
 	var out map[string]interface{}
 	_ = out // avoid not used compiler error
 
-	out, err = {{$.Ptr}}.c.Call("{{$key}}", web3.EncodeBlock(block...){{range $index, $val := tupleElems .Inputs}}, {{if .Name}}{{clean .Name}}{{else}}val{{$index}}{{end}}{{end}})
+	out, err = {{$.Ptr}}.c.Call("{{.Key}}", web3.EncodeBlock(block...){{range $index, $val := tupleElems .Inputs}}, {{if .Name}}{{clean .Name}}{{else}}val{{$index}}{{end}}{{end}})
 	if err != nil {
 		return
 	}
@@ -324,17 +366,17 @@ func ({{$.Ptr}} *{{$.Name}}) {{funcName $key}}({{range $index, $val := tupleElem
 {{end}}{{end}}
 
 // txns
-{{range $key, $value := .Abi.Methods}}{{if not .Const}}
-// {{funcName $key}} sends a {{$key}} transaction in the solidity contract
-func ({{$.Ptr}} *{{$.Name}}) {{funcName $key}}({{range $index, $input := tupleElems .Inputs}}{{if $index}}, {{end}}{{clean .Name}} {{arg .}}{{end}}) *contract.Txn {
-	return {{$.Ptr}}.c.Txn("{{$key}}"{{range $index, $elem := tupleElems .Inputs}}, {{clean $elem.Name}}{{end}})
+{{range .Methods}}{{if not .Const}}
+// {{.GoName}} sends a {{.Key}} transaction in the solidity contract
+func ({{$.Ptr}} *{{$.Name}}) {{.GoName}}({{range $index, $input := tupleElems .Inputs}}{{if $index}}, {{end}}{{clean .Name}} {{arg .}}{{end}}) *contract.Txn {
+	return {{$.Ptr}}.c.Txn("{{.Key}}"{{range $index, $elem := tupleElems .Inputs}}, {{clean $elem.Name}}{{end}})
 }
 {{end}}{{end}}
 
 // events
-{{range $key, $value := .Abi.Events}}{{if not .Anonymous}}
+{{range .Events}}{{if not .Anonymous}}
 
-func({{$.Ptr}} *{{$.Name}}) {{title .Name}}TopicFilter({{getTopicFilterParam $value}})[][]web3.Hash{
+func({{$.Ptr}} *{{$.Name}}) {{.GoName}}TopicFilter({{getTopicFilterParam .Event}})[][]web3.Hash{
 	{{range $index, $input := tupleElems .Inputs}}
     {{if .Indexed}}var {{clean .Name}}Rule []interface{}
     for _, {{.Name}}Item := range {{clean .Name}} {
@@ -343,7 +385,7 @@ func({{$.Ptr}} *{{$.Name}}) {{title .Name}}TopicFilter({{getTopicFilterParam $va
 	{{end}}{{end}}
 
 	var query [][]interface{}
-	query = append(query,[]interface{}{ {{title .Name}}EventID} {{range $index, $input := tupleElems .Inputs}} {{if .Indexed}}, {{clean .Name}}Rule {{end}}{{end}})
+	query = append(query,[]interface{}{ {{.GoName}}EventID} {{range $index, $input := tupleElems .Inputs}} {{if .Indexed}}, {{clean .Name}}Rule {{end}}{{end}})
 
 	topics, err := contract.MakeTopics(query...)
 	utils.Ensure(err)
@@ -351,29 +393,56 @@ func({{$.Ptr}} *{{$.Name}}) {{title .Name}}TopicFilter({{getTopicFilterParam $va
 	return topics
 }
 
-func ({{$.Ptr}} *{{$.Name}}) Filter{{title .Name}}Event({{getFilterEventParam $value}})([]*{{title .Name}}Event, error){
-	topic :={{$.Ptr}}.{{title .Name}}TopicFilter({{getTopicFilterInput $value}})	
+func ({{$.Ptr}} *{{$.Name}}) Filter{{.GoName}}Event({{getFilterEventParam .Event}})([]*{{.GoName}}Event, error){
+	topic :={{$.Ptr}}.{{.GoName}}TopicFilter({{getTopicFilterInput .Event}})	
 
 	logs, err := {{$.Ptr}}.c.FilterLogsWithTopic(topic, startBlock, endBlock...)
 	if err != nil {
 		return nil, err
 	}
-	res := make([]*{{title .Name}}Event, 0)
-	evts := {{$.Ptr}}.c.Abi.Events["{{.Name}}"]
+	res := make([]*{{.GoName}}Event, 0)
 	for _, log := range logs {
-		args, err := evts.ParseLog(log)
+		evtItem, err := {{$.Ptr}}.Parse{{.GoName}}Event(log)
 		if err != nil {
 			return nil, err
 		}
-		var evtItem {{title .Name}}Event
-		err = json.Unmarshal([]byte(utils.JsonStr(args)), &evtItem)
+		res = append(res, evtItem)
+	}
+	return res, nil
+}
+
+// Watch{{.GoName}}Event sends the {{.Key}} events to sink, the events removed by a chain reorg have the Removed flag set
+func ({{$.Ptr}} *{{$.Name}}) Watch{{.GoName}}Event({{getWatchEventParam .GoName .Event}})(*contract.Subscription, error){
+	topic :={{$.Ptr}}.{{.GoName}}TopicFilter({{getTopicFilterInput .Event}})
+
+	return {{$.Ptr}}.c.WatchLogsWithTopic(opts, topic, func(ctx context.Context, log *web3.Log) error {
+		evtItem, err := {{$.Ptr}}.Parse{{.GoName}}Event(log)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		select {
+		case sink <- evtItem:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-		evtItem.Raw = log
-		res = append(res, &evtItem)
+	})
+}
+
+// Parse{{.GoName}}Event parses a {{.Key}} log
+func ({{$.Ptr}} *{{$.Name}}) Parse{{.GoName}}Event(log *web3.Log) (*{{.GoName}}Event, error) {
+	args, err := {{$.Ptr}}.c.Abi.GetEvent("{{.Key}}").ParseLog(log)
+	if err != nil {
+		return nil, err
 	}
-	return res, nil
+	var evtItem {{.GoName}}Event
+	err = json.Unmarshal([]byte(utils.JsonStr(args)), &evtItem)
+	if err != nil {
+		return nil, err
+	}
+	evtItem.Raw = log
+	evtItem.Removed = log.Removed
+	return &evtItem, nil
 }
 {{end}}{{end}}
 `
@@ -381,10 +450,12 @@ func ({{$.Ptr}} *{{$.Name}}) Filter{{title .Name}}Event({{getFilterEventParam $v
 var templateBinStr = `package {{.Config.Package}}
 
 import (
-	"encoding/hex"
+	{{if .Contract.HasLinkReferences}}"encoding/json"{{else}}"encoding/hex"{{end}}
 	"fmt"
 
-	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/abi"{{if .Contract.HasLinkReferences}}
+	"github.com/laizy/web3/compiler"{{end}}{{if .Abi.Errors}}
+	"github.com/laizy/web3/registry"{{end}}
 )
 
 var abi{{.Name}} *abi.ABI
@@ -395,14 +466,26 @@ func {{.Name}}Abi() *abi.ABI {
 }
 
 var bin{{.Name}} []byte
-{{if .Contract.Bin}}
+{{if .Contract.HasLinkReferences}}
+var linkReferences{{.Name}}, deployedLinkReferences{{.Name}} compiler.LinkReferences
+
+// {{.Name}}UnlinkedBin returns the bin of the {{.Name}} contract, the libraries have to be linked before deploying
+func {{.Name}}UnlinkedBin() string {
+	return bin{{.Name}}Str
+}
+
+// {{.Name}}LinkReferences returns the positions of the libraries in the bin and runtime bin of the {{.Name}} contract
+func {{.Name}}LinkReferences() (compiler.LinkReferences, compiler.LinkReferences) {
+	return linkReferences{{.Name}}, deployedLinkReferences{{.Name}}
+}
+{{else if .Contract.Bin}}
 // {{.Name}}Bin returns the bin of the {{.Name}} contract
 func {{.Name}}Bin() []byte {
 	return bin{{.Name}}
 }
 {{end}}
 var binRuntime{{.Name}} []byte
-{{if .Contract.BinRuntime}}
+{{if and .Contract.BinRuntime (not .Contract.HasLinkReferences)}}
 // {{.Name}}BinRuntime returns the runtime bin of the {{.Name}} contract
 func {{.Name}}BinRuntime() []byte {
 	return binRuntime{{.Name}}
@@ -413,7 +496,16 @@ func init() {
 	abi{{.Name}}, err = abi.NewABI(abi{{.Name}}Str)
 	if err != nil {
 		panic(fmt.Errorf("cannot parse {{.Name}} abi: %v", err))
+	}{{if .Abi.Errors}}
+	registry.ErrInstance().RegisterFromAbi(abi{{.Name}}){{end}}
+{{- if .Contract.HasLinkReferences}}
+	if err := json.Unmarshal([]byte(linkReferences{{.Name}}Str), &linkReferences{{.Name}}); err != nil {
+		panic(fmt.Errorf("cannot parse {{.Name}} link references: %v", err))
 	}
+	if err := json.Unmarshal([]byte(deployedLinkReferences{{.Name}}Str), &deployedLinkReferences{{.Name}}); err != nil {
+		panic(fmt.Errorf("cannot parse {{.Name}} deployed link references: %v", err))
+	}
+{{- else}}
 	if len(bin{{.Name}}Str) != 0 {
 		bin{{.Name}}, err = hex.DecodeString(bin{{.Name}}Str[2:])
 		if err != nil {
@@ -426,10 +518,15 @@ func init() {
 			panic(fmt.Errorf("cannot parse {{.Name}} bin runtime: %v", err))
 		}
 	}
+{{- end}}
 }
 
 var bin{{.Name}}Str = "{{.Contract.Bin}}"
 
 var binRuntime{{.Name}}Str = "{{.Contract.BinRuntime}}"
+{{if .Contract.HasLinkReferences}}
+var linkReferences{{.Name}}Str = ` + "`" + `{{toJson .Contract.LinkReferences}}` + "`" + `
 
+var deployedLinkReferences{{.Name}}Str = ` + "`" + `{{toJson .Contract.DeployedLinkReferences}}` + "`" + `
+{{end}}
 var abi{{.Name}}Str = ` + "`" + `{{.Contract.Abi}}` + "`\n"
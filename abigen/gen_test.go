@@ -2,6 +2,10 @@ package abigen
 
 import (
 	"go/format"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/laizy/web3/abi"
@@ -118,6 +122,7 @@ func TestConstructor(t *testing.T) {
 	expected, _ := format.Source([]byte(`package binding
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -130,6 +135,7 @@ import (
 )
 
 var (
+	_ = context.Background
 	_ = json.Unmarshal
 	_ = big.NewInt
 	_ = fmt.Printf
@@ -187,6 +193,7 @@ func TestCodeGen(t *testing.T) {
 	expected, _ := format.Source([]byte(`package binding
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -199,6 +206,7 @@ import (
 )
 
 var (
+	_ = context.Background
 	_ = json.Unmarshal
 	_ = big.NewInt
 	_ = fmt.Printf
@@ -218,7 +226,18 @@ func DeploySample(provider *jsonrpc.Client, from web3.Address) *contract.Txn {
 
 // NewSample creates a new instance of the contract at a specific address
 func NewSample(addr web3.Address, provider *jsonrpc.Client) *Sample {
-	return &Sample{c: contract.NewContract(addr, abiSample, provider)}
+	c := contract.NewContract(addr, abiSample, provider)
+	c.SetErrorFactory(newSampleError)
+	return &Sample{c: c}
+}
+
+// newSampleError returns the go type of the Sample custom error by name
+func newSampleError(name string) error {
+	switch name {
+	case "OnlyCoordinatorCanFulfill":
+		return &OnlyCoordinatorCanFulfillError{}
+	}
+	return nil
 }
 
 // Contract returns the contract object
@@ -338,21 +357,48 @@ func (_a *Sample) FilterDepositEvent(from []web3.Address, to []web3.Address, sta
 		return nil, err
 	}
 	res := make([]*DepositEvent, 0)
-	evts := _a.c.Abi.Events["Deposit"]
 	for _, log := range logs {
-		args, err := evts.ParseLog(log)
+		evtItem, err := _a.ParseDepositEvent(log)
 		if err != nil {
 			return nil, err
 		}
-		var evtItem DepositEvent
-		err = json.Unmarshal([]byte(utils.JsonStr(args)), &evtItem)
+		res = append(res, evtItem)
+	}
+	return res, nil
+}
+
+// WatchDepositEvent sends the Deposit events to sink, the events removed by a chain reorg have the Removed flag set
+func (_a *Sample) WatchDepositEvent(opts *contract.WatchOpts, sink chan<- *DepositEvent, from []web3.Address, to []web3.Address) (*contract.Subscription, error) {
+	topic := _a.DepositTopicFilter(from, to)
+
+	return _a.c.WatchLogsWithTopic(opts, topic, func(ctx context.Context, log *web3.Log) error {
+		evtItem, err := _a.ParseDepositEvent(log)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		evtItem.Raw = log
-		res = append(res, &evtItem)
+		select {
+		case sink <- evtItem:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// ParseDepositEvent parses a Deposit log
+func (_a *Sample) ParseDepositEvent(log *web3.Log) (*DepositEvent, error) {
+	args, err := _a.c.Abi.GetEvent("Deposit").ParseLog(log)
+	if err != nil {
+		return nil, err
 	}
-	return res, nil
+	var evtItem DepositEvent
+	err = json.Unmarshal([]byte(utils.JsonStr(args)), &evtItem)
+	if err != nil {
+		return nil, err
+	}
+	evtItem.Raw = log
+	evtItem.Removed = log.Removed
+	return &evtItem, nil
 }
 
 func (_a *Sample) TransferTopicFilter(from []web3.Address, to []web3.Address, amount []web3.Address) [][]web3.Hash {
@@ -389,21 +435,48 @@ func (_a *Sample) FilterTransferEvent(from []web3.Address, to []web3.Address, am
 		return nil, err
 	}
 	res := make([]*TransferEvent, 0)
-	evts := _a.c.Abi.Events["Transfer"]
 	for _, log := range logs {
-		args, err := evts.ParseLog(log)
+		evtItem, err := _a.ParseTransferEvent(log)
 		if err != nil {
 			return nil, err
 		}
-		var evtItem TransferEvent
-		err = json.Unmarshal([]byte(utils.JsonStr(args)), &evtItem)
+		res = append(res, evtItem)
+	}
+	return res, nil
+}
+
+// WatchTransferEvent sends the Transfer events to sink, the events removed by a chain reorg have the Removed flag set
+func (_a *Sample) WatchTransferEvent(opts *contract.WatchOpts, sink chan<- *TransferEvent, from []web3.Address, to []web3.Address, amount []web3.Address) (*contract.Subscription, error) {
+	topic := _a.TransferTopicFilter(from, to, amount)
+
+	return _a.c.WatchLogsWithTopic(opts, topic, func(ctx context.Context, log *web3.Log) error {
+		evtItem, err := _a.ParseTransferEvent(log)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		select {
+		case sink <- evtItem:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-		evtItem.Raw = log
-		res = append(res, &evtItem)
+	})
+}
+
+// ParseTransferEvent parses a Transfer log
+func (_a *Sample) ParseTransferEvent(log *web3.Log) (*TransferEvent, error) {
+	args, err := _a.c.Abi.GetEvent("Transfer").ParseLog(log)
+	if err != nil {
+		return nil, err
 	}
-	return res, nil
+	var evtItem TransferEvent
+	err = json.Unmarshal([]byte(utils.JsonStr(args)), &evtItem)
+	if err != nil {
+		return nil, err
+	}
+	evtItem.Raw = log
+	evtItem.Removed = log.Removed
+	return &evtItem, nil
 }
 
 func (_a *Sample) NoNameTopicFilter(arg0 []web3.Address) [][]web3.Hash {
@@ -430,21 +503,48 @@ func (_a *Sample) FilterNoNameEvent(arg0 []web3.Address, startBlock uint64, endB
 		return nil, err
 	}
 	res := make([]*NoNameEvent, 0)
-	evts := _a.c.Abi.Events["noName"]
 	for _, log := range logs {
-		args, err := evts.ParseLog(log)
+		evtItem, err := _a.ParseNoNameEvent(log)
 		if err != nil {
 			return nil, err
 		}
-		var evtItem NoNameEvent
-		err = json.Unmarshal([]byte(utils.JsonStr(args)), &evtItem)
+		res = append(res, evtItem)
+	}
+	return res, nil
+}
+
+// WatchNoNameEvent sends the noName events to sink, the events removed by a chain reorg have the Removed flag set
+func (_a *Sample) WatchNoNameEvent(opts *contract.WatchOpts, sink chan<- *NoNameEvent, arg0 []web3.Address) (*contract.Subscription, error) {
+	topic := _a.NoNameTopicFilter(arg0)
+
+	return _a.c.WatchLogsWithTopic(opts, topic, func(ctx context.Context, log *web3.Log) error {
+		evtItem, err := _a.ParseNoNameEvent(log)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		evtItem.Raw = log
-		res = append(res, &evtItem)
+		select {
+		case sink <- evtItem:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// ParseNoNameEvent parses a noName log
+func (_a *Sample) ParseNoNameEvent(log *web3.Log) (*NoNameEvent, error) {
+	args, err := _a.c.Abi.GetEvent("noName").ParseLog(log)
+	if err != nil {
+		return nil, err
 	}
-	return res, nil
+	var evtItem NoNameEvent
+	err = json.Unmarshal([]byte(utils.JsonStr(args)), &evtItem)
+	if err != nil {
+		return nil, err
+	}
+	evtItem.Raw = log
+	evtItem.Removed = log.Removed
+	return &evtItem, nil
 }
 `))
 
@@ -484,7 +584,8 @@ type DepositEvent struct {
 	Amount *big.Int
 	Data   []byte
 
-	Raw *web3.Log
+	Raw     *web3.Log
+	Removed bool
 }
 
 var NoNameEventID = crypto.Keccak256Hash([]byte("noName(address,address)"))
@@ -493,7 +594,17 @@ type NoNameEvent struct {
 	Arg0 web3.Address
 	Arg1 web3.Address
 
-	Raw *web3.Log
+	Raw     *web3.Log
+	Removed bool
+}
+
+type OnlyCoordinatorCanFulfillError struct {
+	Have web3.Address
+	Want web3.Address
+}
+
+func (e *OnlyCoordinatorCanFulfillError) Error() string {
+	return fmt.Sprintf("OnlyCoordinatorCanFulfill(%v, %v)", e.Have, e.Want)
 }
 
 type Output struct {
@@ -516,7 +627,8 @@ type TransferEvent struct {
 	To     web3.Address
 	Amount web3.Address
 
-	Raw *web3.Log
+	Raw     *web3.Log
+	Removed bool
 }
 `))
 
@@ -578,3 +690,95 @@ func TestEncodeTopic(t *testing.T) {
 	assert.Equal("web3.Hash", encodeTopicArg(typ.TupleElems()[1]))
 
 }
+
+func TestOverloadedNames(t *testing.T) {
+	assert := require.New(t)
+	contractAbi, err := abi.NewABIFromList([]string{
+		"function safeTransferFrom(address from, address to, uint256 tokenId)",
+		"function safeTransferFrom(address from, address to, uint256 tokenId, bytes data)",
+		"function burn(uint256 amount)",
+		"function burn(address)",
+		"function burn(bytes32[])",
+		"function contract() view returns (address)",
+		"function name() view returns (string)",
+		"event Transfer(address indexed from, address indexed to, uint256 value)",
+		"event Transfer(address indexed from, address indexed to, uint256 indexed tokenId, bytes data)",
+	})
+	assert.Nil(err)
+
+	names := map[string]string{}
+	for _, m := range bindMethods(contractAbi) {
+		names[m.Key] = m.GoName
+		assert.Equal(m.Method, contractAbi.GetMethod(m.Key))
+	}
+	assert.Equal(map[string]string{
+		"safeTransferFrom(address,address,uint256)":       "SafeTransferFrom",
+		"safeTransferFrom(address,address,uint256,bytes)": "SafeTransferFrom4",
+		"burn(address)":   "Burn",
+		"burn(bytes32[])": "BurnBytes32Array",
+		"burn(uint256)":   "BurnUint256",
+		"contract":        "Contract0",
+		"name":            "Name",
+	}, names)
+
+	names = map[string]string{}
+	for _, e := range bindEvents(contractAbi) {
+		names[e.Key] = e.GoName
+		assert.Equal(e.Event, contractAbi.GetEvent(e.Key))
+	}
+	assert.Equal(map[string]string{
+		"Transfer(address,address,uint256)":       "Transfer",
+		"Transfer(address,address,uint256,bytes)": "Transfer4",
+	}, names)
+
+	defs := NewStructDefExtractor().ExtractFromAbi(contractAbi)
+	assert.NotNil(defs.Defs["TransferEvent"])
+	assert.NotNil(defs.Defs["Transfer4Event"])
+}
+
+// overloadedAbi mixes the overloads of ERC721 and ERC777
+const overloadedAbi = `[
+	{"type": "function", "name": "safeTransferFrom", "stateMutability": "nonpayable", "outputs": [], "inputs": [
+		{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "tokenId", "type": "uint256"}]},
+	{"type": "function", "name": "safeTransferFrom", "stateMutability": "nonpayable", "outputs": [], "inputs": [
+		{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "tokenId", "type": "uint256"}, {"name": "data", "type": "bytes"}]},
+	{"type": "function", "name": "burn", "stateMutability": "nonpayable", "outputs": [], "inputs": [
+		{"name": "tokenId", "type": "uint256"}]},
+	{"type": "function", "name": "burn", "stateMutability": "nonpayable", "outputs": [], "inputs": [
+		{"name": "amount", "type": "uint256"}, {"name": "data", "type": "bytes"}]},
+	{"type": "function", "name": "balanceOf", "stateMutability": "view", "inputs": [
+		{"name": "owner", "type": "address"}], "outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "contract", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "address"}]},
+	{"type": "event", "name": "Transfer", "anonymous": false, "inputs": [
+		{"name": "from", "type": "address", "indexed": true}, {"name": "to", "type": "address", "indexed": true},
+		{"name": "tokenId", "type": "uint256", "indexed": true}]},
+	{"type": "event", "name": "Transfer", "anonymous": false, "inputs": [
+		{"name": "from", "type": "address", "indexed": true}, {"name": "to", "type": "address", "indexed": true},
+		{"name": "tokenId", "type": "uint256", "indexed": true}, {"name": "data", "type": "bytes", "indexed": false}]},
+	{"type": "event", "name": "Burned", "anonymous": false, "inputs": [
+		{"name": "operator", "type": "address", "indexed": true}, {"name": "from", "type": "address", "indexed": true},
+		{"name": "amount", "type": "uint256", "indexed": false}, {"name": "data", "type": "bytes", "indexed": false}]}
+]`
+
+func TestOverloadedBindings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the build of the bindings in short mode")
+	}
+	// the bindings are built in the module to resolve its imports
+	dir, err := ioutil.TempDir("testdata", "overloaded")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &Config{Package: "overloaded", Output: dir}
+	artifacts := map[string]*compiler.Artifact{"Token": compiler.NewArtifact(overloadedAbi, "0x00", "0x00")}
+	require.NoError(t, GenCode(artifacts, config))
+
+	code, err := ioutil.ReadFile(filepath.Join(dir, "token.go"))
+	require.NoError(t, err)
+	for _, fn := range []string{"SafeTransferFrom4(", "Burn2(", "Contract0(", "WatchTransfer4Event(", "ParseTransferEvent("} {
+		require.Contains(t, string(code), "func (_a *Token) "+fn)
+	}
+
+	out, err := exec.Command("go", "vet", "./"+filepath.ToSlash(dir)).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
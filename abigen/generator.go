@@ -43,19 +43,14 @@ func (g *Generator) Gen() (res Result, err error) {
 			return Result{}, err
 		}
 
-		for n, e := range abi.Events { //replace old event to get event nil arg's name
-			abi.Events[n] = optimizeEvent(e)
-		}
-		// replace old input to get input nil arg's name
-		for n, m := range abi.Methods {
-			abi.Methods[n] = optimizeInput(m)
-		}
 		fileName := strings.ToLower(name)
 		input := map[string]interface{}{
 			"Ptr":      "_a",
 			"Config":   g.Config,
 			"Contract": artifact,
 			"Abi":      abi,
+			"Methods":  bindMethods(abi),
+			"Events":   bindEvents(abi),
 			"Name":     name,
 		}
 		abiCode, err := genCodeToBytes("eth-abi", g.funcMap, templateAbiStr, input)
@@ -0,0 +1,200 @@
+package abigen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/laizy/web3/abi"
+)
+
+// reservedNames are the methods already defined in the generated contract type
+var reservedNames = map[string]bool{"Contract": true}
+
+type methodBinding struct {
+	*abi.Method
+	// GoName is the name of the generated go method
+	GoName string
+	// Key is the name used to find the method in the abi, the signature if overloaded
+	Key string
+}
+
+type eventBinding struct {
+	*abi.Event
+	// GoName is the name of the generated go type and methods of the event
+	GoName string
+	// Key is the name used to find the event in the abi, the signature if overloaded
+	Key string
+}
+
+// bindMethods returns the methods of the abi sorted by key, the overloaded ones have
+// a unique go name and are found by signature.
+func bindMethods(contractAbi *abi.ABI) []*methodBinding {
+	all := make([]*abi.Method, 0, len(contractAbi.MethodsBySig))
+	for _, m := range contractAbi.MethodsBySig {
+		all = append(all, m)
+	}
+	// methods created without the signature index are only found by name
+	for _, m := range contractAbi.Methods {
+		if _, ok := contractAbi.MethodsBySig[m.Sig()]; !ok {
+			all = append(all, m)
+		}
+	}
+
+	groups := map[string][]*abi.Method{}
+	for _, m := range all {
+		groups[m.Name] = append(groups[m.Name], m)
+	}
+	var res []*methodBinding
+	for name, methods := range groups {
+		if len(methods) == 1 {
+			res = append(res, &methodBinding{Method: optimizeInput(methods[0]), GoName: funcName(name), Key: name})
+			continue
+		}
+		inputs := make([]*abi.Type, len(methods))
+		for i, m := range methods {
+			inputs[i] = m.Inputs
+		}
+		for i, goName := range overloadedNames(funcName(name), inputs) {
+			m := methods[i]
+			res = append(res, &methodBinding{Method: optimizeInput(m), GoName: goName, Key: m.Sig()})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+
+	used := map[string]bool{}
+	for name := range reservedNames {
+		used[name] = true
+	}
+	for _, m := range res {
+		m.GoName = uniqueName(m.GoName, used)
+	}
+	return res
+}
+
+// bindEvents returns the events of the abi sorted by key, the overloaded ones have
+// a unique go name and are found by signature.
+func bindEvents(contractAbi *abi.ABI) []*eventBinding {
+	all := make([]*abi.Event, 0, len(contractAbi.EventsBySig))
+	for _, e := range contractAbi.EventsBySig {
+		all = append(all, e)
+	}
+	// events created without the signature index are only found by name
+	for _, e := range contractAbi.Events {
+		if _, ok := contractAbi.EventsBySig[e.Sig()]; !ok {
+			all = append(all, e)
+		}
+	}
+
+	groups := map[string][]*abi.Event{}
+	for _, e := range all {
+		groups[e.Name] = append(groups[e.Name], e)
+	}
+	var res []*eventBinding
+	for name, events := range groups {
+		if len(events) == 1 {
+			res = append(res, &eventBinding{Event: optimizeEvent(events[0]), GoName: strings.Title(name), Key: name})
+			continue
+		}
+		inputs := make([]*abi.Type, len(events))
+		for i, e := range events {
+			inputs[i] = e.Inputs
+		}
+		for i, goName := range overloadedNames(strings.Title(name), inputs) {
+			e := events[i]
+			res = append(res, &eventBinding{Event: optimizeEvent(e), GoName: goName, Key: e.Sig()})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+
+	used := map[string]bool{}
+	for _, e := range res {
+		e.GoName = uniqueName(e.GoName, used)
+	}
+	return res
+}
+
+// overloadedNames returns the go names of the overloads with the inputs. The overload
+// with the less arguments, then the first signature, keeps the name, the rest are suffixed
+// with the number of arguments or, if other overload has the same number, with the
+// argument types:
+//
+//	safeTransferFrom(address,address,uint256)       -> SafeTransferFrom
+//	safeTransferFrom(address,address,uint256,bytes) -> SafeTransferFrom4
+//	burn(address)                                   -> Burn
+//	burn(uint256)                                   -> BurnUint256
+func overloadedNames(name string, inputs []*abi.Type) []string {
+	order := make([]int, len(inputs))
+	for i := range order {
+		order[i] = i
+	}
+	sigs := make([]string, len(inputs))
+	arity := map[int]int{}
+	for i, typ := range inputs {
+		sigs[i] = typ.String()
+		arity[len(typ.TupleElems())]++
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := inputs[order[i]], inputs[order[j]]
+		if len(a.TupleElems()) != len(b.TupleElems()) {
+			return len(a.TupleElems()) < len(b.TupleElems())
+		}
+		return sigs[order[i]] < sigs[order[j]]
+	})
+
+	res := make([]string, len(inputs))
+	for n, i := range order {
+		num := len(inputs[i].TupleElems())
+		switch {
+		case n == 0:
+			res[i] = name
+		case arity[num] == 1:
+			res[i] = name + strconv.Itoa(num)
+		default:
+			res[i] = name + typesSuffix(inputs[i])
+		}
+	}
+	return res
+}
+
+func typesSuffix(tuple *abi.Type) string {
+	if len(tuple.TupleElems()) == 0 {
+		return "0"
+	}
+	suffix := ""
+	for _, elem := range tuple.TupleElems() {
+		suffix += typeSuffix(elem.Elem)
+	}
+	return suffix
+}
+
+func typeSuffix(typ *abi.Type) string {
+	switch typ.Kind() {
+	case abi.KindSlice:
+		return typeSuffix(typ.Elem()) + "Array"
+	case abi.KindArray:
+		return fmt.Sprintf("%sArray%d", typeSuffix(typ.Elem()), typ.Size())
+	case abi.KindTuple:
+		if name := typ.RawName(); name != "" {
+			return strings.Title(name)
+		}
+		return "Tuple"
+	default:
+		return strings.Title(typ.String())
+	}
+}
+
+// uniqueName returns name, or name with a numeric suffix if it is already used
+func uniqueName(name string, used map[string]bool) string {
+	res := name
+	for i := 0; used[res]; i++ {
+		res = name + strconv.Itoa(i)
+	}
+	used[res] = true
+	return res
+}
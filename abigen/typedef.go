@@ -29,6 +29,8 @@ type StructDef struct {
 	IsEvent   bool
 	EventID   string
 	EventName string
+	IsError   bool
+	ErrorName string
 }
 
 type StructDefExtractor struct {
@@ -53,7 +55,7 @@ func (self *StructDefExtractor) extractNormal(typ *abi.Type) string {
 	case abi.KindInt:
 		return typ.GoType().String()
 
-	case abi.KindUInt:
+	case abi.KindUInt, abi.KindFixedPoint:
 		return typ.GoType().String()
 
 	case abi.KindFixedBytes:
@@ -100,7 +102,11 @@ func (self *StructDefExtractor) ExtractFromType(typ *abi.Type) string {
 
 //ExtractEvent generate event type, and record it for not duplicated.
 func (self *StructDefExtractor) ExtractEvent(e *abi.Event) {
-	s := &StructDef{Name: strings.Title(e.Name) + "Event", IsEvent: true, EventName: strings.Title(e.Name) + "EventID", EventID: buildSignature(e.Name, e.Inputs)}
+	self.extractEvent(e, strings.Title(e.Name))
+}
+
+func (self *StructDefExtractor) extractEvent(e *abi.Event, goName string) {
+	s := &StructDef{Name: goName + "Event", IsEvent: true, EventName: goName + "EventID", EventID: buildSignature(e.Name, e.Inputs)}
 	for _, elem := range e.Inputs.TupleElems() {
 		typ := self.ExtractFromType(elem.Elem)
 		if elem.Indexed {
@@ -116,18 +122,47 @@ func (self *StructDefExtractor) ExtractEvent(e *abi.Event) {
 	self.Defs[s.Name] = s
 }
 
+//ExtractError generate the go error type of a custom error, and record it for not duplicated.
+func (self *StructDefExtractor) ExtractError(e *abi.Error) {
+	s := &StructDef{Name: errorTypeName(e.Name), IsError: true, ErrorName: e.Name}
+	for i, elem := range e.Inputs.TupleElems() {
+		name := elem.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		s.Fields = append(s.Fields, &FieldDef{Name: name, Type: self.ExtractFromType(elem.Elem)})
+	}
+	if old, exist := self.Defs[s.Name]; exist { // check if two struct have same name but different struct, panic.
+		if !reflect.DeepEqual(s, old) {
+			panic(ErrConflictDef)
+		}
+	}
+	self.Defs[s.Name] = s
+}
+
+//errorTypeName returns the go type name of a custom error.
+func errorTypeName(name string) string {
+	name = strings.Title(name)
+	if strings.HasSuffix(name, "Error") {
+		return name
+	}
+	return name + "Error"
+}
+
 func (self *StructDefExtractor) ExtractFromAbi(abi *abi.ABI) *StructDefExtractor {
 	if abi.Constructor != nil {
 		self.ExtractFromType(abi.Constructor.Inputs)
 	}
-	for _, method := range abi.Methods {
+	for _, method := range bindMethods(abi) {
 		self.ExtractFromType(method.Inputs)
 		self.ExtractFromType(method.Outputs)
 	}
-	for _, event := range abi.Events {
-		ev := optimizeEvent(event)
-		self.ExtractFromType(ev.Inputs)
-		self.ExtractEvent(ev)
+	for _, event := range bindEvents(abi) {
+		self.ExtractFromType(event.Inputs)
+		self.extractEvent(event.Event, event.GoName)
+	}
+	for _, e := range abi.Errors {
+		self.ExtractError(e)
 	}
 
 	return self
@@ -208,7 +243,12 @@ type {{.Name}} struct {
 {{range .Fields}}
 {{title .Name}}   {{.Type}} {{end}}
 {{if .IsEvent}}
-Raw *web3.Log {{end}}
+Raw *web3.Log
+Removed bool {{end}}
+}
+{{if .IsError}}
+func (e *{{.Name}}) Error() string {
+	return fmt.Sprintf("{{.ErrorName}}({{range $i, $f := .Fields}}{{if $i}}, {{end}}%v{{end}})"{{range .Fields}}, e.{{title .Name}}{{end}})
 }
-{{end}}
+{{end}}{{end}}
 `
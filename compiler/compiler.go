@@ -32,8 +32,70 @@ type Artifact struct {
 	Abi        string
 	Bin        string
 	BinRuntime string
+
+	// SourceName is the source file that defines the contract, if known
+	SourceName string
+	// LinkReferences are the library placeholders of Bin and BinRuntime
+	LinkReferences         LinkReferences
+	DeployedLinkReferences LinkReferences
+
+	// SourceMap and DeployedSourceMap are the compressed source maps of Bin and BinRuntime,
+	// the source indexes refer to Sources
+	SourceMap         string
+	DeployedSourceMap string
+	Sources           []string
+	// ImmutableReferences are the positions of the immutable variables in BinRuntime by ast id
+	ImmutableReferences map[string][]LinkReference
+	// MethodIdentifiers are the selectors in hex by method signature
+	MethodIdentifiers map[string]string
+	// Metadata is the metadata json of the contract
+	Metadata      string
+	StorageLayout *StorageLayout
+}
+
+// StorageLayout is the layout of the state variables of a contract (solc 0.5.13 or later)
+type StorageLayout struct {
+	Storage []*StorageSlot          `json:"storage"`
+	Types   map[string]*StorageType `json:"types"`
 }
 
+// StorageSlot is a state variable or a struct member in the storage layout
+type StorageSlot struct {
+	AstID    int    `json:"astId"`
+	Contract string `json:"contract"`
+	Label    string `json:"label"`
+	Offset   int    `json:"offset"`
+	Slot     string `json:"slot"`
+	Type     string `json:"type"`
+}
+
+// StorageType is a type of the storage layout, Key and Value are set for mappings,
+// Base for arrays and Members for structs
+type StorageType struct {
+	Encoding      string         `json:"encoding"`
+	Label         string         `json:"label"`
+	NumberOfBytes string         `json:"numberOfBytes"`
+	Key           string         `json:"key,omitempty"`
+	Value         string         `json:"value,omitempty"`
+	Base          string         `json:"base,omitempty"`
+	Members       []*StorageSlot `json:"members,omitempty"`
+}
+
+// HasLinkReferences returns whether the bytecode has library placeholders to be linked
+func (a *Artifact) HasLinkReferences() bool {
+	return len(a.LinkReferences) != 0 || len(a.DeployedLinkReferences) != 0 ||
+		strings.Contains(a.Bin, "__") || strings.Contains(a.BinRuntime, "__")
+}
+
+// LinkReference is the position in bytes of a library address in the bytecode
+type LinkReference struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// LinkReferences are the positions of the libraries by source file and library name
+type LinkReferences map[string]map[string][]LinkReference
+
 func NewArtifact(abi, bin, binRuntime string) *Artifact {
 	return &Artifact{
 		Abi:        abi,
@@ -0,0 +1,134 @@
+package compiler
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/crypto"
+)
+
+// Placeholder returns the placeholder of the library in the unlinked bytecode, the fully
+// qualified name is 'source:name'
+func Placeholder(fqName string) string {
+	return "__$" + hex.EncodeToString(crypto.Keccak256([]byte(fqName)))[:34] + "$__"
+}
+
+// legacyPlaceholder returns the placeholder used by solc before 0.5.0, the name padded
+// with underscores
+func legacyPlaceholder(fqName string) string {
+	name := "__" + fqName
+	if len(name) > 38 {
+		name = name[:38]
+	}
+	return name + strings.Repeat("_", 40-len(name))
+}
+
+var placeholderRegexp = regexp.MustCompile(`__\$[0-9a-fA-F]{34}\$__|__[^$]{36}__`)
+
+// Libraries returns the fully qualified names of the libraries of the bytecode and the
+// runtime bytecode, sorted
+func (a *Artifact) Libraries() []string {
+	names := map[string]bool{}
+	for _, refs := range []LinkReferences{a.LinkReferences, a.DeployedLinkReferences} {
+		for source, libs := range refs {
+			for name := range libs {
+				names[source+":"+name] = true
+			}
+		}
+	}
+	res := make([]string, 0, len(names))
+	for name := range names {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Link returns a copy of the artifact with the libraries linked. The libraries are
+// keyed by fully qualified name ('source:name') or by name.
+func (a *Artifact) Link(libraries map[string]web3.Address) (*Artifact, error) {
+	bin, err := LinkBytecode(a.Bin, a.LinkReferences, libraries)
+	if err != nil {
+		return nil, err
+	}
+	binRuntime, err := LinkBytecode(a.BinRuntime, a.DeployedLinkReferences, libraries)
+	if err != nil {
+		return nil, err
+	}
+	linked := *a
+	linked.Bin = bin
+	linked.BinRuntime = binRuntime
+	linked.LinkReferences = nil
+	linked.DeployedLinkReferences = nil
+	return &linked, nil
+}
+
+// LinkBytecode replaces the library placeholders of the hex bytecode with the library
+// addresses. The link references give the positions of the placeholders, if nil the
+// placeholders are found by the fully qualified names of the libraries.
+func LinkBytecode(code string, refs LinkReferences, libraries map[string]web3.Address) (string, error) {
+	prefix := ""
+	if strings.HasPrefix(code, "0x") {
+		prefix, code = "0x", code[2:]
+	}
+	buf := []byte(code)
+
+	for source, libs := range refs {
+		for name, positions := range libs {
+			addr, err := lookupLibrary(libraries, source, name)
+			if err != nil {
+				return "", err
+			}
+			hexAddr := hex.EncodeToString(addr[:])
+			for _, pos := range positions {
+				start, end := pos.Start*2, (pos.Start+pos.Length)*2
+				if pos.Length != 20 || end > len(buf) {
+					return "", fmt.Errorf("invalid link reference of %s:%s at %d", source, name, pos.Start)
+				}
+				copy(buf[start:end], hexAddr)
+			}
+		}
+	}
+
+	if refs == nil {
+		// match the placeholders with the given libraries
+		known := map[string]web3.Address{}
+		for fqName, addr := range libraries {
+			known[Placeholder(fqName)] = addr
+			known[legacyPlaceholder(fqName)] = addr
+		}
+		var err error
+		buf = placeholderRegexp.ReplaceAllFunc(buf, func(placeholder []byte) []byte {
+			addr, ok := known[string(placeholder)]
+			if !ok {
+				if err == nil {
+					err = fmt.Errorf("library of placeholder %s not found", placeholder)
+				}
+				return placeholder
+			}
+			return []byte(hex.EncodeToString(addr[:]))
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if loc := placeholderRegexp.FindIndex(buf); loc != nil {
+		return "", fmt.Errorf("unlinked library %s at %d", buf[loc[0]:loc[1]], loc[0]/2)
+	}
+	return prefix + string(buf), nil
+}
+
+func lookupLibrary(libraries map[string]web3.Address, source, name string) (web3.Address, error) {
+	if addr, ok := libraries[source+":"+name]; ok {
+		return addr, nil
+	}
+	if addr, ok := libraries[name]; ok {
+		return addr, nil
+	}
+	return web3.Address{}, fmt.Errorf("library %s:%s not found", source, name)
+}
@@ -0,0 +1,49 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkBytecode(t *testing.T) {
+	lib := web3.HexToAddress("0x00000000000000000000000000000000000000aa")
+	placeholder := Placeholder("contracts/Math.sol:Math")
+	require.Equal(t, 40, len(placeholder))
+	require.True(t, strings.HasPrefix(placeholder, "__$"))
+
+	code := "0x73" + placeholder + "6080"
+	refs := LinkReferences{"contracts/Math.sol": {"Math": {{Start: 1, Length: 20}}}}
+	expected := "0x73" + strings.TrimPrefix(lib.String(), "0x") + "6080"
+
+	// by link references
+	linked, err := LinkBytecode(code, refs, map[string]web3.Address{"Math": lib})
+	require.NoError(t, err)
+	require.Equal(t, expected, linked)
+
+	// by placeholder
+	linked, err = LinkBytecode(code, nil, map[string]web3.Address{"contracts/Math.sol:Math": lib})
+	require.NoError(t, err)
+	require.Equal(t, expected, linked)
+
+	// legacy placeholder
+	linked, err = LinkBytecode("0x73"+legacyPlaceholder("contracts/Math.sol:Math")+"6080", nil, map[string]web3.Address{"contracts/Math.sol:Math": lib})
+	require.NoError(t, err)
+	require.Equal(t, expected, linked)
+
+	_, err = LinkBytecode(code, refs, map[string]web3.Address{})
+	require.EqualError(t, err, "library contracts/Math.sol:Math not found")
+	_, err = LinkBytecode(code, nil, map[string]web3.Address{"Other": lib})
+	require.Error(t, err)
+
+	artifact := &Artifact{Bin: code, BinRuntime: code, LinkReferences: refs, DeployedLinkReferences: refs}
+	require.True(t, artifact.HasLinkReferences())
+	require.Equal(t, []string{"contracts/Math.sol:Math"}, artifact.Libraries())
+	linkedArtifact, err := artifact.Link(map[string]web3.Address{"Math": lib})
+	require.NoError(t, err)
+	require.Equal(t, expected, linkedArtifact.BinRuntime)
+	require.False(t, linkedArtifact.HasLinkReferences())
+	require.Equal(t, code, artifact.Bin)
+}
@@ -0,0 +1,348 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Manager is a local cache of compiler binaries indexed by version, it does not need the
+// network once populated. The binaries are stored as '<dir>/<compiler>/<version>/<compiler>'
+// and verified with the sha256 checksums of '<dir>/<compiler>/list.json', which has the
+// format of the solc-bin lists:
+//
+//	{"builds": [{"version": "0.8.10", "sha256": "0x..."}]}
+type Manager struct {
+	Dir string
+
+	// Settings are the settings of the solidity compilers, the path is ignored
+	Settings *Solidity
+
+	lock     sync.Mutex
+	verified map[string]bool
+}
+
+// NewManager returns the manager of the compilers cached in dir
+func NewManager(dir string) *Manager {
+	return &Manager{Dir: dir, verified: map[string]bool{}}
+}
+
+// DefaultManagerDir returns the default cache directory, '~/.web3/compilers'
+func DefaultManagerDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.TempDir()
+	}
+	return filepath.Join(home, ".web3", "compilers")
+}
+
+type checksumList struct {
+	Builds []struct {
+		Version string `json:"version"`
+		Sha256  string `json:"sha256"`
+	} `json:"builds"`
+}
+
+// checksums returns the sha256 checksums by version of the compiler
+func (m *Manager) checksums(name string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(m.Dir, name, "list.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	var list checksumList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid checksum list of %s: %v", name, err)
+	}
+	res := map[string]string{}
+	for _, build := range list.Builds {
+		v, err := ParseVersion(build.Version)
+		if err != nil {
+			return nil, err
+		}
+		res[v.String()] = strings.ToLower(strings.TrimPrefix(build.Sha256, "0x"))
+	}
+	return res, nil
+}
+
+// Versions returns the installed versions of the compiler in ascending order
+func (m *Manager) Versions(name string) ([]*Version, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(m.Dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var res []*Version
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		v, err := ParseVersion(entry.Name())
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(m.binary(name, v)); err == nil {
+			res = append(res, v)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Cmp(res[j]) < 0
+	})
+	return res, nil
+}
+
+func (m *Manager) binary(name string, v *Version) string {
+	return filepath.Join(m.Dir, name, v.String(), name)
+}
+
+// Path returns the path of an installed compiler after verifying its checksum
+func (m *Manager) Path(name string, v *Version) (string, error) {
+	path := m.binary(name, v)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	// the binary is verified again if modified
+	key := fmt.Sprintf("%s:%d:%d", path, info.Size(), info.ModTime().UnixNano())
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.verified[key] {
+		return path, nil
+	}
+	checksums, err := m.checksums(name)
+	if err != nil {
+		return "", err
+	}
+	expected, ok := checksums[v.String()]
+	if !ok {
+		return "", fmt.Errorf("no checksum for %s %s", name, v)
+	}
+	found, err := fileChecksum(path)
+	if err != nil {
+		return "", err
+	}
+	if found != expected {
+		return "", fmt.Errorf("checksum mismatch of %s %s: expected %s but found %s", name, v, expected, found)
+	}
+	if m.verified == nil {
+		m.verified = map[string]bool{}
+	}
+	m.verified[key] = true
+	return path, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Install copies the compiler binary in src to the cache, the checksum list has to
+// include the version
+func (m *Manager) Install(name string, version string, src string) error {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return err
+	}
+	dst := m.binary(name, v)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(dst, data, 0755); err != nil {
+		return err
+	}
+	if _, err := m.Path(name, v); err != nil {
+		os.RemoveAll(filepath.Dir(dst))
+		return err
+	}
+	return nil
+}
+
+// Resolve returns the highest installed version of the compiler that matches all the
+// constraints
+func (m *Manager) Resolve(name string, constraints ...*Constraint) (*Version, error) {
+	versions, err := m.Versions(name)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if matchAll(versions[i], constraints) {
+			return versions[i], nil
+		}
+	}
+	ranges := make([]string, len(constraints))
+	for i, c := range constraints {
+		ranges[i] = c.String()
+	}
+	return nil, fmt.Errorf("no installed %s matches '%s'", name, strings.Join(ranges, "' and '"))
+}
+
+func matchAll(v *Version, constraints []*Constraint) bool {
+	for _, c := range constraints {
+		if !c.Match(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compiler returns the compiler of the installed version
+func (m *Manager) Compiler(name string, v *Version) (Compiler, error) {
+	path, err := m.Path(name, v)
+	if err != nil {
+		return nil, err
+	}
+	if name == "solidity" && m.Settings != nil {
+		solc := *m.Settings
+		solc.Path = path
+		return &solc, nil
+	}
+	return NewCompiler(name, path)
+}
+
+// CompileJob are the files compiled with one compiler version
+type CompileJob struct {
+	Compiler string
+	Version  *Version
+	Files    []string
+}
+
+// Plan groups the files by the highest installed compiler version that matches their
+// pragmas and the ones of their local imports. The files of different versions, or
+// solidity and vyper files, are compiled by separate jobs.
+func (m *Manager) Plan(files ...string) ([]*CompileJob, error) {
+	jobs := map[string]*CompileJob{}
+	for _, file := range files {
+		name := compilerName(file)
+		if name == "" {
+			return nil, fmt.Errorf("unknown compiler of %s", file)
+		}
+		constraints, err := fileConstraints(name, file, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		v, err := m.Resolve(name, constraints...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		key := name + "@" + v.String()
+		job, ok := jobs[key]
+		if !ok {
+			job = &CompileJob{Compiler: name, Version: v}
+			jobs[key] = job
+		}
+		job.Files = append(job.Files, file)
+	}
+
+	res := make([]*CompileJob, 0, len(jobs))
+	for _, job := range jobs {
+		res = append(res, job)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Compiler != res[j].Compiler {
+			return res[i].Compiler < res[j].Compiler
+		}
+		return res[i].Version.Cmp(res[j].Version) < 0
+	})
+	return res, nil
+}
+
+// Compile compiles the files with the compilers selected by Plan
+func (m *Manager) Compile(files ...string) (map[string]*Artifact, error) {
+	jobs, err := m.Plan(files...)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]*Artifact{}
+	for _, job := range jobs {
+		c, err := m.Compiler(job.Compiler, job.Version)
+		if err != nil {
+			return nil, err
+		}
+		artifacts, err := c.Compile(job.Files...)
+		if err != nil {
+			return nil, err
+		}
+		for name, artifact := range artifacts {
+			res[name] = artifact
+		}
+	}
+	return res, nil
+}
+
+func compilerName(file string) string {
+	switch filepath.Ext(file) {
+	case ".sol":
+		return "solidity"
+	case ".vy":
+		return "vyper"
+	}
+	return ""
+}
+
+var importRegexp = regexp.MustCompile(`import\s+(?:[^"';]*from\s+)?["']([^"']+)["']`)
+
+// fileConstraints returns the version ranges of the file and of the imported files
+// found relative to it
+func fileConstraints(name string, file string, visited map[string]bool) ([]*Constraint, error) {
+	if visited[file] {
+		return nil, nil
+	}
+	visited[file] = true
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	source := string(data)
+	if name == "vyper" {
+		return VyperPragma(source)
+	}
+	constraints, err := SolidityPragma(source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	for _, match := range importRegexp.FindAllStringSubmatch(commentRegexp.ReplaceAllString(source, ""), -1) {
+		path := match[1]
+		if strings.HasPrefix(path, ".") {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			// remapped or library imports are resolved by the compiler
+			continue
+		}
+		imported, err := fileConstraints(name, path, visited)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, imported...)
+	}
+	return constraints, nil
+}
@@ -0,0 +1,122 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T, versions map[string][]string) *Manager {
+	dir, err := ioutil.TempDir("", "web3-compilers-")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	m := NewManager(dir)
+	sources := map[string]map[string]string{}
+	for name, list := range versions {
+		sources[name] = map[string]string{}
+		builds := ""
+		for i, v := range list {
+			binary := []byte("#!/bin/sh\necho " + name + " " + v + "\n")
+			sum := sha256.Sum256(binary)
+			if i != 0 {
+				builds += ","
+			}
+			builds += fmt.Sprintf(`{"version": "%s", "sha256": "0x%s"}`, v, hex.EncodeToString(sum[:]))
+
+			path := filepath.Join(dir, "src-"+name+"-"+v)
+			require.NoError(t, ioutil.WriteFile(path, binary, 0755))
+			sources[name][v] = path
+		}
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name, "list.json"), []byte(`{"builds": [`+builds+`]}`), 0644))
+	}
+	for name, paths := range sources {
+		for v, path := range paths {
+			require.NoError(t, m.Install(name, v, path))
+		}
+	}
+	return m
+}
+
+func TestManager_Resolve(t *testing.T) {
+	m := newTestManager(t, map[string][]string{
+		"solidity": {"0.5.5", "0.7.6", "0.8.4", "0.8.10"},
+		"vyper":    {"0.3.7"},
+	})
+
+	versions, err := m.Versions("solidity")
+	require.NoError(t, err)
+	require.Equal(t, "0.8.10", versions[len(versions)-1].String())
+
+	c, err := ParseConstraint(">=0.7.0 <0.8.5")
+	require.NoError(t, err)
+	v, err := m.Resolve("solidity", c)
+	require.NoError(t, err)
+	require.Equal(t, "0.8.4", v.String())
+
+	c, err = ParseConstraint("^0.6.0")
+	require.NoError(t, err)
+	_, err = m.Resolve("solidity", c)
+	require.Error(t, err)
+
+	path, err := m.Path("vyper", mustVersion(t, "0.3.7"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(m.Dir, "vyper", "0.3.7", "vyper"), path)
+
+	// the binaries are verified
+	require.NoError(t, ioutil.WriteFile(filepath.Join(m.Dir, "solidity", "0.5.5", "solidity"), []byte("tampered"), 0755))
+	_, err = m.Path("solidity", mustVersion(t, "0.5.5"))
+	require.Error(t, err)
+
+	other := filepath.Join(m.Dir, "other")
+	require.NoError(t, ioutil.WriteFile(other, []byte("unknown"), 0755))
+	require.Error(t, m.Install("solidity", "0.6.12", other))
+}
+
+func TestManager_Plan(t *testing.T) {
+	m := newTestManager(t, map[string][]string{
+		"solidity": {"0.7.6", "0.8.4", "0.8.10"},
+		"vyper":    {"0.3.7"},
+	})
+
+	dir := filepath.Join(m.Dir, "project")
+	files := map[string]string{
+		"Token.sol":    "pragma solidity ^0.8.0;\nimport \"./lib/Math.sol\";\ncontract Token {}",
+		"lib/Math.sol": "pragma solidity >=0.7.0 <0.8.5;\nlibrary Math {}",
+		"Legacy.sol":   "pragma solidity ^0.7.0;\nimport {A} from \"@oz/A.sol\";\ncontract Legacy {}",
+		"Vault.vy":     "# @version ^0.3.0\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+		if name != "lib/Math.sol" {
+			paths = append(paths, path)
+		}
+	}
+
+	jobs, err := m.Plan(paths...)
+	require.NoError(t, err)
+	require.Len(t, jobs, 3)
+	require.Equal(t, "solidity", jobs[0].Compiler)
+	require.Equal(t, "0.7.6", jobs[0].Version.String())
+	require.Equal(t, []string{filepath.Join(dir, "Legacy.sol")}, jobs[0].Files)
+	require.Equal(t, "0.8.4", jobs[1].Version.String())
+	require.Equal(t, []string{filepath.Join(dir, "Token.sol")}, jobs[1].Files)
+	require.Equal(t, "vyper", jobs[2].Compiler)
+	require.Equal(t, "0.3.7", jobs[2].Version.String())
+
+	m.Settings = &Solidity{Optimizer: &Optimizer{Enabled: true, Runs: 200}}
+	c, err := m.Compiler("solidity", jobs[1].Version)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(m.Dir, "solidity", "0.8.4", "solidity"), c.(*Solidity).Path)
+	require.Equal(t, 200, c.(*Solidity).Optimizer.Runs)
+}
@@ -1,40 +1,36 @@
 package compiler
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"reflect"
-	"strings"
-
-	"github.com/laizy/web3/utils"
 )
 
-type solcOutput struct {
-	Contracts map[string]*solcContract
-	Version   string
-}
-
-type solcContract struct {
-	BinRuntime string `json:"bin-runtime"`
-	Bin        string
-	Abi        interface{}
-}
-
-// Solidity is the solidity compiler
+// Solidity is the solidity compiler, the contracts are compiled with the standard-json
+// interface and the settings of the compiler
 type Solidity struct {
 	Path string
+
+	// Remappings are the import remappings, ie. '@openzeppelin/=node_modules/@openzeppelin/'
+	Remappings []string
+	// Optimizer are the optimizer settings, disabled if nil
+	Optimizer *Optimizer
+	// EVMVersion is the target evm version, the compiler default if empty
+	EVMVersion string
+	// Libraries are the library addresses by source file and library name
+	Libraries map[string]map[string]string
+	// BasePath is the root of the imported files (solc 0.6.9 or later)
+	BasePath string
+	// AllowPaths are the directories besides the ones of the sources that solc reads
+	AllowPaths []string
 }
 
 // NewSolidityCompiler instantiates a new solidity compiler
 func NewSolidityCompiler(path string) Compiler {
-	return &Solidity{path}
+	return &Solidity{Path: path}
 }
 
 // CompileCode compiles a solidity code
@@ -42,11 +38,7 @@ func (s *Solidity) CompileCode(code string) (map[string]*Artifact, error) {
 	if code == "" {
 		return nil, fmt.Errorf("code is empty")
 	}
-	artifacts, err := s.compileImpl(code)
-	if err != nil {
-		return nil, err
-	}
-	return artifacts, nil
+	return s.compileImpl(s.StandardInput(map[string]string{"<stdin>": code}))
 }
 
 // Compile implements the compiler interface
@@ -54,48 +46,19 @@ func (s *Solidity) Compile(files ...string) (map[string]*Artifact, error) {
 	if len(files) == 0 {
 		return nil, fmt.Errorf("no input files")
 	}
-	return s.compileImpl("", files...)
-}
-
-func (s *Solidity) compileImpl(code string, files ...string) (map[string]*Artifact, error) {
-	args := []string{
-		"--combined-json",
-		"bin,bin-runtime,abi",
-	}
-	if code != "" {
-		args = append(args, "-")
-	}
-	if len(files) != 0 {
-		args = append(args, files...)
-	}
-
-	var stdout, stderr bytes.Buffer
-	cmd := exec.Command(s.Path, args...)
-	if code != "" {
-		cmd.Stdin = strings.NewReader(code)
-	}
-
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to compile: %s", string(stderr.Bytes()))
-	}
-
-	var output *solcOutput
-	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+	input, err := s.StandardInputFromFiles(files...)
+	if err != nil {
 		return nil, err
 	}
+	return s.compileImpl(input)
+}
 
-	artifacts := map[string]*Artifact{}
-	for name, i := range output.Contracts {
-		_abi := fmt.Sprint(i.Abi)
-		if reflect.TypeOf(i.Abi).Kind() != reflect.String { //some compiler version set abi to struct, while others sets abi to string
-			_abi = utils.JsonStr(i.Abi)
-		}
-		artifacts[name] = NewArtifact(_abi, i.Bin, i.BinRuntime)
+func (s *Solidity) compileImpl(input *StandardInput) (map[string]*Artifact, error) {
+	output, err := s.CompileStandard(input)
+	if err != nil {
+		return nil, err
 	}
-	return artifacts, nil
+	return output.Artifacts()
 }
 
 // DownloadSolidity downloads the solidity compiler
@@ -0,0 +1,349 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Optimizer are the optimizer settings of solc
+type Optimizer struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs,omitempty"`
+}
+
+// StandardInput is the solc standard-json input
+type StandardInput struct {
+	Language string                     `json:"language"`
+	Sources  map[string]*StandardSource `json:"sources"`
+	Settings StandardSettings           `json:"settings"`
+}
+
+// StandardSource is a source file of the standard-json input
+type StandardSource struct {
+	Content string   `json:"content,omitempty"`
+	Urls    []string `json:"urls,omitempty"`
+}
+
+// StandardSettings are the settings of the standard-json input
+type StandardSettings struct {
+	Remappings []string   `json:"remappings,omitempty"`
+	Optimizer  *Optimizer `json:"optimizer,omitempty"`
+	EVMVersion string     `json:"evmVersion,omitempty"`
+	// Libraries are the library addresses by source file and library name
+	Libraries       map[string]map[string]string   `json:"libraries,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+// defaultOutputSelection is the output used to fill the artifacts, the selections
+// not supported by old compilers are ignored
+var defaultOutputSelection = map[string]map[string][]string{
+	"*": {
+		"*": {
+			"abi",
+			"metadata",
+			"storageLayout",
+			"evm.bytecode.object",
+			"evm.bytecode.sourceMap",
+			"evm.bytecode.linkReferences",
+			"evm.deployedBytecode.object",
+			"evm.deployedBytecode.sourceMap",
+			"evm.deployedBytecode.linkReferences",
+			"evm.deployedBytecode.immutableReferences",
+			"evm.methodIdentifiers",
+		},
+	},
+}
+
+// StandardInput returns the standard-json input of the sources, keyed by source name,
+// with the settings of the compiler
+func (s *Solidity) StandardInput(sources map[string]string) *StandardInput {
+	input := &StandardInput{
+		Language: "Solidity",
+		Sources:  map[string]*StandardSource{},
+		Settings: StandardSettings{
+			Remappings:      s.Remappings,
+			Optimizer:       s.Optimizer,
+			EVMVersion:      s.EVMVersion,
+			Libraries:       s.Libraries,
+			OutputSelection: defaultOutputSelection,
+		},
+	}
+	for name, content := range sources {
+		input.Sources[name] = &StandardSource{Content: content}
+	}
+	return input
+}
+
+// StandardInputFromFiles returns the standard-json input of the files, the imported
+// files are read by solc
+func (s *Solidity) StandardInputFromFiles(files ...string) (*StandardInput, error) {
+	sources := map[string]string{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources[filepath.ToSlash(filepath.Clean(file))] = string(content)
+	}
+	return s.StandardInput(sources), nil
+}
+
+// CompileStandard compiles the standard-json input, the compilation errors are returned
+// as CompileErrors and the warnings are kept in the output
+func (s *Solidity) CompileStandard(input *StandardInput) (*StandardOutput, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"--standard-json"}
+	if s.BasePath != "" {
+		args = append(args, "--base-path", s.BasePath)
+	}
+	if allowPaths := s.allowPaths(input); len(allowPaths) != 0 {
+		args = append(args, "--allow-paths", strings.Join(allowPaths, ","))
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.Path, args...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to compile: %s", string(stderr.Bytes()))
+	}
+
+	output, err := ParseStandardOutput(stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range output.Errors {
+		if src, ok := input.Sources[e.File]; ok && src.Content != "" && e.SourceLocation != nil {
+			e.Line, e.Column = position(src.Content, e.SourceLocation.Start)
+		}
+	}
+	if err := output.Err(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// allowPaths returns the directories of the sources and the configured allowed paths,
+// solc does not read the imported files outside of them
+func (s *Solidity) allowPaths(input *StandardInput) []string {
+	paths := map[string]bool{}
+	for _, path := range s.AllowPaths {
+		paths[path] = true
+	}
+	for name := range input.Sources {
+		if !strings.HasPrefix(name, "<") {
+			if dir, err := filepath.Abs(filepath.Dir(name)); err == nil {
+				paths[dir] = true
+			}
+		}
+	}
+	res := make([]string, 0, len(paths))
+	for path := range paths {
+		res = append(res, path)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// StandardOutput is the solc standard-json output
+type StandardOutput struct {
+	Errors  []*CompileError `json:"errors"`
+	Sources map[string]struct {
+		ID int `json:"id"`
+	} `json:"sources"`
+	Contracts map[string]map[string]*standardContract `json:"contracts"`
+}
+
+type standardContract struct {
+	Abi           json.RawMessage `json:"abi"`
+	Metadata      string          `json:"metadata"`
+	StorageLayout *StorageLayout  `json:"storageLayout"`
+	Evm           struct {
+		Bytecode          standardBytecode  `json:"bytecode"`
+		DeployedBytecode  standardBytecode  `json:"deployedBytecode"`
+		MethodIdentifiers map[string]string `json:"methodIdentifiers"`
+	} `json:"evm"`
+}
+
+type standardBytecode struct {
+	Object              string                     `json:"object"`
+	SourceMap           string                     `json:"sourceMap"`
+	LinkReferences      LinkReferences             `json:"linkReferences"`
+	ImmutableReferences map[string][]LinkReference `json:"immutableReferences"`
+}
+
+// ParseStandardOutput parses a solc standard-json output, the line and column of the
+// errors are taken from the formatted messages
+func ParseStandardOutput(data []byte) (*StandardOutput, error) {
+	var output *StandardOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+	if output == nil {
+		return nil, fmt.Errorf("empty output")
+	}
+	for _, e := range output.Errors {
+		if e.SourceLocation != nil {
+			e.File = e.SourceLocation.File
+		}
+		if match := locationRegexp.FindStringSubmatch(e.FormattedMessage); match != nil {
+			if e.File == "" {
+				e.File = match[1]
+			}
+			e.Line, _ = strconv.Atoi(match[2])
+			e.Column, _ = strconv.Atoi(match[3])
+		}
+	}
+	return output, nil
+}
+
+// Err returns the errors of the compilation, the warnings are ignored
+func (o *StandardOutput) Err() error {
+	var errs CompileErrors
+	for _, e := range o.Errors {
+		if e.IsError() {
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Warnings returns the warnings and the informational messages of the compilation
+func (o *StandardOutput) Warnings() []*CompileError {
+	var res []*CompileError
+	for _, e := range o.Errors {
+		if !e.IsError() {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// SourceList returns the source names ordered by id, as referenced by the source maps
+func (o *StandardOutput) SourceList() []string {
+	max := -1
+	for _, src := range o.Sources {
+		if src.ID > max {
+			max = src.ID
+		}
+	}
+	res := make([]string, max+1)
+	for name, src := range o.Sources {
+		res[src.ID] = name
+	}
+	return res
+}
+
+// Artifacts returns the compiled contracts keyed by 'source:name'
+func (o *StandardOutput) Artifacts() (map[string]*Artifact, error) {
+	sources := o.SourceList()
+	artifacts := map[string]*Artifact{}
+	for source, contracts := range o.Contracts {
+		for name, c := range contracts {
+			var abi string
+			if err := json.Unmarshal(c.Abi, &abi); err != nil {
+				// the abi is a json list, but some tools store it as a json string
+				var buf bytes.Buffer
+				if err := json.Compact(&buf, c.Abi); err != nil {
+					return nil, fmt.Errorf("%s:%s: invalid abi: %v", source, name, err)
+				}
+				abi = buf.String()
+			}
+			artifact := NewArtifact(abi, c.Evm.Bytecode.Object, c.Evm.DeployedBytecode.Object)
+			artifact.SourceName = source
+			artifact.LinkReferences = c.Evm.Bytecode.LinkReferences
+			artifact.DeployedLinkReferences = c.Evm.DeployedBytecode.LinkReferences
+			artifact.SourceMap = c.Evm.Bytecode.SourceMap
+			artifact.DeployedSourceMap = c.Evm.DeployedBytecode.SourceMap
+			artifact.ImmutableReferences = c.Evm.DeployedBytecode.ImmutableReferences
+			artifact.Sources = sources
+			artifact.MethodIdentifiers = c.Evm.MethodIdentifiers
+			artifact.Metadata = c.Metadata
+			artifact.StorageLayout = c.StorageLayout
+			artifacts[source+":"+name] = artifact
+		}
+	}
+	return artifacts, nil
+}
+
+// SourceLocation is a range of a source file in bytes
+type SourceLocation struct {
+	File  string `json:"file"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// CompileError is an error or a warning of the compiler, the line and column start at 1
+// and are zero if unknown
+type CompileError struct {
+	Severity         string          `json:"severity"`
+	Type             string          `json:"type"`
+	Component        string          `json:"component"`
+	Message          string          `json:"message"`
+	FormattedMessage string          `json:"formattedMessage"`
+	SourceLocation   *SourceLocation `json:"sourceLocation"`
+
+	File   string `json:"-"`
+	Line   int    `json:"-"`
+	Column int    `json:"-"`
+}
+
+// IsError returns whether the message fails the compilation
+func (e *CompileError) IsError() bool {
+	return e.Severity == "error"
+}
+
+func (e *CompileError) Error() string {
+	msg := e.Type + ": " + e.Message
+	switch {
+	case e.Line != 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, msg)
+	case e.File != "":
+		return e.File + ": " + msg
+	}
+	return msg
+}
+
+// CompileErrors are the errors of a failed compilation
+type CompileErrors []*CompileError
+
+func (e CompileErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "failed to compile: " + strings.Join(msgs, "\n")
+}
+
+// locationRegexp matches the location of the formatted messages, ie. '--> a.sol:3:5:'
+var locationRegexp = regexp.MustCompile(`--> ([^\n]+?):(\d+):(\d+):`)
+
+// position returns the line and column of the offset in bytes
+func position(content string, offset int) (int, int) {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	line := strings.Count(content[:offset], "\n") + 1
+	column := offset - strings.LastIndex(content[:offset], "\n")
+	return line, column
+}
@@ -0,0 +1,105 @@
+package compiler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardInput(t *testing.T) {
+	solc := &Solidity{
+		Path:       "solc",
+		Remappings: []string{"@lib/=lib/"},
+		Optimizer:  &Optimizer{Enabled: true, Runs: 200},
+		EVMVersion: "istanbul",
+	}
+	input := solc.StandardInput(map[string]string{"a.sol": "contract A {}"})
+
+	data, err := json.Marshal(input)
+	require.NoError(t, err)
+
+	var value map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &value))
+	settings := value["settings"].(map[string]interface{})
+	require.Equal(t, "Solidity", value["language"])
+	require.Equal(t, "contract A {}", value["sources"].(map[string]interface{})["a.sol"].(map[string]interface{})["content"])
+	require.Equal(t, []interface{}{"@lib/=lib/"}, settings["remappings"])
+	require.Equal(t, map[string]interface{}{"enabled": true, "runs": float64(200)}, settings["optimizer"])
+	require.Equal(t, "istanbul", settings["evmVersion"])
+	require.NotNil(t, settings["outputSelection"])
+	require.Nil(t, settings["libraries"])
+}
+
+const standardOutput = `{
+  "errors": [
+    {
+      "component": "general",
+      "formattedMessage": "Warning: Unused local variable.\n --> a.sol:4:9:\n",
+      "message": "Unused local variable.",
+      "severity": "warning",
+      "sourceLocation": {"file": "a.sol", "start": 61, "end": 67},
+      "type": "Warning"
+    }
+  ],
+  "sources": {"a.sol": {"id": 1}, "lib.sol": {"id": 0}},
+  "contracts": {
+    "a.sol": {
+      "A": {
+        "abi": [{"type": "function", "name": "f", "inputs": [], "outputs": [], "stateMutability": "pure"}],
+        "metadata": "{\"compiler\":{\"version\":\"0.8.10\"}}",
+        "storageLayout": {
+          "storage": [{"astId": 3, "contract": "a.sol:A", "label": "x", "offset": 0, "slot": "0", "type": "t_uint256"}],
+          "types": {"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"}}
+        },
+        "evm": {
+          "bytecode": {"object": "6080", "sourceMap": "0:10:1:-:0", "linkReferences": {}},
+          "deployedBytecode": {"object": "6081", "sourceMap": "1:9:1:-:0", "linkReferences": {}},
+          "methodIdentifiers": {"f()": "26121ff0"}
+        }
+      }
+    }
+  }
+}`
+
+func TestParseStandardOutput(t *testing.T) {
+	output, err := ParseStandardOutput([]byte(standardOutput))
+	require.NoError(t, err)
+	require.NoError(t, output.Err())
+	require.Equal(t, []string{"lib.sol", "a.sol"}, output.SourceList())
+
+	warnings := output.Warnings()
+	require.Len(t, warnings, 1)
+	require.Equal(t, "a.sol:4:9: Warning: Unused local variable.", warnings[0].Error())
+
+	artifacts, err := output.Artifacts()
+	require.NoError(t, err)
+	a := artifacts["a.sol:A"]
+	require.NotNil(t, a)
+	require.Equal(t, `[{"type":"function","name":"f","inputs":[],"outputs":[],"stateMutability":"pure"}]`, a.Abi)
+	require.Equal(t, "0x6080", a.Bin)
+	require.Equal(t, "0x6081", a.BinRuntime)
+	require.Equal(t, "a.sol", a.SourceName)
+	require.Equal(t, "0:10:1:-:0", a.SourceMap)
+	require.Equal(t, "1:9:1:-:0", a.DeployedSourceMap)
+	require.Equal(t, []string{"lib.sol", "a.sol"}, a.Sources)
+	require.Equal(t, map[string]string{"f()": "26121ff0"}, a.MethodIdentifiers)
+	require.Contains(t, a.Metadata, "0.8.10")
+	require.Equal(t, "x", a.StorageLayout.Storage[0].Label)
+	require.Equal(t, "uint256", a.StorageLayout.Types["t_uint256"].Label)
+}
+
+func TestStandardOutput_Err(t *testing.T) {
+	output, err := ParseStandardOutput([]byte(`{"errors": [
+		{"severity": "error", "type": "ParserError", "message": "Expected ';'", "sourceLocation": {"file": "b.sol", "start": 3, "end": 4}},
+		{"severity": "warning", "type": "Warning", "message": "no license"}
+	]}`))
+	require.NoError(t, err)
+
+	// the position is resolved with the source of the input
+	output.Errors[0].Line, output.Errors[0].Column = position("ab\ncd", output.Errors[0].SourceLocation.Start)
+	err = output.Err()
+	require.Error(t, err)
+	require.Len(t, err.(CompileErrors), 1)
+	require.Equal(t, "failed to compile: b.sol:2:1: ParserError: Expected ';'", err.Error())
+}
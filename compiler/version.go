@@ -0,0 +1,259 @@
+package compiler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a compiler version, the prerelease and build metadata are ignored
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses a version like '0.8.10', 'v0.8.10' or '0.8.10+commit.fc410830'
+func ParseVersion(str string) (*Version, error) {
+	v, parts, err := parsePartial(str)
+	if err != nil {
+		return nil, err
+	}
+	if parts != 3 {
+		return nil, fmt.Errorf("invalid version '%s'", str)
+	}
+	return v, nil
+}
+
+// parsePartial parses a version with missing or wildcard components, and returns the
+// number of components set
+func parsePartial(str string) (*Version, int, error) {
+	str = strings.TrimPrefix(strings.TrimSpace(str), "v")
+	if i := strings.IndexAny(str, "+-"); i != -1 {
+		str = str[:i]
+	}
+	v := &Version{}
+	elems := strings.Split(str, ".")
+	if len(elems) > 3 {
+		return nil, 0, fmt.Errorf("invalid version '%s'", str)
+	}
+	fields := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, elem := range elems {
+		if elem == "x" || elem == "X" || elem == "*" {
+			return v, i, nil
+		}
+		num, err := strconv.Atoi(elem)
+		if err != nil || num < 0 {
+			return nil, 0, fmt.Errorf("invalid version '%s'", str)
+		}
+		*fields[i] = num
+	}
+	return v, len(elems), nil
+}
+
+func (v *Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Cmp returns -1, 0 or 1 if v is lower, equal or greater than o
+func (v *Version) Cmp(o *Version) int {
+	a := []int{v.Major, v.Minor, v.Patch}
+	b := []int{o.Major, o.Minor, o.Patch}
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+type comparator struct {
+	op      string
+	version *Version
+}
+
+func (c *comparator) match(v *Version) bool {
+	cmp := v.Cmp(c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return cmp == 0
+}
+
+// Constraint is a version range with the npm semver syntax used by the solidity
+// pragmas, ie. '^0.8.0', '>=0.6.0 <0.9.0' or '0.7.6 || ^0.8.0'
+type Constraint struct {
+	str string
+	// sets of comparators, a version matches if it matches all the comparators of a set
+	sets [][]*comparator
+}
+
+// ParseConstraint parses a version range
+func ParseConstraint(str string) (*Constraint, error) {
+	c := &Constraint{str: strings.TrimSpace(str)}
+	for _, rng := range strings.Split(str, "||") {
+		set, err := parseRange(rng)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range '%s': %v", str, err)
+		}
+		c.sets = append(c.sets, set)
+	}
+	return c, nil
+}
+
+var rangeOpRegexp = regexp.MustCompile(`(\^|~|>=|<=|>|<|=)\s+`)
+
+func parseRange(rng string) ([]*comparator, error) {
+	rng = strings.TrimSpace(rng)
+	if parts := strings.SplitN(rng, " - ", 2); len(parts) == 2 {
+		// hyphen range, 'a - b' is '>=a <=b'
+		from, _, err := parsePartial(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		to, n, err := parsePartial(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if n < 3 {
+			return []*comparator{{">=", from}, {"<", bump(to, n)}}, nil
+		}
+		return []*comparator{{">=", from}, {"<=", to}}, nil
+	}
+
+	// the operators can be separated from the version
+	rng = rangeOpRegexp.ReplaceAllString(rng, "$1")
+	var res []*comparator
+	for _, elem := range strings.Fields(rng) {
+		cmps, err := parseComparator(elem)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, cmps...)
+	}
+	return res, nil
+}
+
+func parseComparator(str string) ([]*comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(str, prefix) {
+			op = prefix
+			break
+		}
+	}
+	v, n, err := parsePartial(str[len(op):])
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		// any version
+		return nil, nil
+	}
+
+	switch op {
+	case "^":
+		// the first non zero component can not change
+		switch {
+		case v.Major != 0 || n == 1:
+			return []*comparator{{">=", v}, {"<", bump(v, 1)}}, nil
+		case v.Minor != 0 || n == 2:
+			return []*comparator{{">=", v}, {"<", bump(v, 2)}}, nil
+		}
+		return []*comparator{{">=", v}, {"<", bump(v, 3)}}, nil
+	case "~":
+		if n == 1 {
+			return []*comparator{{">=", v}, {"<", bump(v, 1)}}, nil
+		}
+		return []*comparator{{">=", v}, {"<", bump(v, 2)}}, nil
+	case "", "=":
+		if n < 3 {
+			return []*comparator{{">=", v}, {"<", bump(v, n)}}, nil
+		}
+		return []*comparator{{"=", v}}, nil
+	case ">":
+		if n < 3 {
+			return []*comparator{{">=", bump(v, n)}}, nil
+		}
+	case "<=":
+		if n < 3 {
+			return []*comparator{{"<", bump(v, n)}}, nil
+		}
+	}
+	return []*comparator{{op, v}}, nil
+}
+
+// bump increments the n-th component of the version and resets the following ones
+func bump(v *Version, n int) *Version {
+	switch n {
+	case 1:
+		return &Version{Major: v.Major + 1}
+	case 2:
+		return &Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+	return &Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+// Match returns whether the version is in the range
+func (c *Constraint) Match(v *Version) bool {
+	for _, set := range c.sets {
+		found := true
+		for _, cmp := range set {
+			if !cmp.match(v) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Constraint) String() string {
+	return c.str
+}
+
+var (
+	commentRegexp        = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	solidityPragmaRegexp = regexp.MustCompile(`pragma\s+solidity\s+([^;]+);`)
+	vyperPragmaRegexp    = regexp.MustCompile(`(?m)^#\s*(?:@version|pragma\s+version)\s+(.+)$`)
+)
+
+// SolidityPragma returns the version ranges of the 'pragma solidity' directives of the
+// source, a version has to match all of them
+func SolidityPragma(source string) ([]*Constraint, error) {
+	source = commentRegexp.ReplaceAllString(source, "")
+	var res []*Constraint
+	for _, match := range solidityPragmaRegexp.FindAllStringSubmatch(source, -1) {
+		c, err := ParseConstraint(match[1])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// VyperPragma returns the version range of the '# @version' directive of the source
+func VyperPragma(source string) ([]*Constraint, error) {
+	var res []*Constraint
+	for _, match := range vyperPragmaRegexp.FindAllStringSubmatch(source, -1) {
+		c, err := ParseConstraint(match[1])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
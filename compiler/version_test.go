@@ -0,0 +1,80 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstraint(t *testing.T) {
+	cases := []struct {
+		rng   string
+		match []string
+		fail  []string
+	}{
+		{"^0.8.0", []string{"0.8.0", "0.8.19"}, []string{"0.7.6", "0.9.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"2.0.0", "1.2.2"}},
+		{"~0.8.1", []string{"0.8.1", "0.8.9"}, []string{"0.8.0", "0.9.0"}},
+		{">=0.6.0 <0.9.0", []string{"0.6.0", "0.8.19"}, []string{"0.5.17", "0.9.0"}},
+		{">= 0.6.0 < 0.8", []string{"0.7.6"}, []string{"0.8.0"}},
+		{"0.8.10", []string{"0.8.10"}, []string{"0.8.11"}},
+		{"=0.8.10", []string{"0.8.10"}, []string{"0.8.9"}},
+		{"0.8", []string{"0.8.0", "0.8.20"}, []string{"0.9.0"}},
+		{"0.8.x", []string{"0.8.3"}, []string{"0.7.0"}},
+		{">0.0.0", []string{"0.0.1", "0.5.5"}, []string{"0.0.0"}},
+		{"0.7.6 || ^0.8.0", []string{"0.7.6", "0.8.4"}, []string{"0.7.5", "0.9.0"}},
+		{"0.6.0 - 0.7", []string{"0.6.0", "0.7.6"}, []string{"0.8.0"}},
+		{"*", []string{"0.4.24"}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.rng, func(t *testing.T) {
+			constraint, err := ParseConstraint(c.rng)
+			require.NoError(t, err)
+			for _, v := range c.match {
+				require.True(t, constraint.Match(mustVersion(t, v)), v)
+			}
+			for _, v := range c.fail {
+				require.False(t, constraint.Match(mustVersion(t, v)), v)
+			}
+		})
+	}
+
+	_, err := ParseConstraint("^0.a")
+	require.Error(t, err)
+}
+
+func mustVersion(t *testing.T, str string) *Version {
+	v, err := ParseVersion(str)
+	require.NoError(t, err)
+	return v
+}
+
+func TestParseVersion(t *testing.T) {
+	v := mustVersion(t, "v0.8.10+commit.fc410830")
+	require.Equal(t, "0.8.10", v.String())
+	require.Equal(t, 1, v.Cmp(mustVersion(t, "0.8.9")))
+
+	_, err := ParseVersion("0.8")
+	require.Error(t, err)
+}
+
+func TestPragma(t *testing.T) {
+	constraints, err := SolidityPragma(`
+// pragma solidity ^0.4.0;
+/* pragma solidity ^0.5.0; */
+pragma solidity >=0.6.0 <0.9.0;
+pragma solidity ^0.8.0;
+pragma abicoder v2;
+contract A {}
+`)
+	require.NoError(t, err)
+	require.Len(t, constraints, 2)
+	require.Equal(t, ">=0.6.0 <0.9.0", constraints[0].String())
+	require.Equal(t, "^0.8.0", constraints[1].String())
+
+	constraints, err = VyperPragma("# @version ^0.3.0\n\nx: uint256\n")
+	require.NoError(t, err)
+	require.Len(t, constraints, 1)
+	require.True(t, constraints[0].Match(mustVersion(t, "0.3.7")))
+}
@@ -0,0 +1,71 @@
+package ens
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/jsonrpc"
+	"github.com/laizy/web3/jsonrpc/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestENS_Simulated(t *testing.T) {
+	owner := web3.HexToAddress("0x1000000000000000000000000000000000000001")
+	other := web3.HexToAddress("0x2000000000000000000000000000000000000002")
+	client := jsonrpc.NewClientWithTransport(transport.NewSimulated(1, map[web3.Address]*big.Int{
+		owner: web3.Ether(100),
+	}))
+
+	accounts, err := client.Eth().Accounts()
+	require.NoError(t, err)
+	require.Equal(t, []web3.Address{owner}, accounts)
+
+	receipt, err := DeployENS(client, owner).DoAndWait()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), receipt.Status)
+	deployTxn, err := client.Eth().GetTransactionByHash(receipt.TransactionHash)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), deployTxn.Nonce)
+	require.Equal(t, uint64(1), deployTxn.BlockNumber)
+
+	ens := NewENS(receipt.ContractAddress, client)
+	ens.Contract().SetFrom(owner)
+
+	root := [32]byte{}
+	addr, err := ens.Owner(root)
+	require.NoError(t, err)
+	require.Equal(t, owner, addr)
+
+	receipt, err = ens.SetOwner(root, other).DoAndWait()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), receipt.BlockNumber)
+
+	addr, err = ens.Owner(root)
+	require.NoError(t, err)
+	require.Equal(t, other, addr)
+
+	num, err := client.Eth().BlockNumber()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), num)
+
+	block, err := client.Eth().GetBlockByNumber(web3.Latest, false)
+	require.NoError(t, err)
+	require.Equal(t, receipt.BlockHash, block.Hash)
+	require.Equal(t, []web3.Hash{receipt.TransactionHash}, block.TransactionsHashes)
+
+	block, err = client.Eth().GetBlockByHash(receipt.BlockHash, true)
+	require.NoError(t, err)
+	require.Len(t, block.Transactions, 1)
+	require.Equal(t, receipt.TransactionHash, block.Transactions[0].Hash())
+
+	events, err := ens.FilterTransferEvent(nil, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, other, events[0].Owner)
+	require.Equal(t, root, events[0].Node)
+
+	// the caller is not the owner anymore
+	_, err = ens.SetOwner(root, owner).DoAndWait()
+	require.Error(t, err)
+}
@@ -18,6 +18,7 @@ type Contract struct {
 	from     *web3.Address
 	Abi      *abi.ABI
 	Provider *jsonrpc.Client
+	newError func(name string) error
 }
 
 func (c *Contract) FilterLogsWithTopic(topics [][]web3.Hash, startBlock uint64, endBlock ...uint64) ([]*web3.Log, error) {
@@ -36,7 +37,11 @@ func (c *Contract) FilterLogsWithTopic(topics [][]web3.Hash, startBlock uint64,
 
 func (c *Contract) FilterLogs(opts *web3.FilterOpts, name string, query ...[]interface{}) ([]*web3.Log, error) {
 	// Append the event selector to the query parameters and construct the topic set
-	query = append([][]interface{}{{c.Abi.Events[name].ID()}}, query...)
+	event := c.Abi.GetEvent(name)
+	if event == nil {
+		return nil, fmt.Errorf("event %s not found", name)
+	}
+	query = append([][]interface{}{{event.ID()}}, query...)
 	topics, err := MakeTopics(query...)
 	if err != nil {
 		return nil, err
@@ -94,14 +99,41 @@ func (c *Contract) EstimateGas(method string, args ...interface{}) (uint64, erro
 	return c.Txn(method, args).EstimateGas()
 }
 
-// Call calls a method in the contract
+// Call calls a method in the contract, the overloaded methods are selected by signature
 func (c *Contract) Call(method string, block web3.BlockNumber, args ...interface{}) (map[string]interface{}, error) {
-	m, ok := c.Abi.Methods[method]
-	if !ok {
-		return nil, fmt.Errorf("method %s not found", method)
+	m, raw, err := c.call(method, block, args...)
+	if err != nil {
+		return nil, err
+	}
+	respInterface, err := abi.Decode(m.Outputs, raw)
+	if err != nil {
+		return nil, err
 	}
 
-	data := m.MustEncodeIDAndInput(args...)
+	resp := respInterface.(map[string]interface{})
+	return resp, nil
+}
+
+// CallInto calls a method in the contract and decodes the outputs into the out param,
+// see abi.Method.DecodeOutputInto
+func (c *Contract) CallInto(method string, block web3.BlockNumber, out interface{}, args ...interface{}) error {
+	m, raw, err := c.call(method, block, args...)
+	if err != nil {
+		return err
+	}
+	return m.DecodeOutputInto(raw, out)
+}
+
+func (c *Contract) call(method string, block web3.BlockNumber, args ...interface{}) (*abi.Method, []byte, error) {
+	m := c.Abi.GetMethod(method)
+	if m == nil {
+		return nil, nil, fmt.Errorf("method %s not found", method)
+	}
+
+	data, err := m.EncodeIDAndInput(args...)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Call function
 	msg := &web3.CallMsg{
@@ -114,39 +146,34 @@ func (c *Contract) Call(method string, block web3.BlockNumber, args ...interface
 
 	rawStr, err := c.Provider.Eth().Call(msg, block)
 	if err != nil {
-		return nil, err
+		return nil, nil, c.decodeError(err)
 	}
 
 	// Decode output
 	raw, err := hex.DecodeString(rawStr[2:])
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if len(raw) == 0 {
-		return nil, fmt.Errorf("empty response")
-	}
-	respInterface, err := abi.Decode(m.Outputs, raw)
-	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("empty response")
 	}
-
-	resp := respInterface.(map[string]interface{})
-	return resp, nil
+	return m, raw, nil
 }
 
-// Txn creates a new transaction object
+// Txn creates a new transaction object, the overloaded methods are selected by signature
 func (c *Contract) Txn(method string, args ...interface{}) *Txn {
-	m, ok := c.Abi.Methods[method]
-	if !ok {
+	m := c.Abi.GetMethod(method)
+	if m == nil {
 		panic(fmt.Errorf("method %s not found", method))
 	}
 	data := m.MustEncodeIDAndInput(args...)
 
 	return &Txn{
-		from:     *c.from,
-		to:       &c.addr,
-		provider: c.Provider,
-		Data:     data,
+		from:      *c.from,
+		to:        &c.addr,
+		provider:  c.Provider,
+		Data:      data,
+		decodeErr: c.decodeError,
 	}
 }
 
@@ -162,6 +189,9 @@ type Txn struct {
 	GasPrice uint64
 	Data     []byte
 	hash     web3.Hash
+
+	// decodeErr converts the revert errors into the typed custom errors
+	decodeErr func(err error) error
 }
 
 // SetValue sets the value for the txn
@@ -184,7 +214,11 @@ func (t *Txn) EstimateGas() (uint64, error) {
 		Value:    t.value,
 		GasPrice: t.GasPrice,
 	}
-	return t.provider.Eth().EstimateGas(msg)
+	gas, err := t.provider.Eth().EstimateGas(msg)
+	if err != nil && t.decodeErr != nil {
+		err = t.decodeErr(err)
+	}
+	return gas, err
 }
 
 // both Do and Wait functions
@@ -275,6 +309,9 @@ func (t *Txn) Do() error {
 	}
 	t.hash, err = t.provider.Eth().SendTransaction(txn)
 	if err != nil {
+		if t.decodeErr != nil {
+			err = t.decodeErr(err)
+		}
 		return err
 	}
 	return nil
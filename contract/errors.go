@@ -0,0 +1,57 @@
+package contract
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/jsonrpc/codec"
+	"github.com/laizy/web3/utils/common/hexutil"
+)
+
+// RevertData returns the data returned by a reverted call from the jsonrpc error
+func RevertData(err error) ([]byte, bool) {
+	var obj *codec.ErrorObject
+	if !errors.As(err, &obj) || obj.Data == "" {
+		return nil, false
+	}
+	data, decodeErr := hexutil.Decode(obj.Data)
+	if decodeErr != nil || len(data) < 4 {
+		return nil, false
+	}
+	return data, true
+}
+
+// SetErrorFactory sets the function that returns a pointer to the Go type of a custom
+// error by name. The reverts of calls and transactions with one of those custom errors
+// are returned as the typed error, so the callers can use errors.As.
+func (c *Contract) SetErrorFactory(newError func(name string) error) {
+	c.newError = newError
+}
+
+// decodeError converts err into the typed custom error if the revert data matches one
+func (c *Contract) decodeError(err error) error {
+	if c.newError == nil {
+		return err
+	}
+	data, ok := RevertData(err)
+	if !ok {
+		return err
+	}
+	for name, e := range c.Abi.Errors {
+		if !bytes.Equal(e.ID(), data[:4]) {
+			continue
+		}
+		typed := c.newError(name)
+		if typed == nil {
+			return err
+		}
+		if len(e.Inputs.TupleElems()) != 0 {
+			if decodeErr := abi.DecodeStruct(e.Inputs, data[4:], typed); decodeErr != nil {
+				return err
+			}
+		}
+		return typed
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/jsonrpc/codec"
+	"github.com/laizy/web3/utils/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type customError struct {
+	Arg0 web3.Address
+	Arg1 web3.Address
+}
+
+func (e *customError) Error() string {
+	return fmt.Sprintf("CustomError(%v, %v)", e.Arg0, e.Arg1)
+}
+
+func TestDecodeError(t *testing.T) {
+	contractAbi, err := abi.NewABIFromList([]string{
+		"error CustomError(address, address)",
+		"error Paused()",
+		"error Unknown(uint256)",
+	})
+	require.NoError(t, err)
+
+	c := NewContract(web3.Address{}, contractAbi, nil)
+	c.SetErrorFactory(func(name string) error {
+		switch name {
+		case "CustomError":
+			return &customError{}
+		}
+		return nil
+	})
+
+	data, err := contractAbi.Errors["CustomError"].EncodeIDAndInput(web3.Address{0x1}, web3.Address{0x2})
+	require.NoError(t, err)
+	reverted := fmt.Errorf("call failed: %w", &codec.ErrorObject{Code: 3, Message: "execution reverted", Data: hexutil.Encode(data)})
+
+	var typed *customError
+	require.True(t, errors.As(c.decodeError(reverted), &typed))
+	assert.Equal(t, web3.Address{0x1}, typed.Arg0)
+	assert.Equal(t, web3.Address{0x2}, typed.Arg1)
+
+	// errors without a go type and plain errors are returned as they are
+	data, err = contractAbi.Errors["Unknown"].EncodeIDAndInput(1)
+	require.NoError(t, err)
+	unknown := &codec.ErrorObject{Code: 3, Data: hexutil.Encode(data)}
+	assert.Equal(t, error(unknown), c.decodeError(unknown))
+	assert.Equal(t, assert.AnError, c.decodeError(assert.AnError))
+
+	_, ok := RevertData(&codec.ErrorObject{Code: 3, Data: "0x01"})
+	assert.False(t, ok)
+}
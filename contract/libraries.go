@@ -0,0 +1,107 @@
+package contract
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/compiler"
+	"github.com/laizy/web3/jsonrpc"
+	"github.com/laizy/web3/utils/common/hexutil"
+)
+
+// DeployLibraries deploys the libraries used by the artifact, and the ones used by those
+// libraries, in dependency order. The library artifacts are keyed by fully qualified name
+// ('source:name') or by name, the libraries in deployed are reused and the new ones are
+// added to it by fully qualified name.
+func DeployLibraries(provider *jsonrpc.Client, from web3.Address, artifact *compiler.Artifact, libraries map[string]*compiler.Artifact, deployed map[string]web3.Address) error {
+	visiting := map[string]bool{}
+	var deploy func(fqName string) error
+	deploy = func(fqName string) error {
+		if _, ok := lookupDeployed(deployed, fqName); ok {
+			return nil
+		}
+		if visiting[fqName] {
+			return fmt.Errorf("circular dependency of library %s", fqName)
+		}
+		visiting[fqName] = true
+
+		lib, ok := libraries[fqName]
+		if !ok {
+			lib, ok = libraries[libraryName(fqName)]
+		}
+		if !ok {
+			return fmt.Errorf("artifact of library %s not found", fqName)
+		}
+		for _, dep := range lib.Libraries() {
+			if err := deploy(dep); err != nil {
+				return err
+			}
+		}
+		addr, err := deployLinked(provider, from, lib, deployed)
+		if err != nil {
+			return fmt.Errorf("failed to deploy library %s: %v", fqName, err)
+		}
+		deployed[fqName] = addr
+		return nil
+	}
+
+	for _, fqName := range artifact.Libraries() {
+		if err := deploy(fqName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeployContractWithLibraries deploys the libraries of the artifact with DeployLibraries and
+// returns the transaction that deploys the linked contract
+func DeployContractWithLibraries(provider *jsonrpc.Client, from web3.Address, abiVal *abi.ABI, artifact *compiler.Artifact, libraries map[string]*compiler.Artifact, deployed map[string]web3.Address, args ...interface{}) (*Txn, error) {
+	if deployed == nil {
+		deployed = map[string]web3.Address{}
+	}
+	if err := DeployLibraries(provider, from, artifact, libraries, deployed); err != nil {
+		return nil, err
+	}
+	linked, err := artifact.Link(deployed)
+	if err != nil {
+		return nil, err
+	}
+	bin, err := hexutil.Decode(linked.Bin)
+	if err != nil {
+		return nil, err
+	}
+	return DeployContract(provider, from, abiVal, bin, args...), nil
+}
+
+func deployLinked(provider *jsonrpc.Client, from web3.Address, lib *compiler.Artifact, deployed map[string]web3.Address) (web3.Address, error) {
+	linked, err := lib.Link(deployed)
+	if err != nil {
+		return web3.Address{}, err
+	}
+	bin, err := hexutil.Decode(linked.Bin)
+	if err != nil {
+		return web3.Address{}, err
+	}
+	receipt, err := DeployContract(provider, from, &abi.ABI{}, bin).DoAndWait()
+	if err != nil {
+		return web3.Address{}, err
+	}
+	if receipt.Status != 1 {
+		return web3.Address{}, fmt.Errorf("transaction %s failed", receipt.TransactionHash)
+	}
+	return receipt.ContractAddress, nil
+}
+
+func lookupDeployed(deployed map[string]web3.Address, fqName string) (web3.Address, bool) {
+	if addr, ok := deployed[fqName]; ok {
+		return addr, true
+	}
+	addr, ok := deployed[libraryName(fqName)]
+	return addr, ok
+}
+
+func libraryName(fqName string) string {
+	return fqName[strings.LastIndex(fqName, ":")+1:]
+}
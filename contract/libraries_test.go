@@ -0,0 +1,71 @@
+package contract
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/compiler"
+	"github.com/laizy/web3/jsonrpc"
+	"github.com/laizy/web3/jsonrpc/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func linkRefs(source, name string, start int) compiler.LinkReferences {
+	return compiler.LinkReferences{source: {name: {{Start: start, Length: 20}}}}
+}
+
+func TestDeployContractWithLibraries(t *testing.T) {
+	from := web3.HexToAddress("0x1000000000000000000000000000000000000001")
+	client := jsonrpc.NewClientWithTransport(transport.NewSimulated(1, map[web3.Address]*big.Int{
+		from: web3.Ether(100),
+	}))
+
+	placeholderA := compiler.Placeholder("lib/A.sol:A")
+	placeholderB := compiler.Placeholder("lib/B.sol:B")
+	libraries := map[string]*compiler.Artifact{
+		// returns the code 0x00
+		"lib/A.sol:A": {Bin: "0x60016000f3"},
+		// uses A
+		"B": {Bin: "0x73" + placeholderA + "5060016000f3", LinkReferences: linkRefs("lib/A.sol", "A", 1)},
+	}
+	// stores the address of B in the slot 0 and the one of A in the slot 1
+	artifact := &compiler.Artifact{Bin: "0x73" + placeholderB + "600055" + "73" + placeholderA + "60015500"}
+	artifact.LinkReferences = linkRefs("lib/B.sol", "B", 1)
+	artifact.LinkReferences["lib/A.sol"] = linkRefs("lib/A.sol", "A", 25)["lib/A.sol"]
+	require.Equal(t, []string{"lib/A.sol:A", "lib/B.sol:B"}, artifact.Libraries())
+
+	deployed := map[string]web3.Address{}
+	txn, err := DeployContractWithLibraries(client, from, &abi.ABI{}, artifact, libraries, deployed)
+	require.NoError(t, err)
+	receipt, err := txn.DoAndWait()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), receipt.Status)
+
+	require.Len(t, deployed, 2)
+	for _, addr := range deployed {
+		code, err := client.Eth().GetCode(addr)
+		require.NoError(t, err)
+		require.Equal(t, "0x00", code)
+	}
+	nonce, err := client.Eth().GetNonce(from, web3.Latest)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), nonce)
+
+	for slot, name := range []string{"lib/B.sol:B", "lib/A.sol:A"} {
+		var value string
+		require.NoError(t, client.Call("eth_getStorageAt", &value, receipt.ContractAddress, web3.BytesToHash([]byte{byte(slot)}).String(), "latest"))
+		addr := deployed[name]
+		require.Equal(t, web3.BytesToHash(addr[:]).String(), value)
+	}
+
+	// the deployed libraries are reused
+	txn, err = DeployContractWithLibraries(client, from, &abi.ABI{}, artifact, nil, deployed)
+	require.NoError(t, err)
+	_, err = txn.DoAndWait()
+	require.NoError(t, err)
+
+	_, err = DeployContractWithLibraries(client, from, &abi.ABI{}, artifact, nil, nil)
+	require.EqualError(t, err, "artifact of library lib/A.sol:A not found")
+}
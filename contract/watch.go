@@ -0,0 +1,153 @@
+package contract
+
+import (
+	"context"
+	"sync"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/tracker"
+)
+
+// WatchOpts are the options to watch the logs of a contract
+type WatchOpts struct {
+	// Tracker is used instead of a jsonrpc subscription if set, the
+	// tracker has to be started by the caller
+	Tracker *tracker.Tracker
+
+	// Context stops the tracker filter and the handler, defaults to the background context
+	Context context.Context
+}
+
+// Subscription is a stream of logs that can be cancelled
+type Subscription struct {
+	errCh       chan error
+	unsubscribe func() error
+	once        sync.Once
+}
+
+func newSubscription() *Subscription {
+	return &Subscription{errCh: make(chan error, 1)}
+}
+
+// Err returns the errors found while watching the logs, it does not block the stream if not read
+func (s *Subscription) Err() <-chan error {
+	return s.errCh
+}
+
+// Unsubscribe stops watching the logs
+func (s *Subscription) Unsubscribe() (err error) {
+	s.once.Do(func() {
+		if s.unsubscribe != nil {
+			err = s.unsubscribe()
+		}
+	})
+	return
+}
+
+func (s *Subscription) sendErr(err error) {
+	select {
+	case s.errCh <- err:
+	default:
+	}
+}
+
+// WatchLogsWithTopic calls handler with the logs of the contract that match the topics. The
+// logs removed by a chain reorg are delivered again with the Removed flag set. The context
+// given to handler is done once unsubscribed, a handler that blocks has to return then.
+func (c *Contract) WatchLogsWithTopic(opts *WatchOpts, topics [][]web3.Hash, handler func(ctx context.Context, log *web3.Log) error) (*Subscription, error) {
+	ctx := context.Background()
+	if opts != nil && opts.Context != nil {
+		ctx = opts.Context
+	}
+	ctx, cancel := context.WithCancel(ctx)
+
+	sub := newSubscription()
+	handle := func(log *web3.Log) {
+		if err := handler(ctx, log); err != nil && ctx.Err() == nil {
+			sub.sendErr(err)
+		}
+	}
+
+	if opts == nil || opts.Tracker == nil {
+		unsubscribe, err := c.Provider.SubscribeLogs(handle, []web3.Address{c.addr}, topics)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		sub.unsubscribe = func() error {
+			cancel()
+			return unsubscribe()
+		}
+		return sub, nil
+	}
+
+	// the tracker filters by one value per topic, the rest is done here
+	config := &tracker.FilterConfig{Address: []web3.Address{c.addr}}
+	for _, topic := range topics {
+		if len(topic) == 1 {
+			config.Topics = append(config.Topics, &topic[0])
+		} else {
+			config.Topics = append(config.Topics, nil)
+		}
+	}
+	filter, err := opts.Tracker.NewFilter(config)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evnt := <-filter.EventCh:
+				for _, log := range evnt.Removed {
+					if matchTopics(log, topics) {
+						removed := *log
+						removed.Removed = true
+						handle(&removed)
+					}
+				}
+				for _, log := range evnt.Added {
+					if matchTopics(log, topics) {
+						handle(log)
+					}
+				}
+			}
+		}
+	}()
+	go func() {
+		if err := filter.Sync(ctx); err != nil && ctx.Err() == nil {
+			sub.sendErr(err)
+		}
+	}()
+
+	sub.unsubscribe = func() error {
+		cancel()
+		return nil
+	}
+	return sub, nil
+}
+
+func matchTopics(log *web3.Log, topics [][]web3.Hash) bool {
+	for i, options := range topics {
+		if len(options) == 0 {
+			continue
+		}
+		if i >= len(log.Topics) {
+			return false
+		}
+		found := false
+		for _, topic := range options {
+			if log.Topics[i] == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
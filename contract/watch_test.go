@@ -0,0 +1,98 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pubSub is a transport that only delivers the logs published by the test
+type pubSub struct {
+	callback func(b []byte)
+}
+
+func (p *pubSub) Call(method string, out interface{}, params ...interface{}) error {
+	return errors.New("not supported")
+}
+
+func (p *pubSub) Close() error {
+	return nil
+}
+
+func (p *pubSub) Subscribe(method string, param interface{}, callback func(b []byte)) (func() error, error) {
+	p.callback = callback
+	return func() error { return nil }, nil
+}
+
+func TestMatchTopics(t *testing.T) {
+	log := &web3.Log{Topics: []web3.Hash{{0x1}, {0x2}}}
+
+	assert.True(t, matchTopics(log, nil))
+	assert.True(t, matchTopics(log, [][]web3.Hash{{{0x1}}}))
+	assert.True(t, matchTopics(log, [][]web3.Hash{{{0x1}}, {{0x3}, {0x2}}}))
+	assert.True(t, matchTopics(log, [][]web3.Hash{nil, {{0x2}}}))
+	assert.False(t, matchTopics(log, [][]web3.Hash{{{0x1}}, {{0x3}}}))
+	assert.False(t, matchTopics(log, [][]web3.Hash{{{0x1}}, nil, {{0x3}}}))
+}
+
+func TestSubscription(t *testing.T) {
+	calls := 0
+	sub := newSubscription()
+	sub.unsubscribe = func() error {
+		calls++
+		return nil
+	}
+	sub.sendErr(assert.AnError)
+	sub.sendErr(assert.AnError) // does not block
+
+	assert.Equal(t, assert.AnError, <-sub.Err())
+	assert.NoError(t, sub.Unsubscribe())
+	assert.NoError(t, sub.Unsubscribe())
+	assert.Equal(t, 1, calls)
+}
+
+func TestWatchLogsWithTopic_Unsubscribe(t *testing.T) {
+	trans := &pubSub{}
+	c := NewContract(web3.Address{0x1}, &abi.ABI{}, jsonrpc.NewClientWithTransport(trans))
+
+	// nobody reads the sink
+	sink := make(chan *web3.Log)
+	sub, err := c.WatchLogsWithTopic(nil, nil, func(ctx context.Context, log *web3.Log) error {
+		select {
+		case sink <- log:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	require.NoError(t, err)
+
+	log, err := (&web3.Log{Address: web3.Address{0x1}}).MarshalJSON()
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		trans.callback(log)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("the handler does not wait the sink")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, sub.Unsubscribe())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the handler is blocked after unsubscribe")
+	}
+	// the cancellation is not reported as an error
+	assert.Len(t, sub.Err(), 0)
+}
@@ -0,0 +1,155 @@
+package eip712
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/utils/common/hexutil"
+)
+
+// convertValue converts a json or go value into the go type expected
+// by the abi encoder for an atomic type
+func convertValue(t *abi.Type, val interface{}) (interface{}, error) {
+	switch t.Kind() {
+	case abi.KindString:
+		s, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string but found %T", val)
+		}
+		return s, nil
+
+	case abi.KindBytes:
+		return toBytes(val)
+
+	case abi.KindBool:
+		switch obj := val.(type) {
+		case bool:
+			return obj, nil
+		case string:
+			if obj == "true" || obj == "false" {
+				return obj == "true", nil
+			}
+		}
+		return nil, fmt.Errorf("expected bool but found %v", val)
+
+	case abi.KindAddress:
+		switch obj := val.(type) {
+		case web3.Address:
+			return obj, nil
+		case *web3.Address:
+			return *obj, nil
+		case string:
+			buf, err := hexutil.Decode(obj)
+			if err != nil || len(buf) != 20 {
+				return nil, fmt.Errorf("invalid address '%s'", obj)
+			}
+			return web3.BytesToAddress(buf), nil
+		}
+		return nil, fmt.Errorf("expected address but found %T", val)
+
+	case abi.KindFixedBytes:
+		buf, err := toBytes(val)
+		if err != nil {
+			return nil, err
+		}
+		if len(buf) > t.Size() {
+			return nil, fmt.Errorf("expected at most %d bytes but found %d", t.Size(), len(buf))
+		}
+		array := reflect.New(t.GoType()).Elem()
+		reflect.Copy(array, reflect.ValueOf(buf))
+		return array.Interface(), nil
+
+	case abi.KindInt, abi.KindUInt:
+		num, err := toBigInt(val)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRange(t, num); err != nil {
+			return nil, err
+		}
+		return num, nil
+
+	default:
+		return nil, fmt.Errorf("type '%s' not supported", t.String())
+	}
+}
+
+func checkRange(t *abi.Type, num *big.Int) error {
+	bits := uint(t.Size())
+	if t.Kind() == abi.KindUInt {
+		if num.Sign() < 0 || num.BitLen() > int(bits) {
+			return fmt.Errorf("value %s out of range for %s", num, t.String())
+		}
+		return nil
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), bits-1)
+	if num.Cmp(limit) >= 0 || num.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("value %s out of range for %s", num, t.String())
+	}
+	return nil
+}
+
+func toBytes(val interface{}) ([]byte, error) {
+	switch obj := val.(type) {
+	case []byte:
+		return obj, nil
+	case string:
+		buf, err := hexutil.Decode(obj)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex bytes '%s': %v", obj, err)
+		}
+		return buf, nil
+	}
+	v := reflect.ValueOf(val)
+	if v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8 {
+		buf := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(buf), v)
+		return buf, nil
+	}
+	return nil, fmt.Errorf("expected bytes but found %T", val)
+}
+
+func toBigInt(val interface{}) (*big.Int, error) {
+	switch obj := val.(type) {
+	case *big.Int:
+		return new(big.Int).Set(obj), nil
+	case big.Int:
+		return new(big.Int).Set(&obj), nil
+	case json.Number:
+		return parseBigInt(obj.String())
+	case string:
+		return parseBigInt(obj)
+	case float64:
+		num, acc := big.NewFloat(obj).Int(nil)
+		if acc != big.Exact {
+			return nil, fmt.Errorf("number %v is not an integer", obj)
+		}
+		return num, nil
+	}
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(v.Uint()), nil
+	}
+	return nil, fmt.Errorf("expected number but found %T", val)
+}
+
+func parseBigInt(str string) (*big.Int, error) {
+	str = strings.TrimSpace(str)
+	base := 10
+	if strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X") {
+		str, base = str[2:], 16
+	}
+	num, ok := new(big.Int).SetString(str, base)
+	if !ok {
+		return nil, fmt.Errorf("invalid number '%s'", str)
+	}
+	return num, nil
+}
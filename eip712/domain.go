@@ -0,0 +1,104 @@
+package eip712
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/laizy/web3"
+)
+
+// Domain is the eip712 domain, only the non-empty fields take part in the separator
+type Domain struct {
+	Name              string
+	Version           string
+	ChainId           *big.Int
+	VerifyingContract *web3.Address
+	Salt              *web3.Hash
+}
+
+// Fields returns the EIP712Domain type definition for the fields being set
+func (d *Domain) Fields() []Field {
+	var fields []Field
+	if d.Name != "" {
+		fields = append(fields, Field{Name: "name", Type: "string"})
+	}
+	if d.Version != "" {
+		fields = append(fields, Field{Name: "version", Type: "string"})
+	}
+	if d.ChainId != nil {
+		fields = append(fields, Field{Name: "chainId", Type: "uint256"})
+	}
+	if d.VerifyingContract != nil {
+		fields = append(fields, Field{Name: "verifyingContract", Type: "address"})
+	}
+	if d.Salt != nil {
+		fields = append(fields, Field{Name: "salt", Type: "bytes32"})
+	}
+	return fields
+}
+
+// Map returns the domain as a struct value
+func (d *Domain) Map() map[string]interface{} {
+	res := map[string]interface{}{}
+	if d.Name != "" {
+		res["name"] = d.Name
+	}
+	if d.Version != "" {
+		res["version"] = d.Version
+	}
+	if d.ChainId != nil {
+		res["chainId"] = d.ChainId
+	}
+	if d.VerifyingContract != nil {
+		res["verifyingContract"] = *d.VerifyingContract
+	}
+	if d.Salt != nil {
+		res["salt"] = *d.Salt
+	}
+	return res
+}
+
+type domainJSON struct {
+	Name              string        `json:"name,omitempty"`
+	Version           string        `json:"version,omitempty"`
+	ChainId           interface{}   `json:"chainId,omitempty"`
+	VerifyingContract *web3.Address `json:"verifyingContract,omitempty"`
+	Salt              *web3.Hash    `json:"salt,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (d *Domain) MarshalJSON() ([]byte, error) {
+	obj := &domainJSON{
+		Name:              d.Name,
+		Version:           d.Version,
+		VerifyingContract: d.VerifyingContract,
+		Salt:              d.Salt,
+	}
+	if d.ChainId != nil {
+		obj.ChainId = json.Number(d.ChainId.String())
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The chain id
+// is accepted both as a number and as a decimal or hex string
+func (d *Domain) UnmarshalJSON(data []byte) error {
+	var obj domainJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	d.Name = obj.Name
+	d.Version = obj.Version
+	d.VerifyingContract = obj.VerifyingContract
+	d.Salt = obj.Salt
+	d.ChainId = nil
+	if obj.ChainId != nil {
+		num, err := toBigInt(obj.ChainId)
+		if err != nil {
+			return fmt.Errorf("chainId: %v", err)
+		}
+		d.ChainId = num
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+package eip712
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/crypto"
+	"github.com/laizy/web3/wallet"
+)
+
+// DomainTypeName is the name of the type used to hash the domain
+const DomainTypeName = "EIP712Domain"
+
+// Field is a member of a struct type
+type Field struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Types are the struct definitions referenced by the typed data
+type Types map[string][]Field
+
+// TypedData is the eip712 typed structured data
+type TypedData struct {
+	Types       Types                  `json:"types"`
+	PrimaryType string                 `json:"primaryType"`
+	Domain      *Domain                `json:"domain"`
+	Message     map[string]interface{} `json:"message"`
+}
+
+// NewTypedData parses the typed data in json format
+func NewTypedData(s string) (*TypedData, error) {
+	return NewTypedDataFromReader(bytes.NewReader([]byte(s)))
+}
+
+// MustNewTypedData parses the typed data or panics if fails
+func MustNewTypedData(s string) *TypedData {
+	t, err := NewTypedData(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// NewTypedDataFromReader returns a typed data object from a reader
+func NewTypedDataFromReader(r io.Reader) (*TypedData, error) {
+	var typed *TypedData
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&typed); err != nil {
+		return nil, err
+	}
+	if typed == nil {
+		return nil, fmt.Errorf("empty typed data")
+	}
+	if typed.Domain == nil {
+		typed.Domain = &Domain{}
+	}
+	if _, ok := typed.Types[typed.PrimaryType]; !ok {
+		return nil, fmt.Errorf("primary type '%s' not defined", typed.PrimaryType)
+	}
+	return typed, nil
+}
+
+// domainTypes returns the fields of the domain, the ones declared in
+// the types section have preference over the ones derived from the domain
+func (t *TypedData) domainTypes() Types {
+	types := Types{}
+	for name, fields := range t.Types {
+		types[name] = fields
+	}
+	if _, ok := types[DomainTypeName]; !ok {
+		types[DomainTypeName] = t.Domain.Fields()
+	}
+	return types
+}
+
+// EncodeType returns the encoded type of the primary type
+func (t *TypedData) EncodeType(primaryType string) (string, error) {
+	return t.domainTypes().EncodeType(primaryType)
+}
+
+// TypeHash returns the hash of the encoded type
+func (t *TypedData) TypeHash(primaryType string) (web3.Hash, error) {
+	return t.domainTypes().TypeHash(primaryType)
+}
+
+// HashStruct returns the hash of a struct value of the given type
+func (t *TypedData) HashStruct(primaryType string, data map[string]interface{}) (web3.Hash, error) {
+	return t.domainTypes().HashStruct(primaryType, data)
+}
+
+// DomainSeparator returns the hash of the domain
+func (t *TypedData) DomainSeparator() (web3.Hash, error) {
+	return t.HashStruct(DomainTypeName, t.Domain.Map())
+}
+
+// Hash returns the digest to be signed: keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+func (t *TypedData) Hash() (web3.Hash, error) {
+	domain, err := t.DomainSeparator()
+	if err != nil {
+		return web3.Hash{}, fmt.Errorf("domain: %v", err)
+	}
+	msg, err := t.HashStruct(t.PrimaryType, t.Message)
+	if err != nil {
+		return web3.Hash{}, fmt.Errorf("message: %v", err)
+	}
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domain[:], msg[:]), nil
+}
+
+// Sign signs the typed data with the key. The signature is in the
+// [R || S || V] format with V being 0 or 1
+func (t *TypedData) Sign(key *wallet.Key) ([]byte, error) {
+	hash, err := t.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return key.Sign(hash[:])
+}
+
+// RecoverSigner returns the address that signed the typed data. V may be
+// either 0/1 or 27/28
+func (t *TypedData) RecoverSigner(signature []byte) (web3.Address, error) {
+	if len(signature) != 65 {
+		return web3.Address{}, fmt.Errorf("invalid signature length %d", len(signature))
+	}
+	hash, err := t.Hash()
+	if err != nil {
+		return web3.Address{}, err
+	}
+	sig := web3.CopyBytes(signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	return wallet.Ecrecover(hash[:], sig)
+}
+
+// EncodeType returns the encoded type, the referenced struct types are
+// appended sorted by name
+func (t Types) EncodeType(primaryType string) (string, error) {
+	if _, ok := t[primaryType]; !ok {
+		return "", fmt.Errorf("type '%s' not defined", primaryType)
+	}
+	deps := map[string]struct{}{}
+	if err := t.dependencies(primaryType, deps); err != nil {
+		return "", err
+	}
+	delete(deps, primaryType)
+
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	names = append([]string{primaryType}, names...)
+
+	var buf strings.Builder
+	for _, name := range names {
+		fields := make([]string, len(t[name]))
+		for i, field := range t[name] {
+			fields[i] = field.Type + " " + field.Name
+		}
+		buf.WriteString(name + "(" + strings.Join(fields, ",") + ")")
+	}
+	return buf.String(), nil
+}
+
+func (t Types) dependencies(typ string, found map[string]struct{}) error {
+	if _, ok := found[typ]; ok {
+		return nil
+	}
+	fields, ok := t[typ]
+	if !ok {
+		return fmt.Errorf("type '%s' not defined", typ)
+	}
+	found[typ] = struct{}{}
+	for _, field := range fields {
+		if base := baseType(field.Type); t.isStruct(base) {
+			if err := t.dependencies(base, found); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TypeHash returns the hash of the encoded type
+func (t Types) TypeHash(primaryType string) (web3.Hash, error) {
+	encoded, err := t.EncodeType(primaryType)
+	if err != nil {
+		return web3.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte(encoded)), nil
+}
+
+// HashStruct returns keccak256(typeHash ‖ encodeData(data))
+func (t Types) HashStruct(primaryType string, data map[string]interface{}) (web3.Hash, error) {
+	encoded, err := t.EncodeData(primaryType, data)
+	if err != nil {
+		return web3.Hash{}, err
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+// EncodeData returns the type hash followed by the encoding of each member
+func (t Types) EncodeData(primaryType string, data map[string]interface{}) ([]byte, error) {
+	typeHash, err := t.TypeHash(primaryType)
+	if err != nil {
+		return nil, err
+	}
+	buf := append([]byte{}, typeHash[:]...)
+	for _, field := range t[primaryType] {
+		val, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s.%s: value not found", primaryType, field.Name)
+		}
+		enc, err := t.encodeValue(field.Type, val)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", primaryType, field.Name, err)
+		}
+		buf = append(buf, enc...)
+	}
+	return buf, nil
+}
+
+func (t Types) isStruct(name string) bool {
+	_, ok := t[name]
+	return ok
+}
+
+// encodeValue returns the 32 bytes encoding of a member value
+func (t Types) encodeValue(typ string, val interface{}) ([]byte, error) {
+	if dim := strings.LastIndex(typ, "["); dim != -1 && strings.HasSuffix(typ, "]") {
+		return t.encodeArray(typ[:dim], typ[dim+1:len(typ)-1], val)
+	}
+	if t.isStruct(typ) {
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object for struct '%s' but found %T", typ, val)
+		}
+		hash, err := t.HashStruct(typ, obj)
+		if err != nil {
+			return nil, err
+		}
+		return hash[:], nil
+	}
+
+	abiType, err := abi.NewType(typ)
+	if err != nil {
+		return nil, err
+	}
+	switch abiType.Kind() {
+	case abi.KindString, abi.KindBytes:
+		v, err := convertValue(abiType, val)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := v.(string); ok {
+			return crypto.Keccak256([]byte(s)), nil
+		}
+		return crypto.Keccak256(v.([]byte)), nil
+
+	case abi.KindBool, abi.KindAddress, abi.KindInt, abi.KindUInt, abi.KindFixedBytes:
+		v, err := convertValue(abiType, val)
+		if err != nil {
+			return nil, err
+		}
+		return abiType.Encode(v)
+
+	default:
+		return nil, fmt.Errorf("type '%s' not supported", typ)
+	}
+}
+
+// encodeArray returns the hash of the concatenated encoding of each element
+func (t Types) encodeArray(elemType, size string, val interface{}) ([]byte, error) {
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected array but found %T", val)
+	}
+	if size != "" && size != strconv.Itoa(v.Len()) {
+		return nil, fmt.Errorf("expected array of size %s but found %d", size, v.Len())
+	}
+	var buf []byte
+	for i := 0; i < v.Len(); i++ {
+		enc, err := t.encodeValue(elemType, v.Index(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %v", i, err)
+		}
+		buf = append(buf, enc...)
+	}
+	return crypto.Keccak256(buf), nil
+}
+
+func baseType(typ string) string {
+	if indx := strings.Index(typ, "["); indx != -1 {
+		return typ[:indx]
+	}
+	return typ
+}
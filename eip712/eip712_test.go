@@ -0,0 +1,155 @@
+package eip712
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/crypto"
+	"github.com/laizy/web3/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+// example from the eip712 specification
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedData_Mail(t *testing.T) {
+	typed, err := NewTypedData(mailTypedData)
+	assert.NoError(t, err)
+
+	encoded, err := typed.EncodeType("Mail")
+	assert.NoError(t, err)
+	assert.Equal(t, "Mail(Person from,Person to,string contents)Person(string name,address wallet)", encoded)
+
+	typeHash, err := typed.TypeHash("Mail")
+	assert.NoError(t, err)
+	assert.Equal(t, web3.HexToHash("0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"), typeHash)
+
+	domain, err := typed.DomainSeparator()
+	assert.NoError(t, err)
+	assert.Equal(t, web3.HexToHash("0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"), domain)
+
+	msg, err := typed.HashStruct("Mail", typed.Message)
+	assert.NoError(t, err)
+	assert.Equal(t, web3.HexToHash("0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"), msg)
+
+	hash, err := typed.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, web3.HexToHash("0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"), hash)
+
+	key, err := wallet.NewWalletFromPrivKey(crypto.Keccak256([]byte("cow")))
+	assert.NoError(t, err)
+	assert.Equal(t, web3.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"), key.Address())
+
+	sig, err := typed.Sign(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d", hex.EncodeToString(sig[:32]))
+	assert.Equal(t, "07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562", hex.EncodeToString(sig[32:64]))
+	assert.Equal(t, byte(1), sig[64])
+
+	signer, err := typed.RecoverSigner(sig)
+	assert.NoError(t, err)
+	assert.Equal(t, key.Address(), signer)
+
+	// 27/28 encoded V
+	sig[64] += 27
+	signer, err = typed.RecoverSigner(sig)
+	assert.NoError(t, err)
+	assert.Equal(t, key.Address(), signer)
+}
+
+func TestTypedData_DerivedDomain(t *testing.T) {
+	typed := MustNewTypedData(mailTypedData)
+	expected, err := typed.DomainSeparator()
+	assert.NoError(t, err)
+
+	delete(typed.Types, DomainTypeName)
+	domain, err := typed.DomainSeparator()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, domain)
+}
+
+func TestTypedData_ArraysAndDynamic(t *testing.T) {
+	typed, err := NewTypedData(`{
+		"types": {
+			"Person": [
+				{"name": "name", "type": "string"},
+				{"name": "wallets", "type": "address[]"}
+			],
+			"Group": [
+				{"name": "members", "type": "Person[]"},
+				{"name": "admins", "type": "Person[2]"},
+				{"name": "data", "type": "bytes"},
+				{"name": "ids", "type": "uint32[][]"},
+				{"name": "tag", "type": "bytes4"},
+				{"name": "delta", "type": "int8"}
+			]
+		},
+		"primaryType": "Group",
+		"domain": {"name": "Groups", "chainId": "0x5"},
+		"message": {
+			"members": [{"name": "a", "wallets": ["0x0000000000000000000000000000000000000001"]}],
+			"admins": [{"name": "b", "wallets": []}, {"name": "c", "wallets": []}],
+			"data": "0x0102",
+			"ids": [[1, 2], ["3"]],
+			"tag": "0xaabbccdd",
+			"delta": -128
+		}
+	}`)
+	assert.NoError(t, err)
+
+	encoded, err := typed.EncodeType("Group")
+	assert.NoError(t, err)
+	assert.Equal(t, "Group(Person[] members,Person[2] admins,bytes data,uint32[][] ids,bytes4 tag,int8 delta)Person(string name,address[] wallets)", encoded)
+
+	key, err := wallet.GenerateKey()
+	assert.NoError(t, err)
+
+	sig, err := typed.Sign(key)
+	assert.NoError(t, err)
+
+	signer, err := typed.RecoverSigner(sig)
+	assert.NoError(t, err)
+	assert.Equal(t, key.Address(), signer)
+
+	// wrong array length
+	typed.Message["admins"] = []interface{}{}
+	_, err = typed.Hash()
+	assert.Error(t, err)
+
+	// out of range
+	typed.Message["admins"] = []interface{}{map[string]interface{}{"name": "b", "wallets": []interface{}{}}, map[string]interface{}{"name": "c", "wallets": []interface{}{}}}
+	typed.Message["delta"] = 128
+	_, err = typed.Hash()
+	assert.Error(t, err)
+}
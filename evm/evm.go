@@ -498,3 +498,6 @@ func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *
 
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
+
+// Config returns the configuration of the virtual machine
+func (evm *EVM) Config() Config { return evm.vmConfig }
@@ -117,6 +117,15 @@ type Tracer interface {
 	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error)
 }
 
+// TxTracer is a Tracer that is also notified around the execution of the transaction,
+// with the gas limit and the gas left after the refund, which include the intrinsic gas
+// that the evm does not see.
+type TxTracer interface {
+	Tracer
+	CaptureTxStart(env *EVM, gasLimit uint64)
+	CaptureTxEnd(restGas uint64)
+}
+
 // StructLogger is an EVM state logger and implements Tracer.
 //
 // StructLogger can capture state based on the given Log configuration and also keeps
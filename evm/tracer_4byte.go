@@ -0,0 +1,79 @@
+package evm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/laizy/web3"
+)
+
+// FourByteTracer is a native implementation of geth's 4byteTracer, it counts the
+// selectors and the sizes of the call data of the calls, ie.
+//
+//	{"0x27dc297e-128": 1, "0x38cc4831-0": 2}
+//
+// The keys are the selectors and the sizes of the call data after the selectors. The
+// calls to the precompiles are not counted.
+type FourByteTracer struct {
+	ids map[string]int
+}
+
+// NewFourByteTracer returns a 4byte tracer
+func NewFourByteTracer() *FourByteTracer {
+	return &FourByteTracer{ids: map[string]int{}}
+}
+
+func (t *FourByteTracer) store(id []byte, size int) {
+	t.ids[fmt.Sprintf("0x%x-%d", id, size)]++
+}
+
+// CaptureStart implements the Tracer interface
+func (t *FourByteTracer) CaptureStart(from web3.Address, to web3.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	if len(input) >= 4 {
+		t.store(input[:4], len(input)-4)
+	}
+}
+
+// CaptureState implements the Tracer interface
+func (t *FourByteTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack,
+	rStack *ReturnStack, rData []byte, contract *Contract, depth int, err error) {
+	if err != nil {
+		return
+	}
+	args := 2
+	switch op {
+	case CALL, CALLCODE:
+		args = 3
+	case DELEGATECALL, STATICCALL:
+	default:
+		return
+	}
+	if _, ok := env.precompile(web3.Address(stack.Back(1).Bytes20())); ok {
+		return
+	}
+	if size := stack.Back(args + 1); size.IsUint64() && size.Uint64() >= 4 {
+		input := memoryCopy(memory, stack.Back(args), size)
+		t.store(input[:4], len(input)-4)
+	}
+}
+
+// CaptureFault implements the Tracer interface
+func (t *FourByteTracer) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack,
+	rStack *ReturnStack, contract *Contract, depth int, err error) {
+}
+
+// CaptureEnd implements the Tracer interface
+func (t *FourByteTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+}
+
+// Selectors returns the counts of the calls by selector and size
+func (t *FourByteTracer) Selectors() map[string]int {
+	return t.ids
+}
+
+// GetResult returns the json of the counts
+func (t *FourByteTracer) GetResult() (json.RawMessage, error) {
+	return json.Marshal(t.ids)
+}
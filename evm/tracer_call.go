@@ -0,0 +1,408 @@
+package evm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/crypto"
+	vmerrors "github.com/laizy/web3/evm/errors"
+	"github.com/laizy/web3/evm/params"
+	"github.com/laizy/web3/utils/common"
+	"github.com/laizy/web3/utils/common/hexutil"
+)
+
+// CallTracerConfig are the options of geth's callTracer
+type CallTracerConfig struct {
+	OnlyTopCall bool `json:"onlyTopCall"` // if true, call tracer won't collect any subcalls
+	WithLog     bool `json:"withLog"`     // if true, call tracer will collect event logs
+}
+
+// CallFrame is a call of the transaction in the format of geth's callTracer
+type CallFrame struct {
+	Type         string         `json:"type"`
+	From         web3.Address   `json:"from"`
+	Gas          hexutil.Uint64 `json:"gas"`
+	GasUsed      hexutil.Uint64 `json:"gasUsed"`
+	To           *web3.Address  `json:"to,omitempty"`
+	Input        hexutil.Bytes  `json:"input"`
+	Output       hexutil.Bytes  `json:"output,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	RevertReason string         `json:"revertReason,omitempty"`
+	Calls        []*CallFrame   `json:"calls,omitempty"`
+	Logs         []*CallLog     `json:"logs,omitempty"`
+	Value        *hexutil.Big   `json:"value,omitempty"`
+}
+
+// CallLog is a log emitted by a call, Position is the number of the calls made before it
+type CallLog struct {
+	Address  web3.Address  `json:"address"`
+	Topics   []web3.Hash   `json:"topics"`
+	Data     hexutil.Bytes `json:"data"`
+	Position hexutil.Uint  `json:"position"`
+}
+
+// CallTracer is a native implementation of geth's callTracer. The evm has no hooks for
+// the calls, they are followed from the call instructions and the depth of the steps.
+type CallTracer struct {
+	cfg      CallTracerConfig
+	env      *EVM
+	gasLimit uint64
+	// calls are the running calls by depth, with the call made by the last one
+	calls []*callState
+	top   *CallFrame
+}
+
+type callState struct {
+	frame *CallFrame
+	// gas is the gas of the caller before the call instruction, charged is the gas
+	// taken by the instruction, including the gas given to the callee
+	gas, charged uint64
+	// entered is set if the callee code was executed
+	entered bool
+	// output is the data of the RETURN or the REVERT of the callee
+	output []byte
+	err    error
+}
+
+// NewCallTracer returns a call tracer, cfg may be nil
+func NewCallTracer(cfg *CallTracerConfig) *CallTracer {
+	t := &CallTracer{}
+	if cfg != nil {
+		t.cfg = *cfg
+	}
+	return t
+}
+
+// CaptureTxStart implements the TxTracer interface
+func (t *CallTracer) CaptureTxStart(env *EVM, gasLimit uint64) {
+	t.env = env
+	t.gasLimit = gasLimit
+}
+
+// CaptureTxEnd implements the TxTracer interface, the gas of the top call is the one of
+// the transaction
+func (t *CallTracer) CaptureTxEnd(restGas uint64) {
+	if t.top == nil {
+		return
+	}
+	t.top.Gas = hexutil.Uint64(t.gasLimit)
+	t.top.GasUsed = hexutil.Uint64(t.gasLimit - restGas)
+}
+
+// CaptureStart implements the Tracer interface
+func (t *CallTracer) CaptureStart(from web3.Address, to web3.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	typ := CALL
+	if create {
+		typ = CREATE
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	toCopy := to
+	t.top = &CallFrame{
+		Type:  typ.String(),
+		From:  from,
+		To:    &toCopy,
+		Input: common.CopyBytes(input),
+		Gas:   hexutil.Uint64(gas),
+		Value: (*hexutil.Big)(new(big.Int).Set(value)),
+	}
+	t.calls = []*callState{{frame: t.top, entered: true}}
+}
+
+// CaptureState implements the Tracer interface
+func (t *CallTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack,
+	rStack *ReturnStack, rData []byte, contract *Contract, depth int, err error) {
+	if len(t.calls) == 0 {
+		return
+	}
+	t.env = env
+	for len(t.calls) > depth {
+		t.exit(gas, rData, stack)
+	}
+	current := t.calls[len(t.calls)-1]
+	current.entered = true
+	if err != nil {
+		current.err = err
+		return
+	}
+	switch op {
+	case CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE, CREATE2:
+		t.enter(op, gas, cost, memory, stack, contract)
+	case SELFDESTRUCT:
+		if t.cfg.OnlyTopCall {
+			return
+		}
+		beneficiary := web3.Address(stack.Back(0).Bytes20())
+		current.frame.Calls = append(current.frame.Calls, &CallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			To:    &beneficiary,
+			Input: []byte{},
+			Value: (*hexutil.Big)(new(big.Int).Set(env.StateDB.GetBalance(contract.Address()))),
+		})
+	case LOG0, LOG1, LOG2, LOG3, LOG4:
+		if !t.cfg.WithLog {
+			return
+		}
+		log := &CallLog{
+			Address:  contract.Address(),
+			Topics:   []web3.Hash{},
+			Data:     memoryCopy(memory, stack.Back(0), stack.Back(1)),
+			Position: hexutil.Uint(len(current.frame.Calls)),
+		}
+		for i := 0; i < int(op-LOG0); i++ {
+			log.Topics = append(log.Topics, web3.Hash(stack.Back(2+i).Bytes32()))
+		}
+		current.frame.Logs = append(current.frame.Logs, log)
+	case RETURN, REVERT:
+		current.output = memoryCopy(memory, stack.Back(0), stack.Back(1))
+	}
+}
+
+// CaptureFault implements the Tracer interface
+func (t *CallTracer) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack,
+	rStack *ReturnStack, contract *Contract, depth int, err error) {
+	if depth > 0 && depth <= len(t.calls) {
+		t.calls[depth-1].err = err
+	}
+}
+
+// CaptureEnd implements the Tracer interface
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	if len(t.calls) == 0 {
+		return
+	}
+	// the calls of a failed execution, they are settled with their gas
+	for len(t.calls) > 1 {
+		state := t.calls[len(t.calls)-1]
+		t.calls = t.calls[:len(t.calls)-1]
+		state.frame.GasUsed = state.frame.Gas
+		state.frame.processOutput(state.output, state.callError(false, t.env))
+		t.add(state.frame)
+	}
+	t.top.GasUsed = hexutil.Uint64(gasUsed)
+	t.top.processOutput(output, err)
+	t.calls = nil
+}
+
+// enter records the call made by the instruction
+func (t *CallTracer) enter(op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract) {
+	frame := &CallFrame{Type: op.String(), From: contract.Address()}
+	state := &callState{frame: frame, gas: gas, charged: cost}
+	switch op {
+	case CREATE, CREATE2:
+		value := stack.Back(0).ToBig()
+		frame.Input = memoryCopy(memory, stack.Back(1), stack.Back(2))
+		var to web3.Address
+		if op == CREATE {
+			to = crypto.CreateAddress(contract.Address(), t.env.StateDB.GetNonce(contract.Address()))
+		} else {
+			to = crypto.CreateAddress2(contract.Address(), stack.Back(3).Bytes32(), crypto.Keccak256(frame.Input))
+		}
+		frame.To = &to
+		frame.Value = (*hexutil.Big)(value)
+		// the callee gets all but one 64th of the gas left by the instruction
+		callGas := gas - cost
+		if t.env.chainRules.IsEIP150 {
+			callGas -= callGas / 64
+		}
+		frame.Gas = hexutil.Uint64(callGas)
+		state.charged += callGas
+	default:
+		to := web3.Address(stack.Back(1).Bytes20())
+		frame.To = &to
+		callGas := t.env.callGasTemp
+		args := 2
+		switch op {
+		case CALL, CALLCODE:
+			value := stack.Back(2).ToBig()
+			frame.Value = (*hexutil.Big)(value)
+			if value.Sign() != 0 {
+				callGas += params.CallStipend
+			}
+			args = 3
+		case DELEGATECALL:
+			frame.Value = (*hexutil.Big)(new(big.Int).Set(contract.Value()))
+		}
+		frame.Input = memoryCopy(memory, stack.Back(args), stack.Back(args+1))
+		frame.Gas = hexutil.Uint64(callGas)
+	}
+	t.calls = append(t.calls, state)
+}
+
+// exit settles the last call with the state of the caller after the call instruction
+func (t *CallTracer) exit(gas uint64, rData []byte, stack *Stack) {
+	state := t.calls[len(t.calls)-1]
+	t.calls = t.calls[:len(t.calls)-1]
+	frame := state.frame
+	success := stack.len() != 0 && !stack.Back(0).IsZero()
+
+	returned := gas + state.charged - state.gas
+	if gas+state.charged < state.gas {
+		returned = 0
+	}
+	if returned > uint64(frame.Gas) {
+		returned = uint64(frame.Gas)
+	}
+	frame.GasUsed = frame.Gas - hexutil.Uint64(returned)
+	if !success && !state.entered && frame.GasUsed == 0 {
+		// the call failed before entering the callee, ie. on the depth limit or the balance
+		return
+	}
+
+	output := rData
+	if frame.Type == CREATE.String() || frame.Type == CREATE2.String() {
+		output = state.output
+		if success {
+			output = t.env.StateDB.GetCode(*frame.To)
+		}
+	}
+	var err error
+	if !success {
+		err = state.callError(frame.GasUsed == frame.Gas, t.env)
+	}
+	frame.processOutput(output, err)
+	t.add(frame)
+}
+
+// add appends the settled call to its caller
+func (t *CallTracer) add(frame *CallFrame) {
+	if t.cfg.OnlyTopCall {
+		return
+	}
+	caller := t.calls[len(t.calls)-1].frame
+	caller.Calls = append(caller.Calls, frame)
+}
+
+// callError returns the error of a failed call, the errors raised outside of the
+// interpreter are inferred from the gas and the output of the callee
+func (s *callState) callError(allGasUsed bool, env *EVM) error {
+	switch {
+	case s.err != nil:
+		return s.err
+	case s.frame.Type == CREATE.String() || s.frame.Type == CREATE2.String():
+		switch {
+		case !s.entered && allGasUsed:
+			return vmerrors.ErrContractAddressCollision
+		case env != nil && env.chainRules.IsEIP158 && len(s.output) > params.MaxCodeSize:
+			return vmerrors.ErrMaxCodeSizeExceeded
+		case s.entered:
+			return vmerrors.ErrCodeStoreOutOfGas
+		}
+	case !allGasUsed:
+		return vmerrors.ErrExecutionReverted
+	}
+	return vmerrors.ErrOutOfGas
+}
+
+func (f *CallFrame) processOutput(output []byte, err error) {
+	output = common.CopyBytes(output)
+	if err == nil {
+		f.Output = output
+		return
+	}
+	f.Error = err.Error()
+	if f.Type == CREATE.String() || f.Type == CREATE2.String() {
+		f.To = nil
+	}
+	if !errors.Is(err, vmerrors.ErrExecutionReverted) || len(output) == 0 {
+		return
+	}
+	f.Output = output
+	if reason, ok := unpackRevert(output); ok {
+		f.RevertReason = reason
+	}
+}
+
+// clearFailedLogs drops the logs of the failed calls, they are reverted
+func (f *CallFrame) clearFailedLogs(parentFailed bool) {
+	failed := f.Error != "" || parentFailed
+	if failed {
+		f.Logs = nil
+	}
+	for _, call := range f.Calls {
+		call.clearFailedLogs(failed)
+	}
+}
+
+// Frame returns the top call of the last transaction, nil if none was traced
+func (t *CallTracer) Frame() *CallFrame {
+	if t.top != nil && t.cfg.WithLog {
+		t.top.clearFailedLogs(false)
+	}
+	return t.top
+}
+
+// GetResult returns the json of the top call of the last transaction
+func (t *CallTracer) GetResult() (json.RawMessage, error) {
+	frame := t.Frame()
+	if frame == nil {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	return json.Marshal(frame)
+}
+
+// memoryCopy returns a copy of the memory, the range is checked by the gas of the instruction
+func memoryCopy(memory *Memory, offset, size interface{ Uint64() uint64 }) []byte {
+	return memory.GetCopy(int64(offset.Uint64()), int64(size.Uint64()))
+}
+
+var (
+	revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector  = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// panicReasons are the messages of the panic codes of solidity
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesSlice",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// unpackRevert decodes the reason of the Error(string) and the Panic(uint256) reverts
+func unpackRevert(data []byte) (string, bool) {
+	if len(data) < 4 {
+		return "", false
+	}
+	switch {
+	case string(data[:4]) == string(revertSelector):
+		data = data[4:]
+		if len(data) < 64 {
+			return "", false
+		}
+		offset := new(big.Int).SetBytes(data[:32])
+		if !offset.IsUint64() || offset.Uint64() > uint64(len(data))-32 {
+			return "", false
+		}
+		start := offset.Uint64() + 32
+		length := new(big.Int).SetBytes(data[start-32 : start])
+		if !length.IsUint64() || length.Uint64() > uint64(len(data))-start {
+			return "", false
+		}
+		return string(data[start : start+length.Uint64()]), true
+	case string(data[:4]) == string(panicSelector):
+		if len(data) != 36 {
+			return "", false
+		}
+		code := new(big.Int).SetBytes(data[4:])
+		if code.IsUint64() {
+			if reason, ok := panicReasons[code.Uint64()]; ok {
+				return reason, true
+			}
+		}
+		return fmt.Sprintf("unknown panic code: %#x", code), true
+	}
+	return "", false
+}
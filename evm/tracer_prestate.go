@@ -0,0 +1,261 @@
+package evm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/crypto"
+	"github.com/laizy/web3/utils/common/hexutil"
+)
+
+// PrestateTracerConfig are the options of geth's prestateTracer
+type PrestateTracerConfig struct {
+	DiffMode bool `json:"diffMode"` // If true, this tracer will return state modifications
+}
+
+// PrestateAccount is the state of an account in the format of geth's prestateTracer
+type PrestateAccount struct {
+	Balance *hexutil.Big            `json:"balance,omitempty"`
+	Code    hexutil.Bytes           `json:"code,omitempty"`
+	Nonce   uint64                  `json:"nonce,omitempty"`
+	Storage map[web3.Hash]web3.Hash `json:"storage,omitempty"`
+}
+
+func (a *PrestateAccount) exists() bool {
+	return a.Nonce > 0 || len(a.Code) > 0 || len(a.Storage) > 0 || (a.Balance != nil && a.Balance.ToInt().Sign() != 0)
+}
+
+// PrestateTracer is a native implementation of geth's prestateTracer, it returns the
+// state of the accounts touched by the transaction before its execution, or the changes
+// of the state in diff mode. It needs the TxTracer hooks of the executor to know the
+// fees of the transaction.
+type PrestateTracer struct {
+	cfg      PrestateTracerConfig
+	env      *EVM
+	gasLimit uint64
+	create   bool
+	to       web3.Address
+	pre      map[web3.Address]*PrestateAccount
+	post     map[web3.Address]*PrestateAccount
+	created  map[web3.Address]bool
+	deleted  map[web3.Address]bool
+}
+
+// NewPrestateTracer returns a prestate tracer, cfg may be nil
+func NewPrestateTracer(cfg *PrestateTracerConfig) *PrestateTracer {
+	t := &PrestateTracer{
+		pre:     map[web3.Address]*PrestateAccount{},
+		post:    map[web3.Address]*PrestateAccount{},
+		created: map[web3.Address]bool{},
+		deleted: map[web3.Address]bool{},
+	}
+	if cfg != nil {
+		t.cfg = *cfg
+	}
+	return t
+}
+
+// CaptureTxStart implements the TxTracer interface
+func (t *PrestateTracer) CaptureTxStart(env *EVM, gasLimit uint64) {
+	t.env = env
+	t.gasLimit = gasLimit
+}
+
+// CaptureStart implements the Tracer interface, the value and the gas are already paid
+// and the nonces incremented, the state of the accounts is restored from them
+func (t *PrestateTracer) CaptureStart(from web3.Address, to web3.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	if t.env == nil {
+		return
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	t.create = create
+	t.to = to
+
+	t.lookupAccount(from)
+	t.lookupAccount(to)
+	t.lookupAccount(t.env.Context.Coinbase)
+
+	// the recipient balance includes the value transferred
+	toBal := new(big.Int).Sub(t.pre[to].Balance.ToInt(), value)
+	t.pre[to].Balance = (*hexutil.Big)(toBal)
+	if create && t.env.chainRules.IsEIP158 && t.pre[to].Nonce > 0 {
+		t.pre[to].Nonce--
+	}
+
+	// the sender balance is after reducing the value and the gas limit
+	fromBal := new(big.Int).Set(t.pre[from].Balance.ToInt())
+	gasPrice := t.env.TxContext.GasPrice
+	if gasPrice == nil {
+		gasPrice = new(big.Int)
+	}
+	consumedGas := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(t.gasLimit))
+	fromBal.Add(fromBal, new(big.Int).Add(value, consumedGas))
+	t.pre[from].Balance = (*hexutil.Big)(fromBal)
+	if t.pre[from].Nonce > 0 {
+		t.pre[from].Nonce--
+	}
+
+	if create && t.cfg.DiffMode {
+		t.created[to] = true
+	}
+}
+
+// CaptureState implements the Tracer interface
+func (t *PrestateTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack,
+	rStack *ReturnStack, rData []byte, contract *Contract, depth int, err error) {
+	if err != nil || t.env == nil {
+		return
+	}
+	stackLen := stack.len()
+	caller := contract.Address()
+	switch {
+	case stackLen >= 1 && (op == SLOAD || op == SSTORE):
+		t.lookupStorage(caller, web3.Hash(stack.Back(0).Bytes32()))
+	case stackLen >= 1 && (op == EXTCODECOPY || op == EXTCODEHASH || op == EXTCODESIZE || op == BALANCE || op == SELFDESTRUCT):
+		t.lookupAccount(web3.Address(stack.Back(0).Bytes20()))
+		if op == SELFDESTRUCT {
+			t.deleted[caller] = true
+		}
+	case stackLen >= 5 && (op == DELEGATECALL || op == CALL || op == STATICCALL || op == CALLCODE):
+		t.lookupAccount(web3.Address(stack.Back(1).Bytes20()))
+	case op == CREATE:
+		addr := crypto.CreateAddress(caller, env.StateDB.GetNonce(caller))
+		t.lookupAccount(addr)
+		t.created[addr] = true
+	case stackLen >= 4 && op == CREATE2:
+		init := memoryCopy(memory, stack.Back(1), stack.Back(2))
+		addr := crypto.CreateAddress2(caller, stack.Back(3).Bytes32(), crypto.Keccak256(init))
+		t.lookupAccount(addr)
+		t.created[addr] = true
+	}
+}
+
+// CaptureFault implements the Tracer interface
+func (t *PrestateTracer) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack,
+	rStack *ReturnStack, contract *Contract, depth int, err error) {
+}
+
+// CaptureEnd implements the Tracer interface
+func (t *PrestateTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	if t.cfg.DiffMode {
+		return
+	}
+	if t.create {
+		// keep the existing account prior to the contract creation at that address
+		if s := t.pre[t.to]; s != nil && !s.exists() {
+			delete(t.pre, t.to)
+		}
+	}
+}
+
+// CaptureTxEnd implements the TxTracer interface, the post state of the diff mode is
+// read after the refund and the payment of the fees
+func (t *PrestateTracer) CaptureTxEnd(restGas uint64) {
+	if !t.cfg.DiffMode || t.env == nil {
+		return
+	}
+	state := t.env.StateDB
+	for addr, pre := range t.pre {
+		// the state of the deleted accounts is pruned from post but kept in pre
+		if t.deleted[addr] {
+			continue
+		}
+		modified := false
+		post := &PrestateAccount{Storage: map[web3.Hash]web3.Hash{}}
+		newBalance := state.GetBalance(addr)
+		newNonce := state.GetNonce(addr)
+		newCode := state.GetCode(addr)
+
+		if newBalance.Cmp(pre.Balance.ToInt()) != 0 {
+			modified = true
+			post.Balance = (*hexutil.Big)(new(big.Int).Set(newBalance))
+		}
+		if newNonce != pre.Nonce {
+			modified = true
+			post.Nonce = newNonce
+		}
+		if !bytes.Equal(newCode, pre.Code) {
+			modified = true
+			post.Code = newCode
+		}
+		for key, val := range pre.Storage {
+			// the empty slots are not included
+			if val == (web3.Hash{}) {
+				delete(pre.Storage, key)
+			}
+			newVal := state.GetState(addr, key)
+			if val == newVal {
+				// the unchanged slots are omitted
+				delete(pre.Storage, key)
+			} else {
+				modified = true
+				if newVal != (web3.Hash{}) {
+					post.Storage[key] = newVal
+				}
+			}
+		}
+		if modified {
+			t.post[addr] = post
+		} else {
+			// the accounts that are not modified are not included in the pre state
+			delete(t.pre, addr)
+		}
+	}
+	// the prestate of the created contracts is empty
+	for addr := range t.created {
+		// the created contract may exist in the state before the transaction
+		if s := t.pre[addr]; s != nil && !s.exists() {
+			delete(t.pre, addr)
+		}
+	}
+}
+
+// Prestate returns the state of the accounts before the transaction, and their state
+// after the transaction in diff mode
+func (t *PrestateTracer) Prestate() (pre, post map[web3.Address]*PrestateAccount) {
+	return t.pre, t.post
+}
+
+// GetResult returns the json of the prestate, with the post state in diff mode
+func (t *PrestateTracer) GetResult() (json.RawMessage, error) {
+	if t.env == nil {
+		return nil, errors.New("prestate tracer needs the transaction hooks of the executor")
+	}
+	if t.cfg.DiffMode {
+		return json.Marshal(struct {
+			Post map[web3.Address]*PrestateAccount `json:"post"`
+			Pre  map[web3.Address]*PrestateAccount `json:"pre"`
+		}{t.post, t.pre})
+	}
+	return json.Marshal(t.pre)
+}
+
+// lookupAccount fetches the details of an account and adds it to the prestate if it
+// does not exist there
+func (t *PrestateTracer) lookupAccount(addr web3.Address) {
+	if _, ok := t.pre[addr]; ok {
+		return
+	}
+	t.pre[addr] = &PrestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(t.env.StateDB.GetBalance(addr))),
+		Nonce:   t.env.StateDB.GetNonce(addr),
+		Code:    t.env.StateDB.GetCode(addr),
+		Storage: map[web3.Hash]web3.Hash{},
+	}
+}
+
+// lookupStorage fetches the requested storage slot and adds it to the prestate of the
+// account if it does not exist there
+func (t *PrestateTracer) lookupStorage(addr web3.Address, key web3.Hash) {
+	t.lookupAccount(addr)
+	if _, ok := t.pre[addr].Storage[key]; ok {
+		return
+	}
+	t.pre[addr].Storage[key] = t.env.StateDB.GetState(addr, key)
+}
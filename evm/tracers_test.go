@@ -0,0 +1,239 @@
+package evm_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/evm"
+	"github.com/laizy/web3/jsonrpc"
+	"github.com/laizy/web3/jsonrpc/transport"
+	"github.com/laizy/web3/jsonrpc/transport/transporttest"
+	"github.com/stretchr/testify/require"
+)
+
+var owner = transporttest.DevAccount
+
+// coinbase receives the fees
+var coinbase = web3.HexToAddress("0xc0ffee0000000000000000000000000000000000")
+
+// boxCode stores 1 in slot 0, emits a log with a topic and returns 42
+const boxCode = "6001600055" + // sstore(0, 1)
+	"7f" + "00000000000000000000000000000000000000000000000000000000000000aa" + "60006000a1" + // log1(0, 0, 0xaa)
+	"602a60005260206000f3" // return 42
+
+// failerCode reverts with Error("nope")
+var failerCode = "7f08c379a0" + strings.Repeat("0", 56) + "600052" + // mstore(0, selector)
+	"6020600452" + // mstore(4, 0x20)
+	"6004602452" + // mstore(36, 4)
+	"7f" + hex.EncodeToString([]byte("nope")) + strings.Repeat("0", 56) + "604452" + // mstore(68, "nope")
+	"60646000fd" // revert(0, 100)
+
+// vaultCode calls fill() on the box, check(uint256) on the failer with a static call and
+// emits a log
+func vaultCode(box, failer web3.Address) string {
+	return "63" + hex.EncodeToString(abi.MustNewMethod("function fill()").ID()) + "60e01b600052" + // mstore(0, fill)
+		"60206000600460006000" + "73" + hex.EncodeToString(box[:]) + "5af150" + // call(gas, box, 0, 0, 4, 0, 32)
+		"63" + hex.EncodeToString(abi.MustNewMethod("function check(uint256)").ID()) + "60e01b600052" + // mstore(0, check)
+		"6000600060246000" + "73" + hex.EncodeToString(failer[:]) + "5afa50" + // staticcall(gas, failer, 0, 36, 0, 0)
+		"60006000a000" // log0(0, 0)
+}
+
+type testEnv struct {
+	local  *transport.Local
+	client *jsonrpc.Client
+	box    web3.Address
+	failer web3.Address
+	vault  web3.Address
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	local := transporttest.NewDevSimulated()
+	local.Coinbase = coinbase
+	env := &testEnv{local: local, client: jsonrpc.NewClientWithTransport(local)}
+	env.box = env.deploy(t, boxCode)
+	env.failer = env.deploy(t, failerCode)
+	env.vault = env.deploy(t, vaultCode(env.box, env.failer))
+	return env
+}
+
+func (e *testEnv) deploy(t *testing.T, runtime string) web3.Address {
+	code, err := hex.DecodeString(runtime)
+	require.NoError(t, err)
+	addr, err := transporttest.DeployCode(e.local, owner, code)
+	require.NoError(t, err)
+	return addr
+}
+
+func (e *testEnv) send(t *testing.T, tracer evm.Tracer, to *web3.Address, input []byte) *web3.Receipt {
+	e.local.Executor.Tracer = tracer
+	defer func() { e.local.Executor.Tracer = nil }()
+	hash, err := e.client.Eth().SendTransaction(&web3.Transaction{From: owner, To: to, Input: input, Gas: 500000, GasPrice: 1})
+	require.NoError(t, err)
+	receipt, err := e.client.Eth().GetTransactionReceipt(hash)
+	require.NoError(t, err)
+	return receipt
+}
+
+func result(t *testing.T, tracer interface {
+	GetResult() (json.RawMessage, error)
+}) string {
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	return string(res)
+}
+
+func TestCallTracer(t *testing.T) {
+	env := newTestEnv(t)
+	tracer := evm.NewCallTracer(&evm.CallTracerConfig{WithLog: true})
+	receipt := env.send(t, tracer, &env.vault, abi.MustNewMethod("function run()").ID())
+	require.Equal(t, uint64(43759), receipt.GasUsed)
+	require.JSONEq(t, `{
+		"type": "CALL",
+		"from": "0x1000000000000000000000000000000000000001",
+		"gas": "0x7a120",
+		"gasUsed": "0xaaef",
+		"to": "0x8fc11ea0315429b971aad0723b981a18cc54191b",
+		"input": "0xc0406226",
+		"calls": [
+			{
+				"type": "CALL",
+				"from": "0x8fc11ea0315429b971aad0723b981a18cc54191b",
+				"gas": "0x72ec7",
+				"gasUsed": "0x512f",
+				"to": "0x5dddfce53ee040d9eb21afbc0ae1bb4dbb0ba643",
+				"input": "0xd9c55ce1",
+				"output": "0x000000000000000000000000000000000000000000000000000000000000002a",
+				"logs": [
+					{
+						"address": "0x5dddfce53ee040d9eb21afbc0ae1bb4dbb0ba643",
+						"topics": ["0x00000000000000000000000000000000000000000000000000000000000000aa"],
+						"data": "0x",
+						"position": "0x0"
+					}
+				],
+				"value": "0x0"
+			},
+			{
+				"type": "STATICCALL",
+				"from": "0x8fc11ea0315429b971aad0723b981a18cc54191b",
+				"gas": "0x6dc07",
+				"gasUsed": "0x36",
+				"to": "0x5f8bd49cd9f0cb2bd5bb9d4320dfe9b61023249d",
+				"input": "0x5f72f4500000000000000000000000000000000000000000000000000000000000000000",
+				"output": "0x08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000046e6f706500000000000000000000000000000000000000000000000000000000",
+				"error": "execution reverted",
+				"revertReason": "nope"
+			}
+		],
+		"logs": [{"address": "0x8fc11ea0315429b971aad0723b981a18cc54191b", "topics": [], "data": "0x", "position": "0x2"}],
+		"value": "0x0"
+	}`, result(t, tracer))
+
+	tracer = evm.NewCallTracer(&evm.CallTracerConfig{OnlyTopCall: true})
+	env.send(t, tracer, &env.vault, nil)
+	require.JSONEq(t, `{
+		"type": "CALL",
+		"from": "0x1000000000000000000000000000000000000001",
+		"gas": "0x7a120",
+		"gasUsed": "0x5faf",
+		"to": "0x8fc11ea0315429b971aad0723b981a18cc54191b",
+		"input": "0x",
+		"value": "0x0"
+	}`, result(t, tracer))
+
+	tracer = evm.NewCallTracer(nil)
+	receipt = env.send(t, tracer, &env.failer, nil)
+	require.Equal(t, uint64(0x523e), receipt.GasUsed)
+	require.JSONEq(t, `{
+		"type": "CALL",
+		"from": "0x1000000000000000000000000000000000000001",
+		"gas": "0x7a120",
+		"gasUsed": "0x523e",
+		"to": "0x5f8bd49cd9f0cb2bd5bb9d4320dfe9b61023249d",
+		"input": "0x",
+		"output": "0x08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000046e6f706500000000000000000000000000000000000000000000000000000000",
+		"error": "execution reverted",
+		"revertReason": "nope",
+		"value": "0x0"
+	}`, result(t, tracer))
+
+	tracer = evm.NewCallTracer(nil)
+	receipt = env.send(t, tracer, nil, transporttest.InitCode(web3.Hex2Bytes(boxCode)))
+	require.Equal(t, uint64(0xfaca), receipt.GasUsed)
+	require.JSONEq(t, `{
+		"type": "CREATE",
+		"from": "0x1000000000000000000000000000000000000001",
+		"gas": "0x7a120",
+		"gasUsed": "0xfaca",
+		"to": "0xac466dee8d32dab5fd3b9b61d003181f2c7b4759",
+		"input": "0x61003580600c6000396000f360016000557f00000000000000000000000000000000000000000000000000000000000000aa60006000a1602a60005260206000f3",
+		"output": "0x60016000557f00000000000000000000000000000000000000000000000000000000000000aa60006000a1602a60005260206000f3",
+		"value": "0x0"
+	}`, result(t, tracer))
+	require.Equal(t, receipt.ContractAddress, *tracer.Frame().To)
+
+	_, err := evm.NewCallTracer(nil).GetResult()
+	require.Error(t, err)
+}
+
+func TestPrestateTracer(t *testing.T) {
+	env := newTestEnv(t)
+	tracer := evm.NewPrestateTracer(nil)
+	env.send(t, tracer, &env.vault, nil)
+	require.JSONEq(t, `{
+		"0xc0ffee0000000000000000000000000000000000": {"balance": "0xed70839993000"},
+		"0x1000000000000000000000000000000000000001": {"balance": "0x56bb887252976d000", "nonce": 3},
+		"0x5dddfce53ee040d9eb21afbc0ae1bb4dbb0ba643": {
+			"balance": "0x0",
+			"code": "0x60016000557f00000000000000000000000000000000000000000000000000000000000000aa60006000a1602a60005260206000f3",
+			"nonce": 1,
+			"storage": {"0x0000000000000000000000000000000000000000000000000000000000000000": "0x0000000000000000000000000000000000000000000000000000000000000000"}
+		},
+		"0x5f8bd49cd9f0cb2bd5bb9d4320dfe9b61023249d": {"balance": "0x0", "code": "0x`+failerCode+`", "nonce": 1},
+		"0x8fc11ea0315429b971aad0723b981a18cc54191b": {"balance": "0x0", "code": "0x`+vaultCode(env.box, env.failer)+`", "nonce": 1}
+	}`, result(t, tracer))
+
+	env = newTestEnv(t)
+	tracer = evm.NewPrestateTracer(&evm.PrestateTracerConfig{DiffMode: true})
+	env.send(t, tracer, &env.vault, nil)
+	require.JSONEq(t, `{
+		"post": {
+			"0xc0ffee0000000000000000000000000000000000": {"balance": "0xed7083999daaf"},
+			"0x1000000000000000000000000000000000000001": {"balance": "0x56bb8872529762551", "nonce": 4},
+			"0x5dddfce53ee040d9eb21afbc0ae1bb4dbb0ba643": {
+				"storage": {"0x0000000000000000000000000000000000000000000000000000000000000000": "0x0000000000000000000000000000000000000000000000000000000000000001"}
+			}
+		},
+		"pre": {
+			"0xc0ffee0000000000000000000000000000000000": {"balance": "0xed70839993000"},
+			"0x1000000000000000000000000000000000000001": {"balance": "0x56bb887252976d000", "nonce": 3},
+			"0x5dddfce53ee040d9eb21afbc0ae1bb4dbb0ba643": {"balance": "0x0", "code": "0x60016000557f00000000000000000000000000000000000000000000000000000000000000aa60006000a1602a60005260206000f3", "nonce": 1}
+		}
+	}`, result(t, tracer))
+
+	// the created contract is not in the pre state
+	tracer = evm.NewPrestateTracer(&evm.PrestateTracerConfig{DiffMode: true})
+	env.send(t, tracer, nil, transporttest.InitCode(web3.Hex2Bytes(boxCode)))
+	require.JSONEq(t, `{
+		"post": {
+			"0xc0ffee0000000000000000000000000000000000": {"balance": "0xed708399ad579"},
+			"0x1000000000000000000000000000000000000001": {"balance": "0x56bb8872529752a87", "nonce": 5},
+			"0x73f0066b241ab4b71c53e4f9fef81a20156c22c5": {"code": "0x60016000557f00000000000000000000000000000000000000000000000000000000000000aa60006000a1602a60005260206000f3", "nonce": 1}
+		},
+		"pre": {
+			"0xc0ffee0000000000000000000000000000000000": {"balance": "0xed7083999daaf"},
+			"0x1000000000000000000000000000000000000001": {"balance": "0x56bb8872529762551", "nonce": 4}
+		}
+	}`, result(t, tracer))
+}
+
+func TestFourByteTracer(t *testing.T) {
+	env := newTestEnv(t)
+	tracer := evm.NewFourByteTracer()
+	env.send(t, tracer, &env.vault, abi.MustNewMethod("function run()").ID())
+	require.JSONEq(t, `{"0x5f72f450-32": 1, "0xc0406226-0": 1, "0xd9c55ce1-0": 1}`, result(t, tracer))
+}
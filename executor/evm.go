@@ -25,13 +25,13 @@ import (
 	"github.com/laizy/web3/evm"
 )
 
-// NewEVMBlockContext creates a new context for use in the EVM.
-func NewEVMBlockContext(height, timestamp uint64, hashFn evm.GetHashFunc) evm.BlockContext {
+// NewEVMBlockContext creates a new context for use in the EVM, the coinbase receives the fees.
+func NewEVMBlockContext(height, timestamp uint64, coinbase web3.Address, hashFn evm.GetHashFunc) evm.BlockContext {
 	return evm.BlockContext{
 		CanTransfer: CanTransfer,
 		Transfer:    Transfer,
 		GetHash:     hashFn,
-		Coinbase:    web3.Address{}, // todo
+		Coinbase:    coinbase,
 		BlockNumber: big.NewInt(int64(height)),
 		Time:        big.NewInt(int64(timestamp)),
 		Difficulty:  big.NewInt(0),
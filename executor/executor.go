@@ -16,6 +16,12 @@ type Executor struct {
 	OverlayDB *overlaydb.OverlayDB
 	ChainID   uint64
 	Trace     bool
+	// Tracer receives the execution steps of the transactions if set, Trace prints them as
+	// json otherwise
+	Tracer evm.Tracer
+	// CallTracer receives the execution steps of the calls, ie. eth_call and eth_estimateGas,
+	// they are not traced by Tracer so that the estimations are not counted as transactions
+	CallTracer evm.Tracer
 }
 
 func NewExecutor(db schema.ChainDB, chainID uint64) *Executor {
@@ -32,6 +38,19 @@ func (self *Executor) ResetOverlay() {
 	self.OverlayDB = overlaydb.NewOverlayDB(self.db)
 }
 
+func (self *Executor) evmConfig(tracer evm.Tracer) evm.Config {
+	evmConf := evm.Config{}
+	switch {
+	case tracer != nil:
+		evmConf.Debug = true
+		evmConf.Tracer = tracer
+	case self.Trace:
+		evmConf.Debug = true
+		evmConf.Tracer = evm.NewJSONLogger(nil, os.Stdout)
+	}
+	return evmConf
+}
+
 type Eip155Context struct {
 	BlockHash web3.Hash
 	TxIndex   uint64
@@ -44,11 +63,7 @@ func (self *Executor) Call(msg Message, ctx Eip155Context) (*web3.ExecutionResul
 	usedGas := uint64(0)
 	config := params.GetChainConfig(self.ChainID)
 	statedb := storage.NewStateDB(storage.NewCacheDB(self.OverlayDB))
-	evmConf := evm.Config{}
-	if self.Trace {
-		evmConf.Debug = true
-		evmConf.Tracer = evm.NewJSONLogger(nil, os.Stdout)
-	}
+	evmConf := self.evmConfig(self.CallTracer)
 	result, receipt, err := ApplyMessage(config, self.db, statedb, msg, ctx, &usedGas, evmConf, false)
 
 	if err != nil {
@@ -68,11 +83,7 @@ func (self *Executor) ExecuteTransaction(tx *web3.Transaction, ctx Eip155Context
 	config := params.GetChainConfig(self.ChainID)
 	cacheDB := storage.NewCacheDB(self.OverlayDB)
 	statedb := storage.NewStateDB(cacheDB)
-	evmConf := evm.Config{}
-	if self.Trace {
-		evmConf.Debug = true
-		evmConf.Tracer = evm.NewJSONLogger(nil, os.Stdout)
-	}
+	evmConf := self.evmConfig(self.Tracer)
 	result, receipt, err := ApplyTransaction(config, self.db, statedb, tx, ctx, &usedGas,
 		evmConf, false)
 
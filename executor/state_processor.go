@@ -89,7 +89,7 @@ func ApplyTransaction(config *params.ChainConfig, bc schema.ChainDB, statedb *st
 
 func ApplyMessage(config *params.ChainConfig, bc schema.ChainDB, statedb *storage.StateDB, msg Message, ctx Eip155Context, usedGas *uint64, cfg evm.Config, commitDB bool) (*web3.ExecutionResult, *web3.Receipt, error) {
 	// Create a new context to be used in the EVM environment
-	blockContext := NewEVMBlockContext(ctx.Height, ctx.Timestamp, bc.GetBlockHash)
+	blockContext := NewEVMBlockContext(ctx.Height, ctx.Timestamp, ctx.Coinbase, bc.GetBlockHash)
 	vmenv := evm.NewEVM(blockContext, evm.TxContext{}, statedb, config, cfg)
 	return applyTransaction(msg, statedb, usedGas, vmenv, ctx, commitDB)
 }
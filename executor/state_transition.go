@@ -291,6 +291,12 @@ func (st *StateTransition) TransitionDb() (*web3.ExecutionResult, error) {
 	if err := st.preCheck(); err != nil {
 		return nil, err
 	}
+	if config := st.evm.Config(); config.Debug {
+		if tracer, ok := config.Tracer.(evm.TxTracer); ok {
+			tracer.CaptureTxStart(st.evm, st.initialGas)
+			defer func() { tracer.CaptureTxEnd(st.gas) }()
+		}
+	}
 	msg := st.msg
 	sender := evm.AccountRef(msg.From())
 	homestead := st.evm.ChainConfig().IsHomestead(st.evm.Context.BlockNumber)
@@ -0,0 +1,118 @@
+package gasprofile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiffEntry is the total gas of a function in two profiles, the gas is zero in the profile
+// that does not call the function
+type DiffEntry struct {
+	Function   string
+	BaseCalls  uint64
+	HeadCalls  uint64
+	Base, Head uint64
+}
+
+// Delta returns the gas change from the base to the head profile
+func (self *DiffEntry) Delta() int64 {
+	return int64(self.Head) - int64(self.Base)
+}
+
+// Percent returns the change relative to the base gas, 100 for a new function
+func (self *DiffEntry) Percent() float64 {
+	if self.Base == 0 {
+		if self.Head == 0 {
+			return 0
+		}
+		return 100
+	}
+	return float64(self.Delta()) * 100 / float64(self.Base)
+}
+
+// Diff compares the profiles of two runs of the same scenario
+type Diff struct {
+	Base, Head uint64
+	// Entries are the functions by decreasing delta
+	Entries []*DiffEntry
+}
+
+// Compare returns the changes of the total gas of the functions from the base profile
+func Compare(base, head *Profile) *Diff {
+	res := &Diff{Base: base.GasUsed, Head: head.GasUsed}
+	entries := map[string]*DiffEntry{}
+	entry := func(name string) *DiffEntry {
+		e, ok := entries[name]
+		if !ok {
+			e = &DiffEntry{Function: name}
+			entries[name] = e
+			res.Entries = append(res.Entries, e)
+		}
+		return e
+	}
+	for _, row := range base.Rows() {
+		e := entry(row.Function)
+		e.Base, e.BaseCalls = row.Total, row.Calls
+	}
+	for _, row := range head.Rows() {
+		e := entry(row.Function)
+		e.Head, e.HeadCalls = row.Total, row.Calls
+	}
+	sort.Slice(res.Entries, func(i, j int) bool {
+		a, b := res.Entries[i], res.Entries[j]
+		if a.Delta() != b.Delta() {
+			return a.Delta() > b.Delta()
+		}
+		return a.Function < b.Function
+	})
+	return res
+}
+
+// Delta returns the change of the gas used by the transactions
+func (self *Diff) Delta() int64 {
+	return int64(self.Head) - int64(self.Base)
+}
+
+// Regressions returns the functions whose gas increased by more than the tolerance, a
+// fraction of the base gas
+func (self *Diff) Regressions(tolerance float64) []*DiffEntry {
+	var res []*DiffEntry
+	for _, e := range self.Entries {
+		if float64(e.Head) > float64(e.Base)*(1+tolerance) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Check returns an error listing the regressions over the tolerance, to fail the tests
+// that increase the gas
+func (self *Diff) Check(tolerance float64) error {
+	regressions := self.Regressions(tolerance)
+	if len(regressions) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(regressions))
+	for i, e := range regressions {
+		msgs[i] = fmt.Sprintf("%s: %d -> %d (%+.2f%%)", e.Function, e.Base, e.Head, e.Percent())
+	}
+	return fmt.Errorf("gas regressions: %s", strings.Join(msgs, ", "))
+}
+
+// String renders the changed functions as a table
+func (self *Diff) String() string {
+	lines := [][]string{{"function", "base", "head", "delta", "%"}}
+	for _, e := range self.Entries {
+		if e.Delta() == 0 {
+			continue
+		}
+		lines = append(lines, []string{e.Function, fmt.Sprint(e.Base), fmt.Sprint(e.Head), fmt.Sprintf("%+d", e.Delta()), fmt.Sprintf("%+.2f", e.Percent())})
+	}
+	percent := 0.0
+	if self.Base != 0 {
+		percent = float64(self.Delta()) * 100 / float64(self.Base)
+	}
+	lines = append(lines, []string{"total", fmt.Sprint(self.Base), fmt.Sprint(self.Head), fmt.Sprintf("%+d", self.Delta()), fmt.Sprintf("%+.2f", percent)})
+	return formatTable(lines)
+}
@@ -0,0 +1,267 @@
+package gasprofile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/laizy/web3/evm"
+)
+
+// Class is a group of opcodes
+type Class string
+
+const (
+	ClassStorage Class = "storage"
+	ClassMemory  Class = "memory"
+	ClassCall    Class = "call"
+	ClassCreate  Class = "create"
+	ClassLog     Class = "log"
+	ClassHash    Class = "hash"
+	// ClassAccount are the reads of the other accounts, ie. BALANCE and EXTCODESIZE
+	ClassAccount Class = "account"
+	ClassCompute Class = "compute"
+)
+
+// Classes are the classes in report order
+var Classes = []Class{ClassStorage, ClassMemory, ClassCall, ClassCreate, ClassLog, ClassHash, ClassAccount, ClassCompute}
+
+// ClassOf returns the class of the opcode
+func ClassOf(op evm.OpCode) Class {
+	switch op {
+	case evm.SLOAD, evm.SSTORE:
+		return ClassStorage
+	case evm.MLOAD, evm.MSTORE, evm.MSTORE8, evm.MSIZE, evm.CALLDATACOPY, evm.CODECOPY, evm.RETURNDATACOPY:
+		return ClassMemory
+	case evm.CALL, evm.CALLCODE, evm.DELEGATECALL, evm.STATICCALL, evm.SELFDESTRUCT:
+		return ClassCall
+	case evm.CREATE, evm.CREATE2:
+		return ClassCreate
+	case evm.LOG0, evm.LOG1, evm.LOG2, evm.LOG3, evm.LOG4:
+		return ClassLog
+	case evm.SHA3:
+		return ClassHash
+	case evm.BALANCE, evm.SELFBALANCE, evm.EXTCODESIZE, evm.EXTCODECOPY, evm.EXTCODEHASH:
+		return ClassAccount
+	}
+	return ClassCompute
+}
+
+// Node is a function of the call tree, the calls of the same function from the same
+// caller are merged
+type Node struct {
+	// Contract is the alias or the address of the contract
+	Contract string
+	// Function is the method name, the selector if unknown, 'fallback' or 'constructor'
+	Function string
+	Calls    uint64
+	// Gas is the gas spent by the function itself by class
+	Gas      map[Class]uint64 `json:",omitempty"`
+	Children []*Node          `json:",omitempty"`
+}
+
+// Profile is the gas of the call trees of the traced transactions. The gas is the one of
+// the evm execution, without the intrinsic gas and the refunds of the transactions
+type Profile struct {
+	Transactions uint64
+	GasUsed      uint64
+	Calls        []*Node `json:",omitempty"`
+}
+
+// Name returns the function as 'Contract.function'
+func (self *Node) Name() string {
+	return self.Contract + "." + self.Function
+}
+
+// SelfGas returns the gas spent by the function itself
+func (self *Node) SelfGas() uint64 {
+	var res uint64
+	for _, gas := range self.Gas {
+		res += gas
+	}
+	return res
+}
+
+// TotalGas returns the gas spent by the function and its calls
+func (self *Node) TotalGas() uint64 {
+	res := self.SelfGas()
+	for _, child := range self.Children {
+		res += child.TotalGas()
+	}
+	return res
+}
+
+func (self *Node) spend(class Class, gas uint64) {
+	if gas == 0 {
+		return
+	}
+	if self.Gas == nil {
+		self.Gas = map[Class]uint64{}
+	}
+	self.Gas[class] += gas
+}
+
+func (self *Node) call(contract, function string) *Node {
+	node := findNode(self.Children, contract, function)
+	if node == nil {
+		node = &Node{Contract: contract, Function: function}
+		self.Children = append(self.Children, node)
+	}
+	node.Calls++
+	return node
+}
+
+func (self *Profile) call(contract, function string) *Node {
+	node := findNode(self.Calls, contract, function)
+	if node == nil {
+		node = &Node{Contract: contract, Function: function}
+		self.Calls = append(self.Calls, node)
+	}
+	node.Calls++
+	return node
+}
+
+func findNode(nodes []*Node, contract, function string) *Node {
+	for _, node := range nodes {
+		if node.Contract == contract && node.Function == function {
+			return node
+		}
+	}
+	return nil
+}
+
+// walk visits the nodes in depth first order with their callers
+func (self *Profile) walk(visit func(path []*Node)) {
+	var rec func(path []*Node)
+	rec = func(path []*Node) {
+		visit(path)
+		for _, child := range path[len(path)-1].Children {
+			rec(append(path[:len(path):len(path)], child))
+		}
+	}
+	for _, node := range self.Calls {
+		rec([]*Node{node})
+	}
+}
+
+// WriteFolded writes the profile in the folded stack format of the flamegraph tools, the
+// gas of the functions is split in frames by class, ie. 'Vault.run;Box.fill;storage 22100'
+func (self *Profile) WriteFolded(w io.Writer) error {
+	var err error
+	self.walk(func(path []*Node) {
+		names := make([]string, len(path))
+		for i, node := range path {
+			names[i] = node.Name()
+		}
+		stack := strings.Join(names, ";")
+		for _, class := range Classes {
+			if gas := path[len(path)-1].Gas[class]; gas != 0 && err == nil {
+				_, err = fmt.Fprintf(w, "%s;%s %d\n", stack, class, gas)
+			}
+		}
+	})
+	return err
+}
+
+// Row is the gas of a function in all its call stacks
+type Row struct {
+	Function string
+	Calls    uint64
+	Self     uint64
+	// Total includes the calls, the recursive calls are counted once
+	Total uint64
+	Gas   map[Class]uint64
+}
+
+// Rows returns the functions of the profile by decreasing total gas
+func (self *Profile) Rows() []*Row {
+	rows := map[string]*Row{}
+	self.walk(func(path []*Node) {
+		node := path[len(path)-1]
+		name := node.Name()
+		row, ok := rows[name]
+		if !ok {
+			row = &Row{Function: name, Gas: map[Class]uint64{}}
+			rows[name] = row
+		}
+		row.Calls += node.Calls
+		row.Self += node.SelfGas()
+		for class, gas := range node.Gas {
+			row.Gas[class] += gas
+		}
+		for _, caller := range path[:len(path)-1] {
+			if caller.Name() == name {
+				return
+			}
+		}
+		row.Total += node.TotalGas()
+	})
+	res := make([]*Row, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, row)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Total != res[j].Total {
+			return res[i].Total > res[j].Total
+		}
+		return res[i].Function < res[j].Function
+	})
+	return res
+}
+
+// Table renders the rows of the profile with the gas of the classes that are used
+func (self *Profile) Table() string {
+	rows := self.Rows()
+	var classes []Class
+	for _, class := range Classes {
+		for _, row := range rows {
+			if row.Gas[class] != 0 {
+				classes = append(classes, class)
+				break
+			}
+		}
+	}
+	header := []string{"function", "calls", "self", "total"}
+	for _, class := range classes {
+		header = append(header, string(class))
+	}
+	lines := [][]string{header}
+	for _, row := range rows {
+		line := []string{row.Function, fmt.Sprint(row.Calls), fmt.Sprint(row.Self), fmt.Sprint(row.Total)}
+		for _, class := range classes {
+			line = append(line, fmt.Sprint(row.Gas[class]))
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, []string{fmt.Sprintf("total (%d transactions)", self.Transactions), "", "", fmt.Sprint(self.GasUsed)})
+	return formatTable(lines)
+}
+
+// formatTable aligns the first column to the left and the others to the right
+func formatTable(lines [][]string) string {
+	var widths []int
+	for _, line := range lines {
+		for i, cell := range line {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		cells := make([]string, len(line))
+		for i, cell := range line {
+			if i == 0 {
+				cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+			} else {
+				cells[i] = fmt.Sprintf("%*s", widths[i], cell)
+			}
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, "  "), " ") + "\n")
+	}
+	return b.String()
+}
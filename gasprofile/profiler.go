@@ -0,0 +1,190 @@
+package gasprofile
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/evm"
+	"github.com/laizy/web3/registry"
+)
+
+// Profiler is an evm.Tracer that attributes the gas of the traced transactions to their
+// call tree, the profile is kept across the transactions. Every traced execution counts as a
+// transaction, so it is set as the Tracer of the executor which does not trace the calls and
+// the gas estimations
+type Profiler struct {
+	// Events names the contracts with their aliases, the address is used otherwise
+	Events *registry.EventRegistry
+	// Methods names the functions from the selectors of the calls
+	Methods *registry.MethodRegistry
+
+	profile *Profile
+	frames  []*frame
+}
+
+type frame struct {
+	node     *Node
+	startGas uint64
+	// used is the gas attributed to the frame and its calls
+	used    uint64
+	pending *step
+}
+
+// step is an instruction that waits the next one to know its gas
+type step struct {
+	op        evm.OpCode
+	gas, cost uint64
+	child     *frame
+}
+
+// NewProfiler creates a profiler with the global registries
+func NewProfiler() *Profiler {
+	return &Profiler{Events: registry.Instance(), Methods: registry.MethodInstance(), profile: &Profile{}}
+}
+
+// Profile returns the profile of the transactions traced since the creation or the last reset
+func (self *Profiler) Profile() *Profile {
+	return self.profile
+}
+
+// Reset drops the profile
+func (self *Profiler) Reset() {
+	self.profile = &Profile{}
+	self.frames = nil
+}
+
+// CaptureStart implements the evm.Tracer interface
+func (self *Profiler) CaptureStart(from web3.Address, to web3.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	node := self.profile.call(self.contractName(to), self.functionName(input, create))
+	self.frames = []*frame{{node: node, startGas: gas}}
+}
+
+// CaptureState implements the evm.Tracer interface
+func (self *Profiler) CaptureState(env *evm.EVM, pc uint64, op evm.OpCode, gas, cost uint64, memory *evm.Memory,
+	stack *evm.Stack, rStack *evm.ReturnStack, rData []byte, contract *evm.Contract, depth int, err error) {
+	if len(self.frames) == 0 {
+		return
+	}
+	for len(self.frames) > depth && len(self.frames) > 1 {
+		self.leave(gas)
+	}
+	top := self.frames[len(self.frames)-1]
+	switch {
+	case len(self.frames) < depth:
+		addr := contract.Address()
+		if contract.CodeAddr != nil {
+			addr = *contract.CodeAddr
+		}
+		create := top.pending != nil && (top.pending.op == evm.CREATE || top.pending.op == evm.CREATE2)
+		child := &frame{
+			node:     top.node.call(self.contractName(addr), self.functionName(contract.Input, create)),
+			startGas: gas,
+		}
+		if top.pending != nil {
+			top.pending.child = child
+		}
+		self.frames = append(self.frames, child)
+		top = child
+	case top.pending != nil:
+		top.spend(top.pending.op, sub(top.pending.gas, gas))
+	}
+	top.pending = &step{op: op, gas: gas, cost: cost}
+}
+
+// CaptureFault implements the evm.Tracer interface, the failed instruction is settled with
+// the gas consumed by the call
+func (self *Profiler) CaptureFault(env *evm.EVM, pc uint64, op evm.OpCode, gas, cost uint64, memory *evm.Memory,
+	stack *evm.Stack, rStack *evm.ReturnStack, contract *evm.Contract, depth int, err error) {
+}
+
+// CaptureEnd implements the evm.Tracer interface
+func (self *Profiler) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {
+	if len(self.frames) == 0 {
+		return
+	}
+	for len(self.frames) > 1 {
+		// the gas of the unfinished calls is unknown, it is left to the transaction
+		inner := self.frames[len(self.frames)-1]
+		inner.finish(inner.used)
+		self.frames = self.frames[:len(self.frames)-1]
+		self.frames[0].used += inner.used
+	}
+	self.frames[0].finish(gasUsed)
+	self.frames = nil
+	self.profile.Transactions++
+	self.profile.GasUsed += gasUsed
+}
+
+// leave returns from the current call, gas is the gas of the caller after the call
+func (self *Profiler) leave(gas uint64) {
+	child := self.frames[len(self.frames)-1]
+	self.frames = self.frames[:len(self.frames)-1]
+	parent := self.frames[len(self.frames)-1]
+	call := parent.pending
+	parent.pending = nil
+	if call == nil {
+		child.finish(child.used)
+		parent.used += child.used
+		return
+	}
+	// the cost of the calls includes the gas given to the callee, unlike the one of the
+	// creations which is the base cost, the unused gas is returned to the caller
+	own := call.cost
+	if call.op != evm.CREATE && call.op != evm.CREATE2 {
+		own = sub(call.cost, child.startGas)
+	}
+	consumed := sub(sub(call.gas, gas), own)
+	child.finish(consumed)
+	parent.spend(call.op, own)
+	parent.used += child.used
+}
+
+// spend attributes the gas of an instruction to the frame
+func (self *frame) spend(op evm.OpCode, gas uint64) {
+	self.node.spend(ClassOf(op), gas)
+	self.used += gas
+}
+
+// finish attributes the remaining gas of the call to its last instruction, ie. the gas
+// lost on a failure or the deposit of the created code
+func (self *frame) finish(consumed uint64) {
+	if self.pending != nil {
+		self.spend(self.pending.op, sub(consumed, self.used))
+		self.pending = nil
+	}
+}
+
+func sub(a, b uint64) uint64 {
+	if a < b {
+		return 0
+	}
+	return a - b
+}
+
+func (self *Profiler) contractName(addr web3.Address) string {
+	if self.Events != nil {
+		if name := self.Events.ContractAlias(addr); name != "" {
+			return name
+		}
+	}
+	return addr.String()
+}
+
+func (self *Profiler) functionName(input []byte, create bool) string {
+	switch {
+	case create:
+		return "constructor"
+	case len(input) < 4:
+		return "fallback"
+	}
+	var id [4]byte
+	copy(id[:], input)
+	if self.Methods != nil {
+		if methods := self.Methods.GetMethods(id); len(methods) != 0 {
+			return methods[0].Name
+		}
+	}
+	return fmt.Sprintf("0x%x", id)
+}
@@ -0,0 +1,216 @@
+package gasprofile
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/contract"
+	"github.com/laizy/web3/evm"
+	"github.com/laizy/web3/jsonrpc"
+	"github.com/laizy/web3/jsonrpc/transport"
+	"github.com/laizy/web3/jsonrpc/transport/transporttest"
+	"github.com/laizy/web3/registry"
+	"github.com/stretchr/testify/require"
+)
+
+var owner = transporttest.DevAccount
+
+// boxCode stores 1 in the slots and emits a log
+func boxCode(slots int) string {
+	var code string
+	for i := 0; i < slots; i++ {
+		code += fmt.Sprintf("600160%02x55", i)
+	}
+	return code + "60006000a000"
+}
+
+// vaultCode calls 'fill()' on the box
+func vaultCode(box web3.Address) string {
+	return "63" + hex.EncodeToString(abi.MustNewMethod("function fill()").ID()) + "60e01b600052" + // mstore(0, selector)
+		"60006000600460006000" + "73" + hex.EncodeToString(box[:]) + "5af1" + // call(gas, box, 0, 0, 4, 0, 0)
+		"5000"
+}
+
+type testEnv struct {
+	client   *jsonrpc.Client
+	local    *transport.Local
+	profiler *Profiler
+	vault    web3.Address
+}
+
+func newTestEnv(t *testing.T, slots int) *testEnv {
+	local := transporttest.NewDevSimulated()
+	client := jsonrpc.NewClientWithTransport(local)
+	deploy := func(runtime string) web3.Address {
+		addr, err := transporttest.DeployCode(local, owner, web3.Hex2Bytes(runtime))
+		require.NoError(t, err)
+		return addr
+	}
+	box := deploy(boxCode(slots))
+	vault := deploy(vaultCode(box))
+
+	events, methods := registry.NewEventRegistry(), registry.NewMethodRegistry()
+	events.RegisterContractAlias(box, "Box")
+	events.RegisterContractAlias(vault, "Vault")
+	methods.RegisterFromHumanString("function fill()")
+	methods.RegisterFromHumanString("function run()")
+	profiler := NewProfiler()
+	profiler.Events, profiler.Methods = events, methods
+	local.Executor.CallTracer = profiler
+	return &testEnv{client: client, local: local, profiler: profiler, vault: vault}
+}
+
+func (e *testEnv) run(t *testing.T, input []byte) {
+	_, err := e.client.Eth().Call(&web3.CallMsg{From: owner, To: &e.vault, Data: input}, web3.Latest)
+	require.NoError(t, err)
+}
+
+func TestProfiler(t *testing.T) {
+	env := newTestEnv(t, 1)
+	run := abi.MustNewMethod("function run()").ID()
+	env.run(t, run)
+	env.run(t, run)
+	env.run(t, nil)
+
+	profile := env.profiler.Profile()
+	require.Equal(t, uint64(3), profile.Transactions)
+	require.Equal(t, uint64(63381), profile.GasUsed)
+	var total uint64
+	for _, node := range profile.Calls {
+		total += node.TotalGas()
+	}
+	require.Equal(t, profile.GasUsed, total)
+
+	require.Equal(t, `function                calls   self  total  storage  memory  call   log  compute
+Box.fill                    3  61161  61161    60000       0     0  1125       36
+Vault.run                   2   1480  42254        0      12  1400     0       68
+Vault.fallback              1    740  21127        0       6   700     0       34
+total (3 transactions)                63381
+`, profile.Table())
+
+	var folded strings.Builder
+	require.NoError(t, profile.WriteFolded(&folded))
+	require.Equal(t, `Vault.run;memory 12
+Vault.run;call 1400
+Vault.run;compute 68
+Vault.run;Box.fill;storage 40000
+Vault.run;Box.fill;log 750
+Vault.run;Box.fill;compute 24
+Vault.fallback;memory 6
+Vault.fallback;call 700
+Vault.fallback;compute 34
+Vault.fallback;Box.fill;storage 20000
+Vault.fallback;Box.fill;log 375
+Vault.fallback;Box.fill;compute 12
+`, folded.String())
+
+	// the profiles are saved as json to be compared with the later runs
+	buf, err := json.Marshal(profile)
+	require.NoError(t, err)
+	saved := &Profile{}
+	require.NoError(t, json.Unmarshal(buf, saved))
+	require.Equal(t, profile, saved)
+
+	env.profiler.Reset()
+	require.Equal(t, &Profile{}, env.profiler.Profile())
+}
+
+func TestProfiler_Transaction(t *testing.T) {
+	env := newTestEnv(t, 1)
+	env.local.Executor.Tracer, env.local.Executor.CallTracer = env.profiler, nil
+
+	// the gas is estimated before the transaction is sent
+	vault := contract.NewContract(env.vault, abi.MustNewABI(`[{"type": "function", "name": "run", "inputs": [], "outputs": []}]`), env.client)
+	vault.SetFrom(owner)
+	receipt, err := vault.Txn("run").DoAndWait()
+	require.NoError(t, err)
+
+	profile := env.profiler.Profile()
+	require.Equal(t, uint64(1), profile.Transactions)
+	// the intrinsic gas is not profiled
+	require.Equal(t, uint64(21127), profile.GasUsed)
+	require.Equal(t, receipt.GasUsed, profile.GasUsed+21000+4*16)
+	require.Equal(t, "Vault.run", profile.Calls[0].Contract+"."+profile.Calls[0].Function)
+}
+
+func TestProfiler_UnknownSelector(t *testing.T) {
+	env := newTestEnv(t, 1)
+	env.profiler.Events, env.profiler.Methods = nil, nil
+	env.run(t, []byte{1, 2, 3, 4})
+
+	node := env.profiler.Profile().Calls[0]
+	require.Equal(t, env.vault.String(), node.Contract)
+	require.Equal(t, "0x01020304", node.Function)
+	require.Equal(t, "0x"+hex.EncodeToString(abi.MustNewMethod("function fill()").ID()), node.Children[0].Function)
+}
+
+func TestProfiler_Create(t *testing.T) {
+	local := transporttest.NewDevSimulated()
+	profiler := NewProfiler()
+	local.Executor.Tracer = profiler
+
+	box, err := transporttest.DeployCode(local, owner, web3.Hex2Bytes(boxCode(1)))
+	require.NoError(t, err)
+	profile := profiler.Profile()
+	require.Len(t, profile.Calls, 1)
+	node := profile.Calls[0]
+	require.Equal(t, box.String(), node.Contract)
+	require.Equal(t, "constructor", node.Function)
+	// the deposit of the code is attributed to the RETURN
+	require.Equal(t, profile.GasUsed, node.SelfGas())
+	require.Greater(t, node.Gas[ClassCompute], uint64(200*len(boxCode(1))/2))
+
+	// the factory pays the creation, the created contract its code deposit
+	init := hex.EncodeToString(transporttest.InitCode([]byte{0}))
+	factory, err := transporttest.DeployCode(local, owner, web3.Hex2Bytes("6c"+init+"600052"+ // mstore(0, init)
+		"600d60136000f05000")) // create(0, 19, 13)
+	require.NoError(t, err)
+	profiler.Reset()
+	_, err = jsonrpc.NewClientWithTransport(local).Eth().SendTransaction(&web3.Transaction{From: owner, To: &factory, Gas: 500000, GasPrice: 1})
+	require.NoError(t, err)
+	node = profiler.Profile().Calls[0]
+	require.Equal(t, "fallback", node.Function)
+	require.Equal(t, uint64(32000), node.Gas[ClassCreate])
+	require.Less(t, node.SelfGas(), uint64(32100))
+	require.Len(t, node.Children, 1)
+	child := node.Children[0]
+	require.Equal(t, "constructor", child.Function)
+	require.Zero(t, child.Gas[ClassCreate])
+	require.Equal(t, child.TotalGas(), child.SelfGas())
+	require.Greater(t, child.Gas[ClassCompute], uint64(200))
+	require.Less(t, child.Gas[ClassCompute], uint64(300))
+}
+
+func TestCompare(t *testing.T) {
+	run := abi.MustNewMethod("function run()").ID()
+	base := newTestEnv(t, 1)
+	base.run(t, run)
+	head := newTestEnv(t, 2)
+	head.run(t, run)
+
+	diff := Compare(base.profiler.Profile(), head.profiler.Profile())
+	require.Equal(t, int64(20006), diff.Delta())
+	require.Equal(t, "Box.fill", diff.Entries[0].Function)
+	require.Equal(t, `function    base   head   delta       %
+Box.fill   20387  40393  +20006  +98.13
+Vault.run  21127  41133  +20006  +94.69
+total      21127  41133  +20006  +94.69
+`, diff.String())
+	require.Len(t, diff.Regressions(0.95), 1)
+	require.NoError(t, diff.Check(1))
+	require.EqualError(t, diff.Check(0.95), "gas regressions: Box.fill: 20387 -> 40393 (+98.13%)")
+
+	require.NoError(t, Compare(base.profiler.Profile(), base.profiler.Profile()).Check(0))
+}
+
+func TestClassOf(t *testing.T) {
+	require.Equal(t, ClassStorage, ClassOf(evm.SSTORE))
+	require.Equal(t, ClassCall, ClassOf(evm.DELEGATECALL))
+	require.Equal(t, ClassLog, ClassOf(evm.LOG2))
+	require.Equal(t, ClassCompute, ClassOf(evm.ADD))
+}
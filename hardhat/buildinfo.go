@@ -0,0 +1,166 @@
+package hardhat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/laizy/web3/compiler"
+)
+
+// BuildInfo is a hardhat build-info file, the standard-json input and output of a
+// compilation
+type BuildInfo struct {
+	ID              string                   `json:"id"`
+	SolcVersion     string                   `json:"solcVersion"`
+	SolcLongVersion string                   `json:"solcLongVersion"`
+	Input           *compiler.StandardInput  `json:"input"`
+	Output          *compiler.StandardOutput `json:"output"`
+
+	artifactsOnce sync.Once
+	artifacts     map[string]*compiler.Artifact
+	artifactsErr  error
+}
+
+// DecodeBuildInfo decodes a build-info file
+func DecodeBuildInfo(buf []byte) (*BuildInfo, error) {
+	var info BuildInfo
+	if err := json.Unmarshal(buf, &info); err != nil {
+		return nil, err
+	}
+	if info.Input == nil || info.Output == nil {
+		return nil, fmt.Errorf("build info without input or output")
+	}
+	return &info, nil
+}
+
+// Source returns the content of the source file
+func (self *BuildInfo) Source(name string) (string, bool) {
+	src, ok := self.Input.Sources[name]
+	if !ok {
+		return "", false
+	}
+	return src.Content, true
+}
+
+// Artifacts returns the contracts of the compilation keyed by 'source:name', they are
+// decoded once and shared by the callers
+func (self *BuildInfo) Artifacts() (map[string]*compiler.Artifact, error) {
+	self.artifactsOnce.Do(func() {
+		self.artifacts, self.artifactsErr = self.Output.Artifacts()
+	})
+	return self.artifacts, self.artifactsErr
+}
+
+// Artifact returns the contract of the compilation
+func (self *BuildInfo) Artifact(sourceName, contractName string) (*compiler.Artifact, error) {
+	artifacts, err := self.Artifacts()
+	if err != nil {
+		return nil, err
+	}
+	artifact, ok := artifacts[sourceName+":"+contractName]
+	if !ok {
+		return nil, fmt.Errorf("contract %s:%s not found in build info %s", sourceName, contractName, self.ID)
+	}
+	return artifact, nil
+}
+
+// GetBuildInfos returns the build-info files of the project keyed by id
+func GetBuildInfos(artifactDirName ...string) (map[string]*BuildInfo, error) {
+	name := "artifacts"
+	if len(artifactDirName) != 0 && artifactDirName[0] != "" {
+		name = artifactDirName[0]
+	}
+	root, err := GetProjectRoot()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(root, name, "build-info")
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]*BuildInfo{}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		info, err := readBuildInfo(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if info.ID == "" {
+			info.ID = strings.TrimSuffix(file.Name(), ".json")
+		}
+		res[info.ID] = info
+	}
+	return res, nil
+}
+
+func readBuildInfo(path string) (*BuildInfo, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := DecodeBuildInfo(buf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid build info %s: %v", path, err)
+	}
+	return info, nil
+}
+
+// buildInfoPath returns the build-info file of the artifact from its debug file, or an
+// empty string if there is no debug file
+func buildInfoPath(artifactPath string) (string, error) {
+	dbgPath := strings.TrimSuffix(artifactPath, ".json") + ".dbg.json"
+	buf, err := ioutil.ReadFile(dbgPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	var dbg struct {
+		BuildInfo string `json:"buildInfo"`
+	}
+	if err := json.Unmarshal(buf, &dbg); err != nil {
+		return "", fmt.Errorf("invalid debug file %s: %v", dbgPath, err)
+	}
+	if dbg.BuildInfo == "" {
+		return "", nil
+	}
+	return filepath.Join(filepath.Dir(dbgPath), filepath.FromSlash(dbg.BuildInfo)), nil
+}
+
+// withBuildInfo fills the fields of the artifact that are only in its build info
+func (self *Artifact) withBuildInfo(info *BuildInfo) error {
+	compiled, err := info.Artifact(self.SourceName, self.ContractName)
+	if err != nil {
+		return err
+	}
+	self.BuildInfo = info
+	if self.StorageLayout == nil {
+		self.StorageLayout = compiled.StorageLayout
+	}
+	if self.MethodIdentifiers == nil {
+		self.MethodIdentifiers = compiled.MethodIdentifiers
+	}
+	if self.SourceMap == "" {
+		self.SourceMap = compiled.SourceMap
+	}
+	if self.DeployedSourceMap == "" {
+		self.DeployedSourceMap = compiled.DeployedSourceMap
+	}
+	if self.ImmutableReferences == nil {
+		self.ImmutableReferences = compiled.ImmutableReferences
+	}
+	if self.Metadata == "" {
+		self.Metadata = compiled.Metadata
+	}
+	self.Sources = compiled.Sources
+	return nil
+}
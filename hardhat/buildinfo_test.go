@@ -0,0 +1,188 @@
+package hardhat
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func chdir(t *testing.T, dir string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+}
+
+func TestGetArtifactWithBuildInfo(t *testing.T) {
+	chdir(t, "testdata/hardhat")
+
+	arti, err := GetArtifact("Box")
+	if err != nil {
+		t.Fatalf("get artifact err: %v", err)
+	}
+	if arti.BuildInfo == nil || arti.BuildInfo.SolcVersion != "0.8.10" || arti.BuildInfoErr != nil {
+		t.Fatalf("build info not loaded")
+	}
+	if src, ok := arti.BuildInfo.Source("contracts/Box.sol"); !ok || src == "" {
+		t.Fatalf("source not found")
+	}
+	if arti.BuildInfo.Input.Settings.Optimizer == nil || arti.BuildInfo.Input.Settings.Optimizer.Runs != 200 {
+		t.Fatalf("bad settings")
+	}
+	if arti.StorageLayout == nil || arti.StorageLayout.Storage[0].Label != "value" {
+		t.Fatalf("bad storage layout")
+	}
+	if arti.MethodIdentifiers["value()"] != "3fa4f245" {
+		t.Fatalf("bad method identifiers")
+	}
+	if arti.DeployedSourceMap != "25:80:0:-:0;;;" || len(arti.Sources) != 1 {
+		t.Fatalf("bad source map")
+	}
+	if refs := arti.ImmutableReferences["7"]; len(refs) != 1 || refs[0].Start != 10 {
+		t.Fatalf("bad immutable references")
+	}
+
+	infos, err := GetBuildInfos()
+	if err != nil {
+		t.Fatalf("get build infos err: %v", err)
+	}
+	if _, ok := infos["6f1c2a"]; !ok {
+		t.Fatalf("build info not found")
+	}
+}
+
+// copyDir copies the files of the testdata project to a temporary directory
+func copyDir(t *testing.T, src string) string {
+	dst := t.TempDir()
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(dst, rel), buf, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+func TestGetArtifactWithStaleBuildInfo(t *testing.T) {
+	dir := copyDir(t, "testdata/hardhat")
+	chdir(t, dir)
+	dbgPath := filepath.Join("artifacts", "contracts", "Box.sol", "Box.dbg.json")
+
+	// the build info does not have the contract
+	stale := []byte(`{"id": "stale", "input": {"sources": {}}, "output": {"contracts": {}}}`)
+	if err := ioutil.WriteFile(filepath.Join("artifacts", "build-info", "stale.json"), stale, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(dbgPath, []byte(`{"buildInfo": "../../build-info/stale.json"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	arti, err := GetArtifact("Box")
+	if err != nil {
+		t.Fatalf("get artifact err: %v", err)
+	}
+	if arti.BuildInfo != nil || arti.StorageLayout != nil {
+		t.Fatalf("stale build info used")
+	}
+	if arti.BuildInfoErr == nil || !strings.Contains(arti.BuildInfoErr.Error(), "stale.json") {
+		t.Fatalf("bad build info error: %v", arti.BuildInfoErr)
+	}
+	if arti.ContractName != "Box" || len(arti.Bytecode) == 0 {
+		t.Fatalf("bad artifact")
+	}
+
+	// the build info was removed
+	if err := ioutil.WriteFile(dbgPath, []byte(`{"buildInfo": "../../build-info/missing.json"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	artifacts, err := GetArtifacts()
+	if err != nil {
+		t.Fatalf("get artifacts err: %v", err)
+	}
+	if arti := artifacts["Box"]; arti == nil || arti.BuildInfo != nil || !os.IsNotExist(arti.BuildInfoErr) {
+		t.Fatalf("bad artifact without build info")
+	}
+}
+
+func TestBuildInfoArtifactsCached(t *testing.T) {
+	info, err := readBuildInfo("testdata/hardhat/artifacts/build-info/6f1c2a.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := info.Artifacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := info.Artifacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first["contracts/Box.sol:Box"] == nil || first["contracts/Box.sol:Box"] != second["contracts/Box.sol:Box"] {
+		t.Fatalf("artifacts not cached")
+	}
+}
+
+func TestGetFoundryArtifacts(t *testing.T) {
+	chdir(t, "testdata/foundry")
+
+	artifacts, err := GetFoundryArtifacts()
+	if err != nil {
+		t.Fatalf("get artifacts err: %v", err)
+	}
+	arti, ok := artifacts["Box"]
+	if !ok {
+		t.Fatalf("artifact not found")
+	}
+	if arti.ContractName != "Box" || arti.SourceName != "src/Box.sol" {
+		t.Fatalf("bad name %s %s", arti.ContractName, arti.SourceName)
+	}
+	if arti.Bytecode.String() != "0x6080" || arti.DeployedBytecode.String() != "0x6081" {
+		t.Fatalf("bad bytecode")
+	}
+	if arti.MethodIdentifiers["value()"] != "3fa4f245" || arti.StorageLayout == nil {
+		t.Fatalf("bad method identifiers or storage layout")
+	}
+	if arti.SourceMap == "" || len(arti.ImmutableReferences["7"]) != 1 {
+		t.Fatalf("bad source map or immutable references")
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	cases := []string{
+		`[]`,
+		`{"bytecode": "0x60"}`,
+		`{"abi": {}, "bytecode": "0x60"}`,
+		`{"abi": [], "bytecode": 1}`,
+		`{"abi": [], "bytecode": "0xzz"}`,
+		`{"abi": [], "bytecode": {"object": []}}`,
+		`{"abi": [], "deployed_bytecode": {"object": "0x6"}}`,
+		`{"abi": [], "metadata": 1}`,
+	}
+	for _, c := range cases {
+		if _, err := DecodeArtifact([]byte(c)); err == nil {
+			t.Fatalf("expected error for %s", c)
+		}
+	}
+	if _, err := DecodeBuildInfo([]byte(`{"id": "a"}`)); err == nil {
+		t.Fatalf("expected error for build info without output")
+	}
+}
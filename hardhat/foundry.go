@@ -0,0 +1,65 @@
+package hardhat
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GetFoundryArtifacts returns the forge artifacts of the project 'out' directory, or of
+// the given directory, keyed by file name without extension
+func GetFoundryArtifacts(outDirName ...string) (map[string]*Artifact, error) {
+	name := "out"
+	if len(outDirName) != 0 && outDirName[0] != "" {
+		name = outDirName[0]
+	}
+	outDir := name
+	if !filepath.IsAbs(name) {
+		root, err := GetFoundryRoot()
+		if err != nil {
+			return nil, err
+		}
+		outDir = filepath.Join(root, name)
+	}
+
+	results := make(map[string]*Artifact)
+	err := filepath.Walk(outDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == "build-info" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		base := filepath.Base(path)
+		if !strings.HasSuffix(base, ".json") || strings.HasSuffix(base, ".metadata.json") {
+			return nil
+		}
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		arti, err := DecodeArtifact(buf)
+		if err != nil {
+			return fmt.Errorf("invalid artifact %s: %v", path, err)
+		}
+		name := strings.TrimSuffix(base, ".json")
+		if arti.ContractName == "" {
+			// the file is named after the contract, with the compiler version if duplicated
+			arti.ContractName = strings.Split(name, ".")[0]
+		}
+		if arti.SourceName == "" && strings.HasSuffix(filepath.Dir(path), ".sol") {
+			arti.SourceName = filepath.Base(filepath.Dir(path))
+		}
+		results[name] = arti
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
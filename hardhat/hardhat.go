@@ -1,18 +1,19 @@
 package hardhat
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"reflect"
 	"strings"
 
+	"github.com/laizy/web3"
 	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/compiler"
 	"github.com/laizy/web3/registry"
-	"github.com/laizy/web3/utils"
 	"github.com/laizy/web3/utils/common/hexutil"
 )
 
@@ -26,9 +27,10 @@ func GetArtifacts(artifactDirName ...string) (map[string]*Artifact, error) {
 	if err != nil {
 		return nil, err
 	}
+	buildInfos := make(map[string]*loadedBuildInfo)
 	results := make(map[string]*Artifact)
 	for name, path := range pathes {
-		arti, err := getArtifactWithPath(path)
+		arti, err := getArtifactWithPath(path, buildInfos)
 		if err != nil {
 			return nil, err
 		}
@@ -44,20 +46,25 @@ func GetArtifact(name string, artifactDirName ...string) (*Artifact, error) {
 		return nil, err
 	}
 
-	return getArtifactWithPath(path)
+	return getArtifactWithPath(path, make(map[string]*loadedBuildInfo))
 }
 
+// DecodeArtifact decodes a hardhat or a foundry artifact
 func DecodeArtifact(buf []byte) (*Artifact, error) {
-	type InnerCode struct {
-		Object hexutil.Bytes
-	}
 	type artifact struct {
-		ContractName      string      `json:"contractName"`
-		SourceName        string      `json:"sourceName"`
-		Abi               interface{} `json:"abi"`
-		Bytecode          interface{} `json:"bytecode"`
-		DeployedBytecode  interface{} `json:"deployedBytecode"`
-		DeployedBytecode2 InnerCode   `json:"deployed_bytecode"` //this is more forge compile case
+		ContractName           string                              `json:"contractName"`
+		SourceName             string                              `json:"sourceName"`
+		Abi                    json.RawMessage                     `json:"abi"`
+		Bytecode               json.RawMessage                     `json:"bytecode"`
+		DeployedBytecode       json.RawMessage                     `json:"deployedBytecode"`
+		DeployedBytecode2      json.RawMessage                     `json:"deployed_bytecode"` //this is more forge compile case
+		LinkReferences         compiler.LinkReferences             `json:"linkReferences"`
+		DeployedLinkReferences compiler.LinkReferences             `json:"deployedLinkReferences"`
+		MethodIdentifiers      map[string]string                   `json:"methodIdentifiers"`
+		StorageLayout          *compiler.StorageLayout             `json:"storageLayout"`
+		ImmutableReferences    map[string][]compiler.LinkReference `json:"immutableReferences"`
+		Metadata               json.RawMessage                     `json:"metadata"`
+		RawMetadata            string                              `json:"rawMetadata"`
 	}
 	var value artifact
 	err := json.Unmarshal(buf, &value)
@@ -65,50 +72,199 @@ func DecodeArtifact(buf []byte) (*Artifact, error) {
 		return nil, err
 	}
 
-	_abi := fmt.Sprint(value.Abi)
-	if reflect.TypeOf(value.Abi).Kind() != reflect.String {
-		_abi = utils.JsonStr(value.Abi)
+	_abi, err := decodeAbi(value.Abi)
+	if err != nil {
+		return nil, err
 	}
-
-	_bytecode := fmt.Sprint(value.Bytecode)
-	if reflect.TypeOf(value.Bytecode).Kind() != reflect.String {
-		var innerCode InnerCode
-		err := json.Unmarshal([]byte(utils.JsonStr(value.Bytecode)), &innerCode)
+	res := &Artifact{
+		ContractName:           value.ContractName,
+		SourceName:             value.SourceName,
+		Abi:                    _abi,
+		LinkReferences:         value.LinkReferences,
+		DeployedLinkReferences: value.DeployedLinkReferences,
+		MethodIdentifiers:      value.MethodIdentifiers,
+		StorageLayout:          value.StorageLayout,
+		ImmutableReferences:    value.ImmutableReferences,
+		Metadata:               value.RawMetadata,
+	}
+	if res.Metadata == "" && len(value.Metadata) != 0 {
+		res.Metadata, err = decodeMetadata(value.Metadata)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
-		_bytecode = innerCode.Object.String()
-	}
-	var _deployedByte string
-	if value.DeployedBytecode != nil { //because depolyedBytecode have 2 key&struct, so this interface maybe empty
-		_deployedByte = fmt.Sprint(value.DeployedBytecode)
-		if reflect.TypeOf(value.DeployedBytecode).Kind() != reflect.String {
-			var innerCode InnerCode
-			err := json.Unmarshal([]byte(utils.JsonStr(value.DeployedBytecode)), &innerCode)
-			if err != nil {
-				panic(err)
-			}
-			_deployedByte = innerCode.Object.String()
+	}
+
+	code, err := decodeBytecode(value.Bytecode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytecode: %v", err)
+	}
+	res.Bytecode, res.UnlinkedBytecode = code.linked, code.unlinked
+	res.SourceMap = code.SourceMap
+	if len(code.LinkReferences) != 0 {
+		res.LinkReferences = code.LinkReferences
+	}
+
+	//because depolyedBytecode have 2 key&struct, so this interface maybe empty
+	deployedBytecode := value.DeployedBytecode
+	if len(deployedBytecode) == 0 {
+		deployedBytecode = value.DeployedBytecode2
+	}
+	code, err = decodeBytecode(deployedBytecode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deployed bytecode: %v", err)
+	}
+	res.DeployedBytecode, res.UnlinkedDeployedBytecode = code.linked, code.unlinked
+	res.DeployedSourceMap = code.SourceMap
+	if len(code.LinkReferences) != 0 {
+		res.DeployedLinkReferences = code.LinkReferences
+	}
+	if len(code.ImmutableReferences) != 0 {
+		res.ImmutableReferences = code.ImmutableReferences
+	}
+
+	if res.ContractName == "" || res.SourceName == "" {
+		// forge keeps them in the compilation target of the metadata
+		source, name := compilationTarget(res.Metadata)
+		if res.ContractName == "" {
+			res.ContractName = name
+		}
+		if res.SourceName == "" {
+			res.SourceName = source
 		}
-	} else {
-		_deployedByte = value.DeployedBytecode2.Object.String()
 	}
+	return res, nil
+}
+
+// decodeAbi returns the abi as compact json, some tools store it as a json string
+func decodeAbi(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", fmt.Errorf("abi not found")
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str, nil
+	}
+	var list []interface{}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return "", fmt.Errorf("invalid abi: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// decodeMetadata returns the metadata json, forge stores it as an object
+func decodeMetadata(raw json.RawMessage) (string, error) {
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str, nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return "", fmt.Errorf("invalid metadata: %v", err)
+	}
+	return string(raw), nil
+}
+
+func compilationTarget(metadata string) (string, string) {
+	var value struct {
+		Settings struct {
+			CompilationTarget map[string]string `json:"compilationTarget"`
+		} `json:"settings"`
+	}
+	if err := json.Unmarshal([]byte(metadata), &value); err != nil {
+		return "", ""
+	}
+	for source, name := range value.Settings.CompilationTarget {
+		return source, name
+	}
+	return "", ""
+}
+
+type bytecode struct {
+	Object              string                              `json:"object"`
+	SourceMap           string                              `json:"sourceMap"`
+	LinkReferences      compiler.LinkReferences             `json:"linkReferences"`
+	ImmutableReferences map[string][]compiler.LinkReference `json:"immutableReferences"`
 
-	return &Artifact{
-		ContractName:     value.ContractName,
-		SourceName:       value.SourceName,
-		Abi:              _abi,
-		Bytecode:         hexutil.MustDecode(_bytecode),
-		DeployedBytecode: hexutil.MustDecode(_deployedByte),
-	}, nil
+	linked   hexutil.Bytes
+	unlinked string
 }
 
-func getArtifactWithPath(path string) (*Artifact, error) {
+// decodeBytecode decodes a bytecode string (hardhat) or object (forge), the bytecode with
+// library placeholders is kept as unlinked
+func decodeBytecode(raw json.RawMessage) (*bytecode, error) {
+	code := &bytecode{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return code, nil
+	}
+	if raw[0] == '"' {
+		if err := json.Unmarshal(raw, &code.Object); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(raw, code); err != nil {
+		return nil, err
+	}
+
+	str := code.Object
+	if !strings.HasPrefix(str, "0x") {
+		str = "0x" + str
+	}
+	if strings.Contains(str, "__") {
+		code.unlinked = str
+		return code, nil
+	}
+	data, err := hexutil.Decode(str)
+	if err != nil {
+		return nil, err
+	}
+	code.linked = data
+	return code, nil
+}
+
+// loadedBuildInfo is a build info read for the artifacts, or the error of its reading
+type loadedBuildInfo struct {
+	info *BuildInfo
+	err  error
+}
+
+func getArtifactWithPath(path string, buildInfos map[string]*loadedBuildInfo) (*Artifact, error) {
 	buf, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	return DecodeArtifact(buf)
+	arti, err := DecodeArtifact(buf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid artifact %s: %v", path, err)
+	}
+
+	// the build info only adds the debug fields, the artifact is returned without them
+	// if the build info is missing or stale, ie. removed by a later compilation
+	infoPath, err := buildInfoPath(path)
+	if err != nil {
+		arti.BuildInfoErr = err
+		return arti, nil
+	}
+	if infoPath == "" {
+		return arti, nil
+	}
+	loaded, ok := buildInfos[infoPath]
+	if !ok {
+		// a build info that can not be read is not retried for the other artifacts
+		loaded = &loadedBuildInfo{}
+		loaded.info, loaded.err = readBuildInfo(infoPath)
+		buildInfos[infoPath] = loaded
+	}
+	if loaded.err != nil {
+		arti.BuildInfoErr = loaded.err
+		return arti, nil
+	}
+	if err := arti.withBuildInfo(loaded.info); err != nil {
+		arti.BuildInfoErr = fmt.Errorf("build info %s: %v", infoPath, err)
+	}
+	return arti, nil
 }
 
 func getArtifactPathes(artifactDirName string) (map[string]string, error) {
@@ -123,7 +279,13 @@ func getArtifactPathes(artifactDirName string) (map[string]string, error) {
 	result := make(map[string]string)
 	buildDir := filepath.Join(dir, artifactDirName)
 	err = filepath.Walk(buildDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
 		if info.IsDir() {
+			if info.Name() == "build-info" {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		base := filepath.Base(path)
@@ -161,15 +323,24 @@ func GetArtifactPath(name string, artifactDirName ...string) (string, error) {
 }
 
 func GetProjectRoot() (string, error) {
+	return findRoot("hardhat.config.js", "hardhat.config.ts", "hardhat.config.json")
+}
+
+// GetFoundryRoot returns the closest parent directory with a foundry.toml file
+func GetFoundryRoot() (string, error) {
+	return findRoot("foundry.toml")
+}
+
+func findRoot(markers ...string) (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return "", err
 	}
 	for {
-		if pathExists(filepath.Join(cwd, "hardhat.config.js")) ||
-			pathExists(filepath.Join(cwd, "hardhat.config.ts")) ||
-			pathExists(filepath.Join(cwd, "hardhat.config.json")) {
-			return cwd, nil
+		for _, marker := range markers {
+			if pathExists(filepath.Join(cwd, marker)) {
+				return cwd, nil
+			}
 		}
 		parent := filepath.Dir(cwd)
 		if parent == cwd {
@@ -187,6 +358,57 @@ type Artifact struct {
 	Abi              string        `json:"abi"`
 	Bytecode         hexutil.Bytes `json:"bytecode"`         // 0x6080
 	DeployedBytecode hexutil.Bytes `json:"deployedBytecode"` // 0x6080
+
+	// the bytecode with library placeholders is kept unlinked and Bytecode is empty
+	UnlinkedBytecode         string                  `json:"unlinkedBytecode,omitempty"`
+	UnlinkedDeployedBytecode string                  `json:"unlinkedDeployedBytecode,omitempty"`
+	LinkReferences           compiler.LinkReferences `json:"linkReferences,omitempty"`
+	DeployedLinkReferences   compiler.LinkReferences `json:"deployedLinkReferences,omitempty"`
+
+	// the fields of forge artifacts, or of the build info of hardhat artifacts
+	SourceMap           string                              `json:"sourceMap,omitempty"`
+	DeployedSourceMap   string                              `json:"deployedSourceMap,omitempty"`
+	ImmutableReferences map[string][]compiler.LinkReference `json:"immutableReferences,omitempty"`
+	MethodIdentifiers   map[string]string                   `json:"methodIdentifiers,omitempty"`
+	StorageLayout       *compiler.StorageLayout             `json:"storageLayout,omitempty"`
+	Metadata            string                              `json:"metadata,omitempty"`
+	// Sources are the source files by id, as referenced by the source maps
+	Sources []string `json:"sources,omitempty"`
+
+	// BuildInfo is the compilation of the hardhat artifact, with the sources and settings
+	BuildInfo *BuildInfo `json:"-"`
+	// BuildInfoErr is why the build info of the hardhat artifact is not used, ie. it is
+	// missing or stale, the fields of the build info are empty then
+	BuildInfoErr error `json:"-"`
+}
+
+// Link returns a copy of the artifact with the libraries linked, keyed by fully qualified
+// name ('source:name') or by name
+func (self *Artifact) Link(libraries map[string]web3.Address) (*Artifact, error) {
+	linked := *self
+	if self.UnlinkedBytecode != "" {
+		code, err := compiler.LinkBytecode(self.UnlinkedBytecode, self.LinkReferences, libraries)
+		if err != nil {
+			return nil, err
+		}
+		if linked.Bytecode, err = hexutil.Decode(code); err != nil {
+			return nil, fmt.Errorf("invalid linked bytecode: %v", err)
+		}
+		linked.UnlinkedBytecode = ""
+	}
+	if self.UnlinkedDeployedBytecode != "" {
+		code, err := compiler.LinkBytecode(self.UnlinkedDeployedBytecode, self.DeployedLinkReferences, libraries)
+		if err != nil {
+			return nil, err
+		}
+		if linked.DeployedBytecode, err = hexutil.Decode(code); err != nil {
+			return nil, fmt.Errorf("invalid linked bytecode: %v", err)
+		}
+		linked.UnlinkedDeployedBytecode = ""
+	}
+	linked.LinkReferences = nil
+	linked.DeployedLinkReferences = nil
+	return &linked, nil
 }
 
 func pathExists(path string) bool {
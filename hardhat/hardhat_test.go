@@ -1,6 +1,10 @@
 package hardhat
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/laizy/web3"
+)
 
 var forgeJson = `
 {
@@ -38,3 +42,41 @@ func TestDecodeOld(t *testing.T) {
 		t.Fatalf("decode new err: %v", err)
 	}
 }
+
+var linkedJson = `
+{
+  "_format": "hh-sol-artifact-1",
+  "contractName": "Vault",
+  "sourceName": "contracts/Vault.sol",
+  "abi": [],
+  "bytecode": "0x73__$7f2a1c3b9d4e5f60718293a4b5c6d7e8f9$__6080",
+  "deployedBytecode": "0x6080",
+  "linkReferences": {"contracts/lib/Math.sol": {"Math": [{"length": 20, "start": 1}]}},
+  "deployedLinkReferences": {}
+}
+`
+
+func TestDecodeLinked(t *testing.T) {
+	arti, err := DecodeArtifact([]byte(linkedJson))
+	if err != nil {
+		t.Fatalf("decode linked err: %v", err)
+	}
+	if len(arti.Bytecode) != 0 || arti.UnlinkedBytecode == "" || len(arti.LinkReferences) != 1 {
+		t.Fatalf("unlinked bytecode not kept")
+	}
+	linked, err := arti.Link(map[string]web3.Address{"Math": web3.HexToAddress("0x00000000000000000000000000000000000000aa")})
+	if err != nil {
+		t.Fatalf("link err: %v", err)
+	}
+	if linked.Bytecode.String() != "0x7300000000000000000000000000000000000000aa6080" {
+		t.Fatalf("bad linked bytecode %s", linked.Bytecode)
+	}
+}
+
+func TestLinkMalformed(t *testing.T) {
+	// the code is not hex but has no placeholder to link
+	arti := &Artifact{UnlinkedDeployedBytecode: "0x60zz"}
+	if _, err := arti.Link(nil); err == nil {
+		t.Fatalf("malformed bytecode linked")
+	}
+}
@@ -1,6 +1,7 @@
 package jsonrpc
 
 import (
+	"github.com/laizy/web3"
 	"github.com/laizy/web3/jsonrpc/transport"
 )
 
@@ -10,6 +11,9 @@ type Client struct {
 	endpoints endpoints
 
 	GasLimitFactor func(gasLimit uint64) uint64
+
+	// logParser decodes the logs returned by this client, if nil the global parser is used
+	logParser web3.LogParser
 }
 
 func DefaultGasFactor(i uint64) uint64 {
@@ -44,6 +48,23 @@ func NewClient(addr string) (*Client, error) {
 	return NewClientWithTransport(t), nil
 }
 
+// SetLogParser sets the parser used to decode the events of the logs and
+// receipts returned by this client instead of the global one
+func (c *Client) SetLogParser(p web3.LogParser) {
+	c.logParser = p
+}
+
+// parseLogs decodes the logs with the client parser if there is one
+func (c *Client) parseLogs(logs []*web3.Log) {
+	if c.logParser == nil {
+		return
+	}
+	for _, log := range logs {
+		log.Event = nil
+		log.ParseEventWith(c.logParser)
+	}
+}
+
 // Close closes the tranport
 func (c *Client) Close() error {
 	return c.transport.Close()
@@ -0,0 +1,72 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubTransport answers the calls with the json of the results by method
+type stubTransport map[string]interface{}
+
+func (s stubTransport) Call(method string, out interface{}, params ...interface{}) error {
+	res, ok := s[method]
+	if !ok {
+		return fmt.Errorf("method %s not found", method)
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (s stubTransport) Close() error {
+	return nil
+}
+
+// addressParser names the events by the address of the logs
+type addressParser struct{}
+
+func (addressParser) ParseLog(log *web3.Log) (*web3.ParsedEvent, error) {
+	return &web3.ParsedEvent{Contract: log.Address.String(), Sig: "Custom()"}, nil
+}
+
+func TestClientSetLogParser(t *testing.T) {
+	log := &web3.Log{Address: addr0, Topics: []web3.Hash{{0x1}}}
+	receipt := &web3.Receipt{TransactionHash: web3.Hash{0x2}, Logs: []*web3.Log{log}}
+	c := NewClientWithTransport(stubTransport{
+		"eth_getLogs":               []*web3.Log{log},
+		"eth_getTransactionReceipt": receipt,
+	})
+
+	// the global parser does not know the event
+	logs, err := c.Eth().GetLogs(&web3.LogFilter{})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Nil(t, logs[0].Event)
+
+	c.SetLogParser(addressParser{})
+	expected := &web3.ParsedEvent{Contract: addr0.String(), Sig: "Custom()"}
+
+	logs, err = c.Eth().GetLogs(&web3.LogFilter{})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, expected, logs[0].Event)
+
+	res, err := c.Eth().GetTransactionReceipt(web3.Hash{0x2})
+	require.NoError(t, err)
+	require.Len(t, res.Logs, 1)
+	assert.Equal(t, expected, res.Logs[0].Event)
+
+	// the logs can be parsed again with another parser
+	res.Logs[0].Event = nil
+	res.ParseEvents(&web3.NilParser{})
+	assert.Nil(t, res.Logs[0].Event)
+	res.ParseEvents(addressParser{})
+	assert.Equal(t, expected, res.Logs[0].Event)
+}
@@ -95,6 +95,7 @@ func (e *Eth) GetFilterChanges(id string) ([]*web3.Log, error) {
 	if err := json.Unmarshal([]byte(raw), &res); err != nil {
 		return nil, err
 	}
+	e.c.parseLogs(res)
 	return res, nil
 }
 
@@ -173,6 +174,9 @@ func (e *Eth) SendTransaction(txn *web3.Transaction) (web3.Hash, error) {
 func (e *Eth) GetTransactionReceipt(hash web3.Hash) (*web3.Receipt, error) {
 	var receipt *web3.Receipt
 	err := e.c.Call("eth_getTransactionReceipt", &receipt, hash)
+	if err == nil && receipt != nil {
+		e.c.parseLogs(receipt.Logs)
+	}
 	return receipt, err
 }
 
@@ -270,6 +274,7 @@ func (e *Eth) GetLogs(filter *web3.LogFilter) ([]*web3.Log, error) {
 	if err := e.c.Call("eth_getLogs", &out, filter); err != nil {
 		return nil, err
 	}
+	e.c.parseLogs(out)
 	return out, nil
 }
 
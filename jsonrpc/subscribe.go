@@ -66,6 +66,7 @@ func (c *Client) SubscribeLogs(callback func(log *web3.Log), addresses []web3.Ad
 		if err := log.UnmarshalJSON(b); err != nil {
 			panic(fmt.Errorf("parse head msg error: %v, msg:%s", err, string(b)))
 		}
+		c.parseLogs([]*web3.Log{&log})
 		callback(&log)
 	})
 }
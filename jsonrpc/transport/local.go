@@ -1,19 +1,28 @@
 package transport
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/laizy/web3"
+	"github.com/laizy/web3/crypto"
 	"github.com/laizy/web3/evm/storage"
 	"github.com/laizy/web3/evm/storage/schema"
 	"github.com/laizy/web3/executor"
+	"github.com/laizy/web3/jsonrpc/codec"
 	"github.com/laizy/web3/utils"
 	"github.com/laizy/web3/utils/common/hexutil"
 	"github.com/laizy/web3/utils/common/uint256"
 	"github.com/laizy/web3/wallet"
+	"github.com/valyala/fastjson"
 )
 
 type Local struct {
@@ -23,19 +32,66 @@ type Local struct {
 	BlockHashes map[uint64]web3.Hash
 	Receipts    map[web3.Hash]*web3.Receipt
 	nextId      uint64
+	// Coinbase receives the fees of the mined transactions
+	Coinbase web3.Address
+
+	// every transaction is mined in its own block
+	blocks       map[uint64]*web3.Block
+	transactions map[web3.Hash]*web3.Transaction
+	accounts     []web3.Address
+	lock         sync.Mutex
 }
 
 func NewLocal(db schema.ChainDB, chainID uint64) *Local {
-	return &Local{
-		db:          db,
-		Executor:    executor.NewExecutor(db, chainID),
-		BlockNumber: 0,
-		BlockHashes: make(map[uint64]web3.Hash),
-		Receipts:    make(map[web3.Hash]*web3.Receipt),
-		nextId:      0,
+	local := &Local{
+		db:           db,
+		Executor:     executor.NewExecutor(db, chainID),
+		BlockNumber:  0,
+		BlockHashes:  make(map[uint64]web3.Hash),
+		Receipts:     make(map[web3.Hash]*web3.Receipt),
+		nextId:       0,
+		blocks:       make(map[uint64]*web3.Block),
+		transactions: make(map[web3.Hash]*web3.Transaction),
+	}
+	local.addBlock(&web3.Block{
+		Header: web3.Header{Difficulty: big.NewInt(0), GasLimit: blockGasLimit},
+		Hash:   crypto.Keccak256Hash([]byte("genesis")),
+	})
+	return local
+}
+
+// NewSimulated returns an in memory chain that funds the accounts of alloc and mines a
+// block for each transaction, it is meant to run contracts in unit tests with
+// jsonrpc.NewClientWithTransport. The funded accounts are returned by eth_accounts.
+func NewSimulated(chainID uint64, alloc map[web3.Address]*big.Int) *Local {
+	db := &simulatedDB{FakeDB: storage.NewFakeDB()}
+	local := NewLocal(db, chainID)
+	db.local = local
+	for addr, amount := range alloc {
+		local.SetBalance(addr, amount)
+		local.accounts = append(local.accounts, addr)
 	}
+	sort.Slice(local.accounts, func(i, j int) bool {
+		return bytes.Compare(local.accounts[i][:], local.accounts[j][:]) < 0
+	})
+	return local
 }
 
+// simulatedDB returns the hashes of the blocks mined by the local transport
+type simulatedDB struct {
+	*storage.FakeDB
+	local *Local
+}
+
+func (self *simulatedDB) GetBlockHash(height uint64) web3.Hash {
+	return self.local.BlockHashes[height]
+}
+
+const blockGasLimit = 30000000
+
+// gasPrice is the price returned by eth_gasPrice, 20 gwei
+const gasPrice = 20000000000
+
 // Close implements the transport interface
 func (self *Local) Close() error {
 	return nil
@@ -64,6 +120,9 @@ func (self *Local) nextID() uint64 {
 
 // Call implements the transport interface
 func (self *Local) Call(method string, out interface{}, params ...interface{}) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
 	var result []byte
 	switch method {
 	case "eth_getCode":
@@ -74,8 +133,60 @@ func (self *Local) Call(method string, out interface{}, params ...interface{}) e
 			return err
 		}
 		result = utils.JsonBytes(val.Code)
+	case "eth_getBalance":
+		addr := params[0].(web3.Address)
+		result = utils.JsonBytes((*hexutil.Big)(self.GetBalance(addr)))
+	case "eth_getStorageAt":
+		addr := params[0].(web3.Address)
+		slot, err := storageSlot(params[1].(string))
+		if err != nil {
+			return err
+		}
+		statedb := storage.NewStateDB(storage.NewCacheDB(self.Executor.OverlayDB))
+		result = utils.JsonBytes(statedb.GetState(addr, slot).String())
+	case "eth_accounts":
+		accounts := self.accounts
+		if accounts == nil {
+			accounts = []web3.Address{}
+		}
+		result = utils.JsonBytes(accounts)
+	case "eth_chainId":
+		result = utils.JsonBytes(hexutil.Uint64(self.Executor.ChainID))
+	case "net_version":
+		result = utils.JsonBytes(strconv.FormatUint(self.Executor.ChainID, 10))
 	case "eth_blockNumber":
 		result = utils.JsonBytes(hexutil.Uint64(self.BlockNumber))
+	case "eth_getBlockByNumber":
+		num, err := self.blockNumberParam(params[0])
+		if err != nil {
+			return err
+		}
+		result = self.marshalBlock(self.blocks[num], fullParam(params))
+	case "eth_getBlockByHash":
+		hash := params[0].(web3.Hash)
+		var block *web3.Block
+		for _, b := range self.blocks {
+			if b.Hash == hash {
+				block = b
+				break
+			}
+		}
+		result = self.marshalBlock(block, fullParam(params))
+	case "eth_getTransactionByHash":
+		hash := params[0].(web3.Hash)
+		txn := self.transactions[hash]
+		if txn == nil {
+			result = []byte("null")
+		} else {
+			result = marshalTransaction(txn).MarshalTo(nil)
+		}
+	case "eth_getLogs":
+		filter := params[0].(*web3.LogFilter)
+		logs, err := self.filterLogs(filter)
+		if err != nil {
+			return err
+		}
+		result = utils.JsonBytes(logs)
 	case "eth_call":
 		msg := params[0].(*web3.CallMsg)
 		// blockNum := params[0].(string)
@@ -93,33 +204,24 @@ func (self *Local) Call(method string, out interface{}, params ...interface{}) e
 		result = utils.JsonBytes(hexutil.Uint64(res.UsedGas))
 	case "eth_sendTransaction":
 		txn := params[0].(*web3.Transaction)
-		_, receipt, err := self.Executor.ExecuteTransaction(txn, executor.Eip155Context{
-			BlockHash: web3.Hash{},
-			Height:    self.BlockNumber,
-		})
-		if err != nil {
+		if err := self.mine(txn); err != nil {
 			return err
 		}
-		self.Receipts[txn.Hash()] = receipt
-		self.BlockNumber += 1
 		result = utils.JsonBytes(txn.Hash().String())
 	case "eth_sendRawTransaction":
 		rawTx := params[0].(string)
 		txn, err := web3.TransactionFromRlp(web3.Hex2Bytes(rawTx[2:]))
-		utils.Ensure(err)
+		if err != nil {
+			return err
+		}
 		sender, err := wallet.NewEIP155Signer(self.Executor.ChainID).RecoverSender(txn)
-		utils.Ensure(err)
-		txn.From = sender
-		_, receipt, err := self.Executor.ExecuteTransaction(txn, executor.Eip155Context{
-			BlockHash: web3.Hash{},
-			Height:    self.BlockNumber,
-			Timestamp: self.BlockNumber * 12,
-		})
 		if err != nil {
 			return err
 		}
-		self.Receipts[txn.Hash()] = receipt
-		self.BlockNumber += 1
+		txn.From = sender
+		if err := self.mine(txn); err != nil {
+			return err
+		}
 		result = utils.JsonBytes(txn.Hash().String())
 	case "eth_getTransactionCount":
 		addr := params[0].(web3.Address)
@@ -130,30 +232,240 @@ func (self *Local) Call(method string, out interface{}, params ...interface{}) e
 		}
 		result = utils.JsonBytes(hexutil.Uint64(val.Nonce))
 	case "eth_gasPrice":
-		result = utils.JsonBytes(hexutil.Uint64(web3.Gwei(20).Uint64()))
+		result = utils.JsonBytes(hexutil.Uint64(gasPrice))
 	case "eth_getTransactionReceipt":
 		hash := params[0].(web3.Hash)
 		receipt := self.Receipts[hash]
 		result = utils.JsonBytes(receipt)
 
 	default:
-		panic(fmt.Errorf("unimplemented method: %s", method))
+		return fmt.Errorf("method %s is not supported by the local transport", method)
 	}
 
 	return json.Unmarshal(result, out)
 }
 
+// mine executes the transaction in a new block
+func (self *Local) mine(txn *web3.Transaction) error {
+	height := self.BlockNumber + 1
+	parent := self.BlockHashes[self.BlockNumber]
+	hash := crypto.Keccak256Hash(parent[:], new(big.Int).SetUint64(height).Bytes(), txn.Hash().Bytes())
+	_, receipt, err := self.Executor.ExecuteTransaction(txn, executor.Eip155Context{
+		BlockHash: hash,
+		Height:    height,
+		Timestamp: height * 12,
+		Coinbase:  self.Coinbase,
+	})
+	if err != nil {
+		return err
+	}
+
+	txn.BlockHash = hash
+	txn.BlockNumber = height
+	txn.TxnIndex = 0
+	self.Receipts[txn.Hash()] = receipt
+	self.transactions[txn.Hash()] = txn
+	self.addBlock(&web3.Block{
+		Header: web3.Header{
+			ParentHash: parent,
+			Miner:      self.Coinbase,
+			Difficulty: big.NewInt(0),
+			Number:     height,
+			GasLimit:   blockGasLimit,
+			GasUsed:    receipt.GasUsed,
+			Timestamp:  height * 12,
+		},
+		Hash:               hash,
+		Transactions:       []*web3.Transaction{txn},
+		TransactionsHashes: []web3.Hash{txn.Hash()},
+	})
+	return nil
+}
+
+func (self *Local) addBlock(block *web3.Block) {
+	self.blocks[block.Number] = block
+	self.BlockHashes[block.Number] = block.Hash
+	self.BlockNumber = block.Number
+}
+
+func (self *Local) marshalBlock(block *web3.Block, full bool) []byte {
+	if block == nil {
+		return []byte("null")
+	}
+	hashes := *block
+	hashes.Transactions = nil
+	if !full {
+		return utils.JsonBytes(&hashes)
+	}
+	val := fastjson.MustParseBytes(utils.JsonBytes(&hashes))
+	txns := fastjson.MustParse("[]")
+	for i, txn := range block.Transactions {
+		txns.SetArrayItem(i, marshalTransaction(txn))
+	}
+	val.Set("transactions", txns)
+	return val.MarshalTo(nil)
+}
+
+// marshalTransaction returns the transaction as returned by the nodes, with the
+// fields omitted by the marshaller of the sent transactions
+func marshalTransaction(txn *web3.Transaction) *fastjson.Value {
+	val := fastjson.MustParseBytes(utils.JsonBytes(txn))
+	val.Set("nonce", fastjson.MustParse(utils.JsonString(hexutil.Uint64(txn.Nonce))))
+	val.Set("input", fastjson.MustParse(utils.JsonString(hexutil.Bytes(txn.Input))))
+	if txn.Value == nil {
+		val.Set("value", fastjson.MustParse(`"0x0"`))
+	}
+	return val
+}
+
+// storageSlot parses the slot of eth_getStorageAt, either a quantity or a 32 bytes hash
+func storageSlot(param string) (web3.Hash, error) {
+	str := strings.TrimPrefix(param, "0x")
+	if len(str)%2 == 1 {
+		str = "0" + str
+	}
+	buf, err := hex.DecodeString(str)
+	if err != nil || len(buf) > 32 {
+		return web3.Hash{}, fmt.Errorf("invalid storage slot %s", param)
+	}
+	return web3.BytesToHash(buf), nil
+}
+
+func fullParam(params []interface{}) bool {
+	if len(params) < 2 {
+		return false
+	}
+	full, _ := params[1].(bool)
+	return full
+}
+
+func (self *Local) blockNumberParam(param interface{}) (uint64, error) {
+	var num web3.BlockNumber
+	switch p := param.(type) {
+	case web3.BlockNumber:
+		num = p
+	case string:
+		switch p {
+		case "latest", "pending":
+			num = web3.Latest
+		case "earliest":
+			num = web3.Earliest
+		default:
+			n, err := hexutil.DecodeUint64(p)
+			if err != nil {
+				return 0, err
+			}
+			return n, nil
+		}
+	default:
+		return 0, fmt.Errorf("invalid block number %v", param)
+	}
+	return self.resolveBlockNumber(num), nil
+}
+
+func (self *Local) resolveBlockNumber(num web3.BlockNumber) uint64 {
+	switch {
+	case num == web3.Earliest:
+		return 0
+	case num < 0:
+		return self.BlockNumber
+	}
+	return uint64(num)
+}
+
+// filterLogs returns the logs of the mined transactions that match the filter
+func (self *Local) filterLogs(filter *web3.LogFilter) ([]*web3.Log, error) {
+	from, to := uint64(0), self.BlockNumber
+	if filter.BlockHash != nil {
+		found := false
+		for num, hash := range self.BlockHashes {
+			if hash == *filter.BlockHash {
+				from, to, found = num, num, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown block %s", filter.BlockHash)
+		}
+	} else {
+		if filter.From != nil {
+			from = self.resolveBlockNumber(*filter.From)
+		}
+		if filter.To != nil {
+			to = self.resolveBlockNumber(*filter.To)
+		}
+	}
+
+	logs := []*web3.Log{}
+	for num := from; num <= to && num <= self.BlockNumber; num++ {
+		block := self.blocks[num]
+		if block == nil {
+			continue
+		}
+		for _, hash := range block.TransactionsHashes {
+			for _, log := range self.Receipts[hash].Logs {
+				if matchLog(log, filter) {
+					logs = append(logs, log)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+func matchLog(log *web3.Log, filter *web3.LogFilter) bool {
+	if len(filter.Address) != 0 {
+		found := false
+		for _, addr := range filter.Address {
+			if log.Address == addr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for i, options := range filter.Topics {
+		if len(options) == 0 {
+			continue
+		}
+		if i >= len(log.Topics) {
+			return false
+		}
+		found := false
+		for _, topic := range options {
+			if log.Topics[i] == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 func (self *Local) CallEvm(msg *web3.CallMsg) (*web3.ExecutionResult, error) {
 	res, _, err := self.Executor.Call(CallMsg{msg}, executor.Eip155Context{
-		BlockHash: web3.Hash{},
+		BlockHash: self.BlockHashes[self.BlockNumber],
 		Height:    self.BlockNumber,
 		Timestamp: self.BlockNumber * 12,
+		Coinbase:  self.Coinbase,
 	})
 	if err != nil {
 		return nil, err
 	}
 	if res.Failed() {
-		return nil, fmt.Errorf(res.RevertReason)
+		// same as the geth error so that the callers can decode the revert data
+		obj := &codec.ErrorObject{Code: -32000, Message: res.Err.Error()}
+		if len(res.Revert()) != 0 {
+			obj.Code = 3
+			obj.Message = "execution reverted: " + res.RevertReason
+			obj.Data = hexutil.Encode(res.Revert())
+		}
+		return nil, obj
 	}
 
 	return res, nil
@@ -0,0 +1,147 @@
+package transport_test
+
+import (
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/evm"
+	"github.com/laizy/web3/jsonrpc/codec"
+	"github.com/laizy/web3/jsonrpc/transport"
+	"github.com/laizy/web3/jsonrpc/transport/transporttest"
+	"github.com/laizy/web3/utils/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+// storeCode stores the first word of the input in the slot 0 and emits it as the topic
+// of a log, it reverts with 0xdead if the word is zero
+const storeCode = "600035" + "8060005580" + // x := calldataload(0); sstore(0, x)
+	"15601257" + // if iszero(x) jump to revert
+	"60006000a100" + // log1(0, 0, x); stop
+	"5b61dead600052" + "6002601efd" // revert: mstore(0, 0xdead); revert(30, 2)
+
+func newStoreEnv(t *testing.T) (*transport.Local, web3.Address) {
+	local := transporttest.NewDevSimulated()
+	addr, err := transporttest.DeployCode(local, transporttest.DevAccount, web3.Hex2Bytes(storeCode))
+	require.NoError(t, err)
+	return local, addr
+}
+
+// store mines a transaction that stores the value and returns its block
+func store(t *testing.T, local *transport.Local, addr web3.Address, value byte) *web3.Block {
+	var nonce hexutil.Uint64
+	require.NoError(t, local.Call("eth_getTransactionCount", &nonce, transporttest.DevAccount, "latest"))
+	txn := &web3.Transaction{
+		From:     transporttest.DevAccount,
+		To:       &addr,
+		Input:    web3.Hash{31: value}.Bytes(),
+		Gas:      100000,
+		GasPrice: 1,
+		Nonce:    uint64(nonce),
+	}
+	var hash web3.Hash
+	require.NoError(t, local.Call("eth_sendTransaction", &hash, txn))
+	var block web3.Block
+	require.NoError(t, local.Call("eth_getBlockByNumber", &block, "latest", false))
+	require.Equal(t, []web3.Hash{hash}, block.TransactionsHashes)
+	return &block
+}
+
+func TestLocal_GetLogs(t *testing.T) {
+	local, addr := newStoreEnv(t)
+	first := store(t, local, addr, 1)
+	second := store(t, local, addr, 2)
+	store(t, local, addr, 3)
+
+	getLogs := func(filter *web3.LogFilter) []web3.Hash {
+		var logs []*web3.Log
+		require.NoError(t, local.Call("eth_getLogs", &logs, filter))
+		topics := []web3.Hash{}
+		for _, log := range logs {
+			require.Equal(t, addr, log.Address)
+			topics = append(topics, log.Topics[0])
+		}
+		return topics
+	}
+	num := func(block *web3.Block) *web3.BlockNumber {
+		n := web3.BlockNumber(block.Number)
+		return &n
+	}
+
+	require.Equal(t, []web3.Hash{{31: 1}, {31: 2}, {31: 3}}, getLogs(&web3.LogFilter{}))
+	require.Equal(t, []web3.Hash{{31: 2}, {31: 3}}, getLogs(&web3.LogFilter{From: num(second)}))
+	require.Equal(t, []web3.Hash{{31: 1}, {31: 2}}, getLogs(&web3.LogFilter{From: num(first), To: num(second)}))
+	require.Equal(t, []web3.Hash{{31: 2}}, getLogs(&web3.LogFilter{BlockHash: &second.Hash}))
+	require.Equal(t, []web3.Hash{{31: 3}}, getLogs(&web3.LogFilter{Topics: [][]web3.Hash{{{31: 3}}}}))
+	require.Equal(t, []web3.Hash{}, getLogs(&web3.LogFilter{Address: []web3.Address{transporttest.DevAccount}}))
+
+	var logs []*web3.Log
+	require.Error(t, local.Call("eth_getLogs", &logs, &web3.LogFilter{BlockHash: &web3.Hash{0x1}}))
+}
+
+func TestLocal_GetStorageAt(t *testing.T) {
+	local, addr := newStoreEnv(t)
+
+	var value web3.Hash
+	require.NoError(t, local.Call("eth_getStorageAt", &value, addr, "0x0", "latest"))
+	require.Equal(t, web3.Hash{}, value)
+
+	store(t, local, addr, 7)
+	require.NoError(t, local.Call("eth_getStorageAt", &value, addr, "0x0", "latest"))
+	require.Equal(t, web3.Hash{31: 7}, value)
+	// the slot may also be a full hash
+	require.NoError(t, local.Call("eth_getStorageAt", &value, addr, web3.Hash{}.String(), "latest"))
+	require.Equal(t, web3.Hash{31: 7}, value)
+	require.NoError(t, local.Call("eth_getStorageAt", &value, addr, "0x1", "latest"))
+	require.Equal(t, web3.Hash{}, value)
+
+	require.Error(t, local.Call("eth_getStorageAt", &value, addr, "0xzz", "latest"))
+}
+
+func TestLocal_CallRevert(t *testing.T) {
+	local, addr := newStoreEnv(t)
+
+	var out hexutil.Bytes
+	err := local.Call("eth_call", &out, &web3.CallMsg{From: transporttest.DevAccount, To: &addr, Data: web3.Hash{}.Bytes()}, "latest")
+	obj, ok := err.(*codec.ErrorObject)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, 3, obj.Code)
+	require.Contains(t, obj.Message, "execution reverted")
+	require.Equal(t, "0xdead", obj.Data)
+
+	// a failure without revert data
+	invalid, err := transporttest.DeployCode(local, transporttest.DevAccount, []byte{0xfe})
+	require.NoError(t, err)
+	err = local.Call("eth_call", &out, &web3.CallMsg{From: transporttest.DevAccount, To: &invalid}, "latest")
+	obj, ok = err.(*codec.ErrorObject)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, -32000, obj.Code)
+	require.Empty(t, obj.Data)
+
+	// the estimation fails the same way
+	var gas hexutil.Uint64
+	err = local.Call("eth_estimateGas", &gas, &web3.CallMsg{From: transporttest.DevAccount, To: &addr, Data: web3.Hash{}.Bytes()})
+	require.IsType(t, &codec.ErrorObject{}, err)
+
+	require.NoError(t, local.Call("eth_call", &out, &web3.CallMsg{From: transporttest.DevAccount, To: &addr, Data: web3.Hash{31: 1}.Bytes()}, "latest"))
+	require.Empty(t, out)
+}
+
+func TestLocal_Tracers(t *testing.T) {
+	local, addr := newStoreEnv(t)
+	txTracer, callTracer := evm.NewFourByteTracer(), evm.NewFourByteTracer()
+	local.Executor.Tracer, local.Executor.CallTracer = txTracer, callTracer
+
+	var out hexutil.Bytes
+	require.NoError(t, local.Call("eth_call", &out, &web3.CallMsg{From: transporttest.DevAccount, To: &addr, Data: web3.Hash{31: 1}.Bytes()}, "latest"))
+	var gas hexutil.Uint64
+	require.NoError(t, local.Call("eth_estimateGas", &gas, &web3.CallMsg{From: transporttest.DevAccount, To: &addr, Data: web3.Hash{31: 2}.Bytes()}))
+	store(t, local, addr, 3)
+
+	// the calls are only seen by the call tracer
+	res, err := txTracer.GetResult()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"0x00000000-28": 1}`, string(res))
+	res, err = callTracer.GetResult()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"0x00000000-28": 2}`, string(res))
+}
@@ -0,0 +1,59 @@
+// Package transporttest provides a simulated chain to run hand written code in the tests
+package transporttest
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/jsonrpc/transport"
+	"github.com/laizy/web3/utils/common/hexutil"
+)
+
+// DevAccount is the account funded with 100 ether by NewDevSimulated
+var DevAccount = web3.HexToAddress("0x1000000000000000000000000000000000000001")
+
+// NewDevSimulated returns a simulated chain where DevAccount is funded, with chain id 1
+func NewDevSimulated() *transport.Local {
+	return transport.NewSimulated(1, map[web3.Address]*big.Int{DevAccount: web3.Ether(100)})
+}
+
+// InitCode returns the creation code of a contract without constructor, it deploys the
+// runtime code as is
+func InitCode(runtime []byte) []byte {
+	// PUSH2 len DUP1 PUSH1 12 PUSH1 0 CODECOPY PUSH1 0 RETURN
+	code := []byte{0x61, byte(len(runtime) >> 8), byte(len(runtime)), 0x80, 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3}
+	return append(code, runtime...)
+}
+
+// DeployCode mines the creation of a contract with the runtime code from the account and
+// returns its address
+func DeployCode(local *transport.Local, from web3.Address, runtime []byte) (web3.Address, error) {
+	var nonce hexutil.Uint64
+	if err := local.Call("eth_getTransactionCount", &nonce, from, "latest"); err != nil {
+		return web3.Address{}, err
+	}
+	var price hexutil.Uint64
+	if err := local.Call("eth_gasPrice", &price); err != nil {
+		return web3.Address{}, err
+	}
+	txn := &web3.Transaction{
+		From:     from,
+		Input:    InitCode(runtime),
+		Gas:      30000000,
+		GasPrice: uint64(price),
+		Nonce:    uint64(nonce),
+	}
+	var hash web3.Hash
+	if err := local.Call("eth_sendTransaction", &hash, txn); err != nil {
+		return web3.Address{}, err
+	}
+	var receipt web3.Receipt
+	if err := local.Call("eth_getTransactionReceipt", &receipt, hash); err != nil {
+		return web3.Address{}, err
+	}
+	if receipt.Status != 1 {
+		return web3.Address{}, fmt.Errorf("deployment of %s failed", hash)
+	}
+	return receipt.ContractAddress, nil
+}
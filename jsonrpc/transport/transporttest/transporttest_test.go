@@ -0,0 +1,27 @@
+package transporttest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/utils/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployCode(t *testing.T) {
+	local := NewDevSimulated()
+
+	// the length of the runtime does not fit in a PUSH1
+	runtime := append(bytes.Repeat([]byte{0x5b}, 300), 0x00)
+	addr, err := DeployCode(local, DevAccount, runtime)
+	require.NoError(t, err)
+
+	var code hexutil.Bytes
+	require.NoError(t, local.Call("eth_getCode", &code, addr, web3.Latest))
+	require.Equal(t, hexutil.Bytes(runtime), code)
+
+	// the deployment fails without funds
+	_, err = DeployCode(local, web3.Address{0x1}, runtime)
+	require.Error(t, err)
+}
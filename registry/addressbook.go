@@ -0,0 +1,288 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"gopkg.in/yaml.v3"
+)
+
+// AddressBook is the list of well known contracts of a network. It is loaded from
+// json or yaml files like:
+//
+//	chainId: 1
+//	contracts:
+//	  - name: USDT
+//	    address: "0xdac17f958d2ee523a2206206994597c13d831ec7"
+//	    abi: abis/erc20.json
+//	    tags: [token, stablecoin]
+//
+// The abi is either inline (json abi or a list of human readable signatures)
+// or a path to a json abi file relative to the address book file.
+type AddressBook struct {
+	ChainId   web3.Network        `json:"chainId"`
+	Contracts []*AddressBookEntry `json:"contracts"`
+}
+
+type AddressBookEntry struct {
+	Name    string          `json:"name"`
+	Address web3.Address    `json:"address"`
+	Tags    []string        `json:"tags,omitempty"`
+	RawAbi  json.RawMessage `json:"abi,omitempty"`
+
+	// Abi is resolved from RawAbi when the address book is loaded
+	Abi *abi.ABI `json:"-"`
+}
+
+// HasTag returns whether the contract is tagged with tag
+func (self *AddressBookEntry) HasTag(tag string) bool {
+	for _, t := range self.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadAddressBook loads an address book from a json or yaml file
+func LoadAddressBook(path string) (*AddressBook, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("failed to parse address book %s: %v", path, err)
+		}
+	case ".json":
+	default:
+		return nil, fmt.Errorf("unknown address book format '%s'", path)
+	}
+	book, err := ParseAddressBook(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse address book %s: %v", path, err)
+	}
+	return book, nil
+}
+
+// ParseAddressBook parses a json address book, the abi references are relative to baseDir
+func ParseAddressBook(data []byte, baseDir string) (*AddressBook, error) {
+	book := &AddressBook{}
+	if err := json.Unmarshal(data, book); err != nil {
+		return nil, err
+	}
+	for _, entry := range book.Contracts {
+		if len(entry.RawAbi) == 0 {
+			continue
+		}
+		contractAbi, err := resolveAbi(entry.RawAbi, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("contract %s: %v", entry.Name, err)
+		}
+		entry.Abi = contractAbi
+	}
+	return book, nil
+}
+
+func resolveAbi(raw json.RawMessage, baseDir string) (*abi.ABI, error) {
+	var path string
+	if err := json.Unmarshal(raw, &path); err == nil {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return abi.NewABIFromReader(file)
+	}
+
+	var human []string
+	if err := json.Unmarshal(raw, &human); err == nil {
+		return abi.NewABIFromList(human)
+	}
+	return abi.NewABI(string(raw))
+}
+
+// Lookup returns the contract with the address or nil
+func (self *AddressBook) Lookup(addr web3.Address) *AddressBookEntry {
+	for _, entry := range self.Contracts {
+		if entry.Address == addr {
+			return entry
+		}
+	}
+	return nil
+}
+
+// ByName returns the contract with the name or nil
+func (self *AddressBook) ByName(name string) *AddressBookEntry {
+	for _, entry := range self.Contracts {
+		if entry.Name == name {
+			return entry
+		}
+	}
+	return nil
+}
+
+// ByTag returns the contracts tagged with tag
+func (self *AddressBook) ByTag(tag string) []*AddressBookEntry {
+	var res []*AddressBookEntry
+	for _, entry := range self.Contracts {
+		if entry.HasTag(tag) {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
+
+// RegisterTo registers the contract names as aliases and the abis of the contracts
+// in the registries, any of them may be nil
+func (self *AddressBook) RegisterTo(events *EventRegistry, methods *MethodRegistry, errors *ErrorRegistry) {
+	for _, entry := range self.Contracts {
+		if events != nil {
+			if entry.Name != "" {
+				events.RegisterContractAlias(entry.Address, entry.Name)
+			}
+			if entry.Abi != nil {
+				events.RegisterFromAbi(entry.Abi)
+				events.BindContract(entry.Address, entry.Abi)
+			}
+		}
+		if entry.Abi == nil {
+			continue
+		}
+		if methods != nil {
+			methods.RegisterFromAbi(entry.Abi)
+		}
+		if errors != nil {
+			errors.RegisterFromAbi(entry.Abi)
+		}
+	}
+}
+
+// AddressBooks holds the address books by network
+type AddressBooks map[web3.Network]*AddressBook
+
+// LoadAddressBooks loads all the json and yaml address books in the directory,
+// the books of the same network are merged
+func LoadAddressBooks(dir string) (AddressBooks, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	books := AddressBooks{}
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		if file.IsDir() {
+			continue
+		}
+		book, err := LoadAddressBook(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		books.Add(book)
+	}
+	return books, nil
+}
+
+// Add merges the address book with the one of the same network
+func (self AddressBooks) Add(book *AddressBook) {
+	if old, ok := self[book.ChainId]; ok {
+		old.Contracts = append(old.Contracts, book.Contracts...)
+		return
+	}
+	self[book.ChainId] = book
+}
+
+// RegisterTo registers the address book of the network in the registries
+func (self AddressBooks) RegisterTo(network web3.Network, events *EventRegistry, methods *MethodRegistry, errors *ErrorRegistry) error {
+	book, ok := self[network]
+	if !ok {
+		return fmt.Errorf("no address book for network %d", network)
+	}
+	book.RegisterTo(events, methods, errors)
+	return nil
+}
+
+// yamlToJSON converts a yaml document into json. Hex numbers are kept as strings
+// since yaml would decode short addresses like 0x01 as integers.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	val, err := yamlNodeToValue(&node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(val)
+}
+
+func yamlNodeToValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeToValue(node.Content[0])
+
+	case yaml.AliasNode:
+		return yamlNodeToValue(node.Alias)
+
+	case yaml.SequenceNode:
+		res := make([]interface{}, 0, len(node.Content))
+		for _, elem := range node.Content {
+			val, err := yamlNodeToValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, val)
+		}
+		return res, nil
+
+	case yaml.MappingNode:
+		res := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			val, err := yamlNodeToValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			res[node.Content[i].Value] = val
+		}
+		return res, nil
+
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int":
+			if strings.HasPrefix(node.Value, "0x") || strings.HasPrefix(node.Value, "0X") {
+				return node.Value, nil
+			}
+			return json.Number(node.Value), nil
+		case "!!float":
+			return json.Number(node.Value), nil
+		case "!!bool":
+			var b bool
+			if err := node.Decode(&b); err != nil {
+				return nil, err
+			}
+			return b, nil
+		case "!!null":
+			return nil, nil
+		default:
+			return node.Value, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown yaml node at line %d", node.Line)
+}
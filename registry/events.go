@@ -12,7 +12,10 @@ import (
 )
 
 type EventRegistry struct {
-	events        map[web3.Hash]*abi.Event
+	// events holds the candidate events for each topic, i.e. the erc20 and erc721
+	// Transfer events share the topic but differ in the number of indexed arguments
+	events        map[web3.Hash][]*abi.Event
+	bindings      map[web3.Address]map[web3.Hash]*abi.Event
 	contractNames map[web3.Address]string
 	lock          sync.RWMutex
 }
@@ -34,19 +37,25 @@ func (self *EventRegistry) Register(e *abi.Event) {
 	self.lock.Lock()
 	defer self.lock.Unlock()
 	if len(self.events) == 0 {
-		self.events = map[web3.Hash]*abi.Event{}
+		self.events = map[web3.Hash][]*abi.Event{}
 	}
-	if event := self.events[e.ID()]; event != nil {
+	for _, event := range self.events[e.ID()] {
 		utils.EnsureTrue(event.Name == e.Name)
-		return
+		if indexedLayout(event) == indexedLayout(e) {
+			return
+		}
 	}
-	self.events[e.ID()] = e
+	self.events[e.ID()] = append(self.events[e.ID()], e)
 }
 
 func (self *EventRegistry) RegisterFromAbi(abi *abi.ABI) {
 	for _, e := range abi.Events {
 		self.Register(e)
 	}
+	// the overloaded events are only kept by signature
+	for _, e := range abi.EventsBySig {
+		self.Register(e)
+	}
 }
 
 func (self *EventRegistry) RegisterFromHumanString(eventStr string) {
@@ -54,35 +63,142 @@ func (self *EventRegistry) RegisterFromHumanString(eventStr string) {
 	self.Register(e)
 }
 
+// BindContract binds the abi to the contract address, the logs emitted by the contract
+// are decoded with its events before falling back to the registered ones
+func (self *EventRegistry) BindContract(c web3.Address, contractAbi *abi.ABI) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if len(self.bindings) == 0 {
+		self.bindings = map[web3.Address]map[web3.Hash]*abi.Event{}
+	}
+	events := map[web3.Hash]*abi.Event{}
+	for _, e := range contractAbi.Events {
+		if !e.Anonymous {
+			events[e.ID()] = e
+		}
+	}
+	for _, e := range contractAbi.EventsBySig {
+		if !e.Anonymous {
+			events[e.ID()] = e
+		}
+	}
+	self.bindings[c] = events
+}
+
 func (self *EventRegistry) ParseLog(log *web3.Log) (*web3.ParsedEvent, error) {
+	_, parsed, err := self.parseLog(log)
+	return parsed, err
+}
+
+// parseLog returns the parsed log with the event that decodes it
+func (self *EventRegistry) parseLog(log *web3.Log) (*abi.Event, *web3.ParsedEvent, error) {
 	if len(log.Topics) == 0 {
-		return nil, errors.New("no topic found")
+		return nil, nil, errors.New("no topic found")
 	}
-	e := self.GetEvent(log.Topics[0])
-	if e == nil {
-		return nil, fmt.Errorf("can not parse log with sig: %s", log.Topics[0].String())
+	candidates := self.candidates(log)
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("can not parse log with sig: %s", log.Topics[0].String())
 	}
-	val, err := abi.ParseLog(e.Inputs, log)
-	if err != nil {
-		return nil, err
+
+	// prefer the candidates that decode the log canonically
+	var e *abi.Event
+	var val map[string]interface{}
+	for _, candidate := range candidates {
+		if res, err := abi.ParseLogStrict(candidate.Inputs, log); err == nil {
+			e, val = candidate, res
+			break
+		}
+	}
+	if e == nil {
+		var err error
+		for _, candidate := range candidates {
+			if val, err = abi.ParseLog(candidate.Inputs, log); err == nil {
+				e = candidate
+				break
+			}
+		}
+		if e == nil {
+			return nil, nil, err
+		}
 	}
 	sig := e.DetailedSig()
 	addr := log.Address.String()
-	if name := self.contractNames[log.Address]; name != "" {
+	if name := self.ContractAlias(log.Address); name != "" {
 		addr = name
 	}
-	return &web3.ParsedEvent{
+	return e, &web3.ParsedEvent{
 		Contract: addr,
 		Sig:      sig,
 		Values:   val,
 	}, nil
 }
 
+// candidates returns the events that match the topic and the number of topics of the log,
+// the event bound to the log address goes first
+func (self *EventRegistry) candidates(log *web3.Log) []*abi.Event {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	var res []*abi.Event
+	if e := self.bindings[log.Address][log.Topics[0]]; e != nil && numIndexed(e) == len(log.Topics)-1 {
+		res = append(res, e)
+	}
+	for _, e := range self.events[log.Topics[0]] {
+		if numIndexed(e) == len(log.Topics)-1 {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// ContractAlias returns the alias registered for the contract or an empty string
+func (self *EventRegistry) ContractAlias(c web3.Address) string {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	return self.contractNames[c]
+}
+
+// GetEvent returns the first event registered with the topic
 func (self *EventRegistry) GetEvent(id web3.Hash) *abi.Event {
 	self.lock.RLock()
 	defer self.lock.RUnlock()
 
-	return self.events[id]
+	if events := self.events[id]; len(events) != 0 {
+		return events[0]
+	}
+	return nil
+}
+
+// GetEvents returns all the events registered with the topic
+func (self *EventRegistry) GetEvents(id web3.Hash) []*abi.Event {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	return append([]*abi.Event{}, self.events[id]...)
+}
+
+func numIndexed(e *abi.Event) int {
+	num := 0
+	for _, elem := range e.Inputs.TupleElems() {
+		if elem.Indexed {
+			num++
+		}
+	}
+	return num
+}
+
+// indexedLayout returns the signature with the indexed arguments marked
+func indexedLayout(e *abi.Event) string {
+	layout := e.Sig()
+	for _, elem := range e.Inputs.TupleElems() {
+		if elem.Indexed {
+			layout += "i"
+		} else {
+			layout += "-"
+		}
+	}
+	return layout
 }
 
 func (self *EventRegistry) DumpLog(log *web3.Log) string {
@@ -6,6 +6,7 @@ import (
 
 var eventRegistry = NewEventRegistry()
 var errorRegister = NewErrorRegistry()
+var methodRegistry = NewMethodRegistry()
 
 func Instance() *EventRegistry {
 	return eventRegistry
@@ -15,6 +16,10 @@ func ErrInstance() *ErrorRegistry {
 	return errorRegister
 }
 
+func MethodInstance() *MethodRegistry {
+	return methodRegistry
+}
+
 func init() {
 	eventRegistry.RegisterPresetMainnet()
 	web3.RegisterParser(eventRegistry)
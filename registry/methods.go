@@ -0,0 +1,321 @@
+package registry
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/utils"
+)
+
+// methodEntry is a candidate method for a selector, signatures imported from
+// 4byte dumps have no argument names and lower priority than the ones from abis
+type methodEntry struct {
+	method   *abi.Method
+	imported bool
+}
+
+type MethodRegistry struct {
+	methods map[[4]byte][]*methodEntry
+	lock    sync.RWMutex
+}
+
+// ParsedCall is the transaction input decoded with a registered method
+type ParsedCall struct {
+	Method *abi.Method `json:"-"`
+	Sig    string
+	Args   map[string]interface{}
+	// Alternatives are the signatures of the other methods that also decode the input
+	Alternatives []string `json:",omitempty"`
+}
+
+func NewMethodRegistry() *MethodRegistry {
+	return &MethodRegistry{}
+}
+
+func (self *MethodRegistry) Register(m *abi.Method) {
+	self.register(m, false)
+}
+
+func (self *MethodRegistry) register(m *abi.Method, imported bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if len(self.methods) == 0 {
+		self.methods = map[[4]byte][]*methodEntry{}
+	}
+	var id [4]byte
+	copy(id[:], m.ID())
+	entries := self.methods[id]
+	for i, entry := range entries {
+		if entry.method.Sig() == m.Sig() {
+			// a method from an abi replaces an imported signature
+			if entry.imported && !imported {
+				entries[i] = &methodEntry{method: m}
+				sortEntries(entries)
+			}
+			return
+		}
+	}
+	entries = append(entries, &methodEntry{method: m, imported: imported})
+	sortEntries(entries)
+	self.methods[id] = entries
+}
+
+func sortEntries(entries []*methodEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return !entries[i].imported && entries[j].imported
+	})
+}
+
+func (self *MethodRegistry) RegisterFromAbi(abi *abi.ABI) {
+	for _, m := range abi.Methods {
+		self.Register(m)
+	}
+	// the overloaded methods are only kept by signature
+	for _, m := range abi.MethodsBySig {
+		self.Register(m)
+	}
+}
+
+// RegisterFromSignature registers a method from its signature, i.e. 'transfer(address,uint256)'
+func (self *MethodRegistry) RegisterFromSignature(sig string) error {
+	m, err := abi.NewMethod(sig)
+	if err != nil {
+		return err
+	}
+	self.Register(m)
+	return nil
+}
+
+func (self *MethodRegistry) RegisterFromHumanString(methodStr string) {
+	self.Register(abi.MustNewMethod(methodStr))
+}
+
+// GetMethods returns the registered methods with the given selector
+func (self *MethodRegistry) GetMethods(id [4]byte) []*abi.Method {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	var res []*abi.Method
+	for _, entry := range self.methods[id] {
+		res = append(res, entry.method)
+	}
+	return res
+}
+
+// ImportFile imports a 4byte signature dump, the format is picked from the file extension (.json or .csv)
+func (self *MethodRegistry) ImportFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return self.ImportJSON(file)
+	case ".csv":
+		return self.ImportCSV(file)
+	default:
+		return 0, fmt.Errorf("unknown signature dump format '%s'", path)
+	}
+}
+
+// ImportJSON imports signatures from a json dump. Supported formats are the 4byte.directory
+// api response ({"results": [{"hex_signature": .., "text_signature": ..}]}), a map from
+// selector to a signature or a list of signatures, and a plain list of signatures.
+// It returns the number of signatures imported, the unparseable ones are skipped.
+func (self *MethodRegistry) ImportJSON(r io.Reader) (int, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return 0, err
+	}
+
+	var sigs [][2]string
+	var list []string
+	var api struct {
+		Results []struct {
+			HexSignature  string `json:"hex_signature"`
+			TextSignature string `json:"text_signature"`
+		} `json:"results"`
+	}
+	var dict map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, sig := range list {
+			sigs = append(sigs, [2]string{"", sig})
+		}
+	} else if err := json.Unmarshal(raw, &dict); err != nil {
+		return 0, fmt.Errorf("unknown signature dump format: %v", err)
+	} else if _, ok := dict["results"]; ok {
+		if err := json.Unmarshal(raw, &api); err != nil {
+			return 0, err
+		}
+		for _, res := range api.Results {
+			sigs = append(sigs, [2]string{res.HexSignature, res.TextSignature})
+		}
+	} else {
+		selectors := make([]string, 0, len(dict))
+		for selector := range dict {
+			selectors = append(selectors, selector)
+		}
+		sort.Strings(selectors)
+		for _, selector := range selectors {
+			val := dict[selector]
+			var sig string
+			if err := json.Unmarshal(val, &sig); err == nil {
+				sigs = append(sigs, [2]string{selector, sig})
+				continue
+			}
+			if err := json.Unmarshal(val, &list); err != nil {
+				return 0, fmt.Errorf("invalid signatures for selector %s: %v", selector, err)
+			}
+			for _, sig := range list {
+				sigs = append(sigs, [2]string{selector, sig})
+			}
+		}
+	}
+	return self.importSignatures(sigs), nil
+}
+
+// ImportCSV imports signatures from a csv dump with either 'selector,signature'
+// or 'signature' rows, the signature may be unquoted. Rows without a signature
+// (like the header) are skipped.
+func (self *MethodRegistry) ImportCSV(r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var sigs [][2]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		for i, field := range record {
+			if !strings.Contains(field, "(") {
+				continue
+			}
+			selector := ""
+			if i > 0 {
+				selector = record[i-1]
+			}
+			sigs = append(sigs, [2]string{selector, strings.Join(record[i:], ",")})
+			break
+		}
+	}
+	return self.importSignatures(sigs), nil
+}
+
+// importSignatures registers the (selector, signature) pairs, the selector is optional
+// and if present it has to match the signature
+func (self *MethodRegistry) importSignatures(sigs [][2]string) int {
+	count := 0
+	for _, pair := range sigs {
+		m, err := abi.NewMethod(strings.TrimSpace(pair[1]))
+		if err != nil {
+			continue
+		}
+		if selector := strings.TrimSpace(pair[0]); selector != "" {
+			id, err := hex.DecodeString(strings.TrimPrefix(selector, "0x"))
+			if err != nil || hex.EncodeToString(id) != hex.EncodeToString(m.ID()) {
+				continue
+			}
+		}
+		self.register(m, true)
+		count++
+	}
+	return count
+}
+
+// ParseInput decodes the transaction input with the registered methods. Only the
+// methods whose canonical encoding matches the input are considered. When more than
+// one method decodes the input, the one from an abi or the first registered is
+// returned and the others are listed as alternatives.
+func (self *MethodRegistry) ParseInput(input []byte) (*ParsedCall, error) {
+	calls, err := self.ParseInputAll(input)
+	if err != nil {
+		return nil, err
+	}
+	call := calls[0]
+	for _, alt := range calls[1:] {
+		call.Alternatives = append(call.Alternatives, alt.Sig)
+	}
+	return call, nil
+}
+
+// ParseInputAll returns all the registered methods that decode the input
+func (self *MethodRegistry) ParseInputAll(input []byte) ([]*ParsedCall, error) {
+	if len(input) < 4 {
+		return nil, fmt.Errorf("short input")
+	}
+	var id [4]byte
+	copy(id[:], input[:4])
+
+	methods := self.GetMethods(id)
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("can not parse input with sig: %x", id)
+	}
+
+	var calls []*ParsedCall
+	var errs []string
+	for _, m := range methods {
+		args, err := decodeMethodInput(m, input[4:])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", m.Sig(), err))
+			continue
+		}
+		calls = append(calls, &ParsedCall{Method: m, Sig: methodSig(m), Args: args})
+	}
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("can not decode input with sig %x: %s", id, strings.Join(errs, "; "))
+	}
+	return calls, nil
+}
+
+// methodSig returns the signature with the argument names if the method has them
+func methodSig(m *abi.Method) string {
+	for _, elem := range m.Inputs.TupleElems() {
+		if elem.Name != "" {
+			return m.DetailedSig()
+		}
+	}
+	return m.Sig()
+}
+
+func decodeMethodInput(m *abi.Method, data []byte) (map[string]interface{}, error) {
+	if len(m.Inputs.TupleElems()) == 0 {
+		if len(data) != 0 {
+			return nil, fmt.Errorf("%d trailing bytes", len(data))
+		}
+		return map[string]interface{}{}, nil
+	}
+	val, err := abi.DecodeStrict(m.Inputs, data)
+	if err != nil {
+		return nil, err
+	}
+	return val.(map[string]interface{}), nil
+}
+
+func (self *MethodRegistry) DumpInput(input []byte) string {
+	decoded, err := self.ParseInput(input)
+	if err != nil {
+		return err.Error()
+	}
+
+	buf, err := json.MarshalIndent(decoded, "", "  ")
+	utils.Ensure(err)
+
+	return string(buf)
+}
@@ -0,0 +1,423 @@
+package registry
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+)
+
+type ReportFormat string
+
+const (
+	FormatText     ReportFormat = "text"
+	FormatMarkdown ReportFormat = "markdown"
+	FormatJSON     ReportFormat = "json"
+)
+
+// Reporter builds human readable reports of transactions with the registries
+type Reporter struct {
+	Events  *EventRegistry
+	Methods *MethodRegistry
+	Errors  *ErrorRegistry
+}
+
+// NewReporter creates a reporter with the global registries
+func NewReporter() *Reporter {
+	return &Reporter{Events: Instance(), Methods: MethodInstance(), Errors: ErrInstance()}
+}
+
+// Report is the decoded summary of a transaction and its receipt
+type Report struct {
+	TxHash          web3.Hash
+	Status          string
+	BlockNumber     uint64
+	From            string
+	To              string `json:",omitempty"`
+	ContractCreated string `json:",omitempty"`
+	Value           string
+	GasLimit        uint64
+	GasUsed         uint64
+	GasPrice        uint64
+	Nonce           uint64
+	Call            *ReportCall    `json:",omitempty"`
+	Revert          string         `json:",omitempty"`
+	Events          []*ReportEvent `json:",omitempty"`
+}
+
+// ReportCall is the decoded calldata, Args keep the order of the method inputs
+type ReportCall struct {
+	Sig          string
+	Selector     string
+	Args         []*ReportArg `json:",omitempty"`
+	Alternatives []string     `json:",omitempty"`
+	Error        string       `json:",omitempty"`
+}
+
+type ReportArg struct {
+	Name  string
+	Value string
+}
+
+// ReportEvent is a decoded log, the raw topics and data are kept if the log can not be decoded
+type ReportEvent struct {
+	Index    uint64
+	Contract string
+	Sig      string       `json:",omitempty"`
+	Args     []*ReportArg `json:",omitempty"`
+	Topics   []web3.Hash  `json:",omitempty"`
+	Data     string       `json:",omitempty"`
+}
+
+// Build decodes the transaction and its receipt. The receipt does not carry the revert
+// data, the caller may provide it (i.e. from replaying the transaction with eth_call).
+// Without a receipt, i.e. for a pending transaction, only the transaction is decoded.
+func (self *Reporter) Build(txn *web3.Transaction, receipt *web3.Receipt, revertData []byte) *Report {
+	report := &Report{Status: "pending"}
+	if receipt != nil {
+		report.TxHash = receipt.TransactionHash
+		report.BlockNumber = receipt.BlockNumber
+		report.From = self.addressName(receipt.From)
+		report.GasUsed = receipt.GasUsed
+		report.Status = "success"
+		if receipt.IsReverted() {
+			report.Status = "reverted"
+		}
+		if receipt.ContractAddress != (web3.Address{}) {
+			report.ContractCreated = self.addressName(receipt.ContractAddress)
+		}
+	}
+
+	value := big.NewInt(0)
+	if txn != nil {
+		if receipt == nil {
+			report.TxHash = txn.Hash()
+			report.From = self.addressName(txn.From)
+		}
+		if txn.To != nil {
+			report.To = self.addressName(*txn.To)
+		}
+		if txn.Value != nil {
+			value = txn.Value
+		}
+		report.GasLimit = txn.Gas
+		report.GasPrice = txn.GasPrice
+		report.Nonce = txn.Nonce
+		if txn.To != nil && len(txn.Input) != 0 {
+			report.Call = self.decodeCall(txn.Input)
+		}
+	}
+	report.Value = formatEther(value)
+
+	if len(revertData) != 0 {
+		report.Revert = self.decodeRevert(revertData)
+	}
+
+	if receipt != nil {
+		for _, log := range receipt.Logs {
+			report.Events = append(report.Events, self.decodeLog(log))
+		}
+	}
+	return report
+}
+
+func (self *Reporter) addressName(addr web3.Address) string {
+	if self.Events != nil {
+		if name := self.Events.ContractAlias(addr); name != "" {
+			return name + " (" + addr.String() + ")"
+		}
+	}
+	return addr.String()
+}
+
+func (self *Reporter) decodeCall(input []byte) *ReportCall {
+	call := &ReportCall{}
+	if len(input) >= 4 {
+		call.Selector = "0x" + hex.EncodeToString(input[:4])
+	}
+	if self.Methods == nil {
+		call.Error = "no method registry"
+		return call
+	}
+	parsed, err := self.Methods.ParseInput(input)
+	if err != nil {
+		call.Error = err.Error()
+		return call
+	}
+	call.Sig = parsed.Sig
+	call.Alternatives = parsed.Alternatives
+	for i, elem := range parsed.Method.Inputs.TupleElems() {
+		name := elem.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		call.Args = append(call.Args, &ReportArg{Name: name, Value: self.formatValue(parsed.Args[abi.NameToKey(elem.Name, i)])})
+	}
+	return call
+}
+
+func (self *Reporter) decodeRevert(data []byte) string {
+	if self.Errors != nil {
+		if reason, err := self.Errors.ParseError(data); err == nil {
+			return reason
+		}
+	}
+	return "0x" + hex.EncodeToString(data)
+}
+
+func (self *Reporter) decodeLog(log *web3.Log) *ReportEvent {
+	event := &ReportEvent{Index: log.LogIndex, Contract: self.addressName(log.Address)}
+	parsed := log.Event
+	var e *abi.Event
+	if self.Events != nil {
+		if res, p, err := self.Events.parseLog(log); err == nil {
+			e, parsed = res, p
+		}
+	}
+	if e == nil && parsed != nil {
+		// the logs parsed beforehand only have the signature of their event
+		e, _ = abi.NewEvent(parsed.Sig)
+	}
+	if parsed == nil {
+		event.Topics = log.Topics
+		event.Data = "0x" + hex.EncodeToString(log.Data)
+		return event
+	}
+	event.Sig = parsed.Sig
+	for _, k := range eventKeys(e, parsed.Values) {
+		event.Args = append(event.Args, &ReportArg{Name: k, Value: self.formatValue(parsed.Values[k])})
+	}
+	return event
+}
+
+// eventKeys returns the keys of the event values in the order of the event inputs, the
+// keys that are not in the event go last in alphabetical order
+func eventKeys(e *abi.Event, values map[string]interface{}) []string {
+	var keys []string
+	seen := map[string]bool{}
+	if e != nil {
+		for i, elem := range e.Inputs.TupleElems() {
+			// the values are keyed as abi.ParseLog does
+			key := abi.ToName(elem.Name, i)
+			if _, ok := values[key]; ok && !seen[key] {
+				keys = append(keys, key)
+				seen[key] = true
+			}
+		}
+	}
+	var rest []string
+	for k := range values {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+func (self *Reporter) formatValue(val interface{}) string {
+	switch obj := val.(type) {
+	case nil:
+		return "<nil>"
+	case web3.Address:
+		return self.addressName(obj)
+	case *big.Int:
+		return obj.String()
+	case *big.Rat:
+		return obj.RatString()
+	case []byte:
+		return "0x" + hex.EncodeToString(obj)
+	case string:
+		return fmt.Sprintf("%q", obj)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fields := make([]string, len(keys))
+		for i, k := range keys {
+			fields[i] = k + ": " + self.formatValue(obj[k])
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			buf := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(buf), v)
+			return "0x" + hex.EncodeToString(buf)
+		}
+		fallthrough
+	case reflect.Slice:
+		elems := make([]string, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elems[i] = self.formatValue(v.Index(i).Interface())
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// formatEther formats an amount of wei in ether
+func formatEther(wei *big.Int) string {
+	ether := new(big.Rat).SetFrac(wei, new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+	str := strings.TrimRight(strings.TrimRight(ether.FloatString(18), "0"), ".")
+	return str + " ETH"
+}
+
+// Render writes the report in the given format
+func (self *Report) Render(w io.Writer, format ReportFormat) error {
+	switch format {
+	case FormatText:
+		_, err := io.WriteString(w, self.Text())
+		return err
+	case FormatMarkdown:
+		_, err := io.WriteString(w, self.Markdown())
+		return err
+	case FormatJSON:
+		buf, err := json.MarshalIndent(self, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(buf, '\n'))
+		return err
+	default:
+		return fmt.Errorf("unknown report format '%s'", format)
+	}
+}
+
+func (self *Report) gasSummary() string {
+	if self.GasLimit == 0 {
+		return fmt.Sprintf("%d", self.GasUsed)
+	}
+	return fmt.Sprintf("%d / %d (%.2f%%)", self.GasUsed, self.GasLimit, float64(self.GasUsed)*100/float64(self.GasLimit))
+}
+
+// Text renders the report as plain text
+func (self *Report) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Transaction %s\n", self.TxHash.String())
+	fmt.Fprintf(&b, "  Status:    %s\n", self.Status)
+	fmt.Fprintf(&b, "  Block:     %d\n", self.BlockNumber)
+	fmt.Fprintf(&b, "  From:      %s\n", self.From)
+	if self.To != "" {
+		fmt.Fprintf(&b, "  To:        %s\n", self.To)
+	}
+	if self.ContractCreated != "" {
+		fmt.Fprintf(&b, "  Created:   %s\n", self.ContractCreated)
+	}
+	fmt.Fprintf(&b, "  Value:     %s\n", self.Value)
+	fmt.Fprintf(&b, "  Gas used:  %s\n", self.gasSummary())
+	if self.GasPrice != 0 {
+		fmt.Fprintf(&b, "  Gas price: %d\n", self.GasPrice)
+	}
+	if self.Call != nil {
+		if self.Call.Sig != "" {
+			fmt.Fprintf(&b, "  Call:      %s\n", self.Call.Sig)
+		} else {
+			fmt.Fprintf(&b, "  Call:      %s (%s)\n", self.Call.Selector, self.Call.Error)
+		}
+		for _, arg := range self.Call.Args {
+			fmt.Fprintf(&b, "    %s: %s\n", arg.Name, arg.Value)
+		}
+		for _, alt := range self.Call.Alternatives {
+			fmt.Fprintf(&b, "    also matches: %s\n", alt)
+		}
+	}
+	if self.Revert != "" {
+		fmt.Fprintf(&b, "  Revert:    %s\n", self.Revert)
+	}
+	if len(self.Events) != 0 {
+		fmt.Fprintf(&b, "Events:\n")
+	}
+	for _, e := range self.Events {
+		if e.Sig == "" {
+			fmt.Fprintf(&b, "  [%d] %s <unknown>\n", e.Index, e.Contract)
+			for i, topic := range e.Topics {
+				fmt.Fprintf(&b, "    topic%d: %s\n", i, topic.String())
+			}
+			fmt.Fprintf(&b, "    data: %s\n", e.Data)
+			continue
+		}
+		fmt.Fprintf(&b, "  [%d] %s %s\n", e.Index, e.Contract, e.Sig)
+		for _, arg := range e.Args {
+			fmt.Fprintf(&b, "    %s: %s\n", arg.Name, arg.Value)
+		}
+	}
+	return b.String()
+}
+
+// Markdown renders the report as markdown
+func (self *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Transaction `%s`\n\n", self.TxHash.String())
+	fmt.Fprintf(&b, "| Field | Value |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Status | %s |\n", self.Status)
+	fmt.Fprintf(&b, "| Block | %d |\n", self.BlockNumber)
+	fmt.Fprintf(&b, "| From | `%s` |\n", self.From)
+	if self.To != "" {
+		fmt.Fprintf(&b, "| To | `%s` |\n", self.To)
+	}
+	if self.ContractCreated != "" {
+		fmt.Fprintf(&b, "| Created | `%s` |\n", self.ContractCreated)
+	}
+	fmt.Fprintf(&b, "| Value | %s |\n", self.Value)
+	fmt.Fprintf(&b, "| Gas used | %s |\n", self.gasSummary())
+	if self.GasPrice != 0 {
+		fmt.Fprintf(&b, "| Gas price | %d |\n", self.GasPrice)
+	}
+	if self.Revert != "" {
+		fmt.Fprintf(&b, "| Revert | `%s` |\n", escapeMarkdown(self.Revert))
+	}
+
+	if self.Call != nil {
+		fmt.Fprintf(&b, "\n#### Call\n\n")
+		if self.Call.Sig != "" {
+			fmt.Fprintf(&b, "`%s`\n", self.Call.Sig)
+		} else {
+			fmt.Fprintf(&b, "`%s` (%s)\n", self.Call.Selector, escapeMarkdown(self.Call.Error))
+		}
+		if len(self.Call.Args) != 0 {
+			fmt.Fprintf(&b, "\n| Argument | Value |\n|---|---|\n")
+			for _, arg := range self.Call.Args {
+				fmt.Fprintf(&b, "| %s | `%s` |\n", arg.Name, escapeMarkdown(arg.Value))
+			}
+		}
+		for _, alt := range self.Call.Alternatives {
+			fmt.Fprintf(&b, "\nAlso matches `%s`\n", alt)
+		}
+	}
+
+	if len(self.Events) != 0 {
+		fmt.Fprintf(&b, "\n#### Events\n")
+	}
+	for _, e := range self.Events {
+		if e.Sig == "" {
+			fmt.Fprintf(&b, "\n%d. `%s` unknown event\n", e.Index, e.Contract)
+			for i, topic := range e.Topics {
+				fmt.Fprintf(&b, "    - topic%d: `%s`\n", i, topic.String())
+			}
+			fmt.Fprintf(&b, "    - data: `%s`\n", e.Data)
+			continue
+		}
+		fmt.Fprintf(&b, "\n%d. `%s` `%s`\n", e.Index, e.Contract, e.Sig)
+		for _, arg := range e.Args {
+			fmt.Fprintf(&b, "    - %s: `%s`\n", arg.Name, escapeMarkdown(arg.Value))
+		}
+	}
+	return b.String()
+}
+
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", "\\|", "`", "'", "\n", " ").Replace(s)
+}
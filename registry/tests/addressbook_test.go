@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	registry2 "github.com/laizy/web3/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressBooks(t *testing.T) {
+	books, err := registry2.LoadAddressBooks("testdata")
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+
+	goerli := books[web3.Goerli]
+	require.NotNil(t, goerli)
+	token := goerli.ByName("TestToken")
+	require.NotNil(t, token)
+	assert.Equal(t, web3.Address{19: 1}, token.Address)
+	assert.NotNil(t, token.Abi.Methods["transfer"])
+	assert.Len(t, goerli.ByTag("token"), 1)
+	assert.Equal(t, "Vault", goerli.Lookup(web3.Address{19: 2}).Name)
+	assert.NotNil(t, goerli.Lookup(web3.Address{19: 2}).Abi.Events["Deposit"])
+
+	assert.Equal(t, "DAI", books[web3.Mainnet].Contracts[0].Name)
+
+	events := registry2.NewEventRegistry()
+	methods := registry2.NewMethodRegistry()
+	require.NoError(t, books.RegisterTo(web3.Goerli, events, methods, nil))
+	assert.Error(t, books.RegisterTo(web3.Rinkeby, events, methods, nil))
+
+	deposit := abi.MustNewEvent("event Deposit(address indexed user, uint256 amount)")
+	data, err := abi.Encode([]interface{}{big.NewInt(3)}, abi.MustNewType("tuple(uint256)"))
+	require.NoError(t, err)
+	parsed, err := events.ParseLog(&web3.Log{
+		Address: web3.Address{19: 2},
+		Topics:  []web3.Hash{deposit.ID(), {31: 1}},
+		Data:    data,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Vault", parsed.Contract)
+
+	call, err := methods.ParseInput(token.Abi.Methods["transfer"].MustEncodeIDAndInput(web3.Address{1}, big.NewInt(1)))
+	require.NoError(t, err)
+	assert.Equal(t, "transfer(address to, uint256 amount)", call.Sig)
+}
+
+func TestPresetMainnet(t *testing.T) {
+	events := registry2.NewEventRegistry()
+	events.RegisterPresetMainnet()
+	assert.Equal(t, "USDT", events.ContractAlias(web3.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")))
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	registry2 "github.com/laizy/web3/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRegistry_TransferCollision(t *testing.T) {
+	erc20 := abi.MustNewEvent("event Transfer(address indexed from, address indexed to, uint256 value)")
+	erc721 := abi.MustNewEvent("event Transfer(address indexed from, address indexed to, uint256 indexed tokenId)")
+	assert.Equal(t, erc20.ID(), erc721.ID())
+
+	registry := registry2.NewEventRegistry()
+	registry.Register(erc20)
+	registry.Register(erc721)
+	registry.Register(erc20)
+	assert.Len(t, registry.GetEvents(erc20.ID()), 2)
+
+	from, to := web3.Address{1}, web3.Address{2}
+	value, err := abi.Encode([]interface{}{big.NewInt(100)}, abi.MustNewType("tuple(uint256)"))
+	require.NoError(t, err)
+
+	log20 := &web3.Log{
+		Address: web3.Address{0xaa},
+		Topics:  []web3.Hash{erc20.ID(), web3.BytesToHash(from[:]), web3.BytesToHash(to[:])},
+		Data:    value,
+	}
+	parsed, err := registry.ParseLog(log20)
+	require.NoError(t, err)
+	assert.Equal(t, erc20.DetailedSig(), parsed.Sig)
+	assert.Equal(t, big.NewInt(100), parsed.Values["value"])
+
+	log721 := &web3.Log{
+		Address: web3.Address{0xbb},
+		Topics:  []web3.Hash{erc721.ID(), web3.BytesToHash(from[:]), web3.BytesToHash(to[:]), web3.BytesToHash([]byte{7})},
+	}
+	parsed, err = registry.ParseLog(log721)
+	require.NoError(t, err)
+	assert.Equal(t, erc721.DetailedSig(), parsed.Sig)
+	assert.Equal(t, big.NewInt(7), parsed.Values["tokenId"])
+
+	// the abi bound to the address takes precedence
+	bound, err := abi.NewABIFromList([]string{
+		"event Transfer(address indexed sender, address indexed receiver, uint256 amount)",
+	})
+	require.NoError(t, err)
+	registry.BindContract(web3.Address{0xaa}, bound)
+	parsed, err = registry.ParseLog(log20)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), parsed.Values["amount"])
+
+	// per call parser
+	log20.ParseEventWith(registry)
+	assert.Equal(t, big.NewInt(100), log20.Event.Values["amount"])
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	registry2 "github.com/laizy/web3/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodRegistry_ParseInput(t *testing.T) {
+	registry := registry2.NewMethodRegistry()
+	registry.RegisterFromAbi(abi.MustNewABI(`[{
+		"name": "transfer",
+		"type": "function",
+		"inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "uint256"}],
+		"outputs": [{"name": "", "type": "bool"}]
+	}]`))
+
+	// colliding selectors from the 4byte database
+	n, err := registry.ImportJSON(strings.NewReader(`{
+		"0xa9059cbb": ["transfer(address,uint256)", "many_msg_babbage(bytes1)", "join_tg_invmru_haha_fd06787(address,bool)"],
+		"0x095ea7b3": "sign_szabo_bytecode(bytes16,uint128)",
+		"0x12345678": "balanceOf(address)"
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, 4, n) // balanceOf does not match the selector
+
+	transfer := abi.MustNewMethod("transfer(address,uint256)")
+	assert.Len(t, registry.GetMethods([4]byte{0xa9, 0x05, 0x9c, 0xbb}), 3)
+
+	// amount 1 is also a valid boolean
+	call, err := registry.ParseInput(transfer.MustEncodeIDAndInput(web3.Address{19: 1}, big.NewInt(1)))
+	require.NoError(t, err)
+	assert.Equal(t, "transfer(address to, uint256 amount)", call.Sig)
+	assert.Equal(t, big.NewInt(1), call.Args["amount"])
+	assert.Equal(t, []string{"join_tg_invmru_haha_fd06787(address,bool)"}, call.Alternatives)
+
+	call, err = registry.ParseInput(transfer.MustEncodeIDAndInput(web3.Address{19: 1}, big.NewInt(100)))
+	require.NoError(t, err)
+	assert.Empty(t, call.Alternatives)
+
+	calls, err := registry.ParseInputAll(transfer.MustEncodeIDAndInput(web3.Address{19: 1}, big.NewInt(1)))
+	require.NoError(t, err)
+	assert.Len(t, calls, 2)
+
+	// approve is not registered and the input is not a valid bytes16
+	approve := abi.MustNewMethod("approve(address,uint256)")
+	_, err = registry.ParseInput(approve.MustEncodeIDAndInput(web3.Address{19: 1}, big.NewInt(1)))
+	assert.Error(t, err)
+
+	_, err = registry.ParseInput([]byte{0x1, 0x2})
+	assert.Error(t, err)
+}
+
+func TestMethodRegistry_RegisterOverloads(t *testing.T) {
+	registry := registry2.NewMethodRegistry()
+	registry.RegisterFromAbi(abi.MustNewABI(`[
+		{"name": "burn", "type": "function", "inputs": [{"name": "amount", "type": "uint256"}]},
+		{"name": "burn", "type": "function", "inputs": [{"name": "from", "type": "address"}]}
+	]`))
+
+	for _, sig := range []string{"burn(uint256)", "burn(address)"} {
+		var id [4]byte
+		copy(id[:], abi.MustNewMethod(sig).ID())
+		methods := registry.GetMethods(id)
+		require.Len(t, methods, 1, sig)
+		assert.Equal(t, sig, methods[0].Sig())
+	}
+
+	burn := abi.MustNewMethod("burn(address)")
+	call, err := registry.ParseInput(burn.MustEncodeIDAndInput(web3.Address{19: 1}))
+	require.NoError(t, err)
+	assert.Equal(t, "burn(address from)", call.Sig)
+}
+
+func TestMethodRegistry_ImportFile(t *testing.T) {
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "sigs.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("selector,signature\n0x70a08231,balanceOf(address)\nallowance(address,address)\nbad(\n"), 0644))
+
+	jsonPath := filepath.Join(dir, "sigs.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"results": [{"hex_signature": "0x18160ddd", "text_signature": "totalSupply()"}]}`), 0644))
+
+	registry := registry2.NewMethodRegistry()
+	n, err := registry.ImportFile(csvPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = registry.ImportFile(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	call, err := registry.ParseInput([]byte{0x18, 0x16, 0x0d, 0xdd})
+	require.NoError(t, err)
+	assert.Equal(t, "totalSupply()", call.Sig)
+
+	call, err = registry.ParseInput(abi.MustNewMethod("balanceOf(address)").MustEncodeIDAndInput(web3.Address{1}))
+	require.NoError(t, err)
+	assert.Equal(t, web3.Address{1}, call.Args["0"])
+
+	_, err = registry.ImportFile(filepath.Join(dir, "sigs.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	registry2 "github.com/laizy/web3/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReporter(t *testing.T) {
+	usdt := web3.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")
+	from, to := web3.Address{0x1}, web3.Address{0x2}
+
+	events := registry2.NewEventRegistry()
+	events.RegisterPresetMainnet()
+	events.RegisterFromHumanString("event Transfer(address indexed from, address indexed to, uint256 value)")
+	methods := registry2.NewMethodRegistry()
+	methods.RegisterFromHumanString("function transfer(address to, uint256 amount)")
+	reporter := &registry2.Reporter{Events: events, Methods: methods, Errors: registry2.NewErrorRegistry()}
+
+	transfer := abi.MustNewMethod("function transfer(address to, uint256 amount)")
+	event := abi.MustNewEvent("event Transfer(address indexed from, address indexed to, uint256 value)")
+	data, err := abi.Encode([]interface{}{big.NewInt(5)}, abi.MustNewType("tuple(uint256)"))
+	require.NoError(t, err)
+
+	txn := &web3.Transaction{
+		From:  from,
+		To:    &usdt,
+		Input: transfer.MustEncodeIDAndInput(to, big.NewInt(5)),
+		Gas:   100000,
+		Value: big.NewInt(1500000000000000000),
+	}
+	receipt := &web3.Receipt{
+		Status:      0,
+		BlockNumber: 10,
+		From:        from,
+		GasUsed:     25000,
+		Logs: []*web3.Log{
+			{Address: usdt, Topics: []web3.Hash{event.ID(), web3.BytesToHash(from[:]), web3.BytesToHash(to[:])}, Data: data},
+			{LogIndex: 1, Address: to, Topics: []web3.Hash{{0x1}}},
+		},
+	}
+	revert, err := abi.DefaultError()[0].EncodeIDAndInput("not enough balance")
+	require.NoError(t, err)
+
+	report := reporter.Build(txn, receipt, revert)
+	assert.Equal(t, "reverted", report.Status)
+	assert.Equal(t, "1.5 ETH", report.Value)
+	assert.Equal(t, "USDT ("+usdt.String()+")", report.To)
+	assert.Equal(t, "Error(not enough balance)", report.Revert)
+	assert.Equal(t, "transfer(address to, uint256 amount)", report.Call.Sig)
+	assert.Equal(t, "5", report.Call.Args[1].Value)
+	require.Len(t, report.Events, 2)
+	assert.Equal(t, "5", report.Events[0].Args[2].Value)
+	assert.Empty(t, report.Events[1].Sig)
+
+	text := report.Text()
+	assert.Contains(t, text, "Gas used:  25000 / 100000 (25.00%)")
+	assert.Contains(t, text, "[0] USDT ("+usdt.String()+") Transfer(")
+	assert.Contains(t, text, "[1] "+to.String()+" <unknown>")
+
+	assert.True(t, strings.HasPrefix(report.Markdown(), "### Transaction"))
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Render(&buf, registry2.FormatJSON))
+	var decoded registry2.Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report, &decoded)
+
+	assert.Error(t, report.Render(&buf, "html"))
+}
+
+func TestReporter_EventArgsOrder(t *testing.T) {
+	events := registry2.NewEventRegistry()
+	// the underscores are trimmed and the unnamed args are named by position
+	events.RegisterFromHumanString("event Deposit(address indexed _user, uint256 _amount, uint256)")
+	reporter := &registry2.Reporter{Events: events}
+
+	event := abi.MustNewEvent("event Deposit(address indexed _user, uint256 _amount, uint256)")
+	data, err := abi.Encode([]interface{}{big.NewInt(5), big.NewInt(1)}, abi.MustNewType("tuple(uint256,uint256)"))
+	require.NoError(t, err)
+	receipt := &web3.Receipt{
+		Status: 1,
+		Logs:   []*web3.Log{{Topics: []web3.Hash{event.ID(), web3.BytesToHash([]byte{0x1})}, Data: data}},
+	}
+
+	report := reporter.Build(nil, receipt, nil)
+	require.Len(t, report.Events, 1)
+	var names []string
+	for _, arg := range report.Events[0].Args {
+		names = append(names, arg.Name)
+	}
+	assert.Equal(t, []string{"user", "amount", "arg2"}, names)
+}
+
+func TestReporter_NoReceipt(t *testing.T) {
+	to := web3.Address{0x2}
+	txn := &web3.Transaction{From: web3.Address{0x1}, To: &to, Value: big.NewInt(1)}
+
+	report := registry2.NewReporter().Build(txn, nil, nil)
+	assert.Equal(t, "pending", report.Status)
+	assert.Equal(t, txn.Hash(), report.TxHash)
+	assert.Equal(t, web3.Address{0x1}.String(), report.From)
+	assert.Empty(t, report.Events)
+	assert.Contains(t, report.Text(), "Status:    pending")
+}
@@ -0,0 +1,478 @@
+package sourcemap
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/crypto"
+	"github.com/laizy/web3/evm"
+)
+
+// CoverageTracer is an evm.Tracer that counts the executed instructions of every code,
+// the counts are kept across the transactions
+type CoverageTracer struct {
+	project *Project
+	create  bool
+	frames  []*codeHits
+	hits    map[codeKey]*codeHits
+}
+
+type codeKey struct {
+	hash   web3.Hash
+	create bool
+}
+
+// codeHits are the executions of a code
+type codeHits struct {
+	contract *Contract
+	create   bool
+	op       evm.OpCode
+	// counts are the executions by pc
+	counts []uint64
+	// jumps are the conditional jumps taken and not taken by pc
+	jumps map[uint64]*[2]uint64
+}
+
+// NewCoverageTracer returns the coverage tracer of the contracts of the project
+func NewCoverageTracer(project *Project) *CoverageTracer {
+	return &CoverageTracer{project: project, hits: map[codeKey]*codeHits{}}
+}
+
+// CaptureStart implements the evm.Tracer interface
+func (t *CoverageTracer) CaptureStart(from web3.Address, to web3.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.create = create
+	t.frames = nil
+}
+
+// CaptureState implements the evm.Tracer interface
+func (t *CoverageTracer) CaptureState(env *evm.EVM, pc uint64, op evm.OpCode, gas, cost uint64, memory *evm.Memory,
+	stack *evm.Stack, rStack *evm.ReturnStack, rData []byte, contract *evm.Contract, depth int, err error) {
+	if err != nil {
+		// the instructions that fail before their execution are not counted
+		return
+	}
+	hits := t.enter(contract, depth)
+	hits.op = op
+	if pc < uint64(len(hits.counts)) {
+		hits.counts[pc]++
+	}
+	if op == evm.JUMPI {
+		jump, ok := hits.jumps[pc]
+		if !ok {
+			jump = &[2]uint64{}
+			hits.jumps[pc] = jump
+		}
+		if stack.Back(1).IsZero() {
+			jump[1]++
+		} else {
+			jump[0]++
+		}
+	}
+}
+
+// CaptureFault implements the evm.Tracer interface
+func (t *CoverageTracer) CaptureFault(env *evm.EVM, pc uint64, op evm.OpCode, gas, cost uint64, memory *evm.Memory,
+	stack *evm.Stack, rStack *evm.ReturnStack, contract *evm.Contract, depth int, err error) {
+}
+
+// CaptureEnd implements the evm.Tracer interface
+func (t *CoverageTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+}
+
+func (t *CoverageTracer) enter(contract *evm.Contract, depth int) *codeHits {
+	if len(t.frames) > depth {
+		t.frames = t.frames[:depth]
+	}
+	if len(t.frames) < depth {
+		create := t.create
+		if len(t.frames) != 0 {
+			op := t.frames[len(t.frames)-1].op
+			create = op == evm.CREATE || op == evm.CREATE2
+		}
+		key := codeKey{hash: crypto.Keccak256Hash(contract.Code), create: create}
+		hits, ok := t.hits[key]
+		if !ok {
+			hits = &codeHits{
+				contract: t.project.Match(contract.Code, create),
+				create:   create,
+				counts:   make([]uint64, len(contract.Code)),
+				jumps:    map[uint64]*[2]uint64{},
+			}
+			t.hits[key] = hits
+		}
+		t.frames = append(t.frames, hits)
+	}
+	return t.frames[len(t.frames)-1]
+}
+
+// Hits returns the executions by pc of the code, the creation code includes the
+// constructor arguments
+func (t *CoverageTracer) Hits(code []byte, create bool) map[uint64]uint64 {
+	hits, ok := t.hits[codeKey{hash: crypto.Keccak256Hash(code), create: create}]
+	if !ok {
+		return nil
+	}
+	res := map[uint64]uint64{}
+	for pc, count := range hits.counts {
+		if count != 0 {
+			res[uint64(pc)] = count
+		}
+	}
+	return res
+}
+
+// Coverage returns the coverage of the sources of the project, with the lines and the
+// branches of the contracts that are not executed
+func (t *CoverageTracer) Coverage() *Coverage {
+	files := map[string]*fileHits{}
+	fileOf := func(src *Source) *fileHits {
+		f, ok := files[src.Name]
+		if !ok {
+			f = &fileHits{src: src, lines: map[int]uint64{}, branches: map[branchKey]*[2]uint64{}}
+			files[src.Name] = f
+		}
+		return f
+	}
+
+	for _, c := range t.project.contracts {
+		for _, create := range []bool{true, false} {
+			code := c.runtime
+			if create {
+				code = c.creation
+			}
+			var executions []*codeHits
+			for _, hits := range t.hits {
+				if hits.contract == c && hits.create == create {
+					executions = append(executions, hits)
+				}
+			}
+			// the lines of every code are the most executed instruction, the codes of
+			// the contracts that use the same source are added
+			lines := map[*fileHits]map[int]uint64{}
+			for pc := range code.bin {
+				if pc != 0 && code.indexes[pc] == code.indexes[pc-1] {
+					continue
+				}
+				entry := code.entry(uint64(pc))
+				src := code.source(entry)
+				if src == nil || isBlock(src, entry) {
+					continue
+				}
+				f := fileOf(src)
+				line, _ := src.Position(entry.Start)
+				var count uint64
+				for _, hits := range executions {
+					if pc < len(hits.counts) {
+						count += hits.counts[pc]
+					}
+				}
+				if lines[f] == nil {
+					lines[f] = map[int]uint64{}
+				}
+				if prev, ok := lines[f][line]; !ok || count > prev {
+					lines[f][line] = count
+				}
+
+				if evm.OpCode(code.bin[pc]) == evm.JUMPI {
+					key := branchKey{line: line, start: entry.Start, pc: pc, contract: c.Name, create: create}
+					branch := &[2]uint64{}
+					for _, hits := range executions {
+						if jump, ok := hits.jumps[uint64(pc)]; ok {
+							branch[0] += jump[0]
+							branch[1] += jump[1]
+						}
+					}
+					f.branches[key] = branch
+				}
+			}
+			for f, fileLines := range lines {
+				for line, count := range fileLines {
+					f.lines[line] += count
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	res := &Coverage{}
+	for _, name := range names {
+		res.Files = append(res.Files, files[name].coverage())
+	}
+	return res
+}
+
+// source returns the source of the entry, nil for the generated code
+func (c *code) source(entry *Entry) *Source {
+	if entry == nil || entry.File < 0 || entry.File >= len(c.sources) {
+		return nil
+	}
+	return c.sources[entry.File]
+}
+
+// isBlock returns whether the entry is the range of a contract, a function or a block of
+// statements, which contains the lines of its statements
+func isBlock(src *Source, entry *Entry) bool {
+	start, end := entry.Start, entry.Start+entry.Length
+	if start < 0 || end > len(src.Content) {
+		return true
+	}
+	// the definitions contain the bodies of the contracts and the functions
+	for _, sc := range src.scopes {
+		if start <= sc.start && sc.end <= end {
+			return true
+		}
+	}
+	// the blocks are delimited by their braces, unlike the statements that contain some
+	return entry.Length != 0 && src.Content[start] == '{' && src.Content[end-1] == '}'
+}
+
+type branchKey struct {
+	line     int
+	start    int
+	pc       int
+	contract string
+	create   bool
+}
+
+type fileHits struct {
+	src      *Source
+	lines    map[int]uint64
+	branches map[branchKey]*[2]uint64
+}
+
+func (f *fileHits) coverage() *FileCoverage {
+	res := &FileCoverage{Name: f.src.Name}
+	for line, count := range f.lines {
+		res.Lines = append(res.Lines, &LineCoverage{Line: line, Hits: count})
+	}
+	sort.Slice(res.Lines, func(i, j int) bool { return res.Lines[i].Line < res.Lines[j].Line })
+
+	keys := make([]branchKey, 0, len(f.branches))
+	for key := range f.branches {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch {
+		case a.start != b.start:
+			return a.start < b.start
+		case a.contract != b.contract:
+			return a.contract < b.contract
+		case a.create != b.create:
+			return a.create
+		}
+		return a.pc < b.pc
+	})
+	for i, key := range keys {
+		branch := f.branches[key]
+		res.Branches = append(res.Branches, &BranchCoverage{Line: key.line, Block: i, Taken: branch[0], NotTaken: branch[1]})
+	}
+
+	// the overloaded functions are named with their line
+	names, overloaded := map[string]bool{}, map[string]bool{}
+	for _, sc := range f.src.scopes {
+		if sc.contract {
+			continue
+		}
+		contract, _ := f.src.Function(sc.start)
+		name := contract + "." + sc.name
+		overloaded[name] = names[name]
+		names[name] = true
+	}
+	for _, sc := range f.src.scopes {
+		if sc.contract {
+			continue
+		}
+		contract, _ := f.src.Function(sc.start)
+		line, _ := f.src.Position(sc.start)
+		endLine, _ := f.src.Position(sc.end - 1)
+		fn := &FunctionCoverage{Name: contract + "." + sc.name, Line: line}
+		if overloaded[fn.Name] {
+			fn.Name += fmt.Sprintf(":%d", line)
+		}
+		for _, l := range res.Lines {
+			if l.Line >= line && l.Line <= endLine && l.Hits > fn.Hits {
+				fn.Hits = l.Hits
+			}
+		}
+		res.Functions = append(res.Functions, fn)
+	}
+	return res
+}
+
+// Coverage is the coverage of the sources by file name
+type Coverage struct {
+	Files []*FileCoverage
+}
+
+// FileCoverage is the coverage of a source file
+type FileCoverage struct {
+	Name string
+	// Lines are the lines with instructions
+	Lines     []*LineCoverage
+	Branches  []*BranchCoverage
+	Functions []*FunctionCoverage
+}
+
+// LineCoverage is the executions of a line
+type LineCoverage struct {
+	Line int
+	Hits uint64
+}
+
+// BranchCoverage is the executions of a conditional jump
+type BranchCoverage struct {
+	Line     int
+	Block    int
+	Taken    uint64
+	NotTaken uint64
+}
+
+// FunctionCoverage is the executions of a function or a modifier, the most executed line
+type FunctionCoverage struct {
+	// Name is 'Contract.function', followed by the line if the function is overloaded,
+	// ie. 'Contract.function:12'
+	Name string
+	Line int
+	Hits uint64
+}
+
+// Summary is the number of lines, branches and functions, and the executed ones. Every
+// conditional jump is two branches.
+type Summary struct {
+	Lines        int
+	LinesHit     int
+	Branches     int
+	BranchesHit  int
+	Functions    int
+	FunctionsHit int
+}
+
+// Summary returns the totals of the file
+func (f *FileCoverage) Summary() *Summary {
+	res := &Summary{Lines: len(f.Lines), Branches: 2 * len(f.Branches), Functions: len(f.Functions)}
+	for _, l := range f.Lines {
+		if l.Hits != 0 {
+			res.LinesHit++
+		}
+	}
+	for _, b := range f.Branches {
+		if b.Taken != 0 {
+			res.BranchesHit++
+		}
+		if b.NotTaken != 0 {
+			res.BranchesHit++
+		}
+	}
+	for _, fn := range f.Functions {
+		if fn.Hits != 0 {
+			res.FunctionsHit++
+		}
+	}
+	return res
+}
+
+// Summary returns the totals of all the files
+func (c *Coverage) Summary() *Summary {
+	res := &Summary{}
+	for _, f := range c.Files {
+		s := f.Summary()
+		res.Lines += s.Lines
+		res.LinesHit += s.LinesHit
+		res.Branches += s.Branches
+		res.BranchesHit += s.BranchesHit
+		res.Functions += s.Functions
+		res.FunctionsHit += s.FunctionsHit
+	}
+	return res
+}
+
+// File returns the coverage of the file, nil if it has no instructions
+func (c *Coverage) File(name string) *FileCoverage {
+	for _, f := range c.Files {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func percent(hit, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(hit) * 100 / float64(total)
+}
+
+// LinePercent returns the percentage of executed lines
+func (s *Summary) LinePercent() float64 {
+	return percent(s.LinesHit, s.Lines)
+}
+
+// BranchPercent returns the percentage of executed branches
+func (s *Summary) BranchPercent() float64 {
+	return percent(s.BranchesHit, s.Branches)
+}
+
+// FunctionPercent returns the percentage of executed functions
+func (s *Summary) FunctionPercent() float64 {
+	return percent(s.FunctionsHit, s.Functions)
+}
+
+func (s *Summary) String() string {
+	return fmt.Sprintf("lines %.1f%% (%d/%d), branches %.1f%% (%d/%d), functions %.1f%% (%d/%d)",
+		s.LinePercent(), s.LinesHit, s.Lines,
+		s.BranchPercent(), s.BranchesHit, s.Branches,
+		s.FunctionPercent(), s.FunctionsHit, s.Functions)
+}
+
+// String returns the summary of every file and the total
+func (c *Coverage) String() string {
+	var lines []string
+	for _, f := range c.Files {
+		lines = append(lines, f.Name+": "+f.Summary().String())
+	}
+	lines = append(lines, "total: "+c.Summary().String())
+	return strings.Join(lines, "\n")
+}
+
+// WriteLCOV writes the coverage in the lcov tracefile format
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	var b strings.Builder
+	for _, f := range c.Files {
+		s := f.Summary()
+		b.WriteString("TN:\n")
+		fmt.Fprintf(&b, "SF:%s\n", f.Name)
+		for _, fn := range f.Functions {
+			fmt.Fprintf(&b, "FN:%d,%s\n", fn.Line, fn.Name)
+		}
+		for _, fn := range f.Functions {
+			fmt.Fprintf(&b, "FNDA:%d,%s\n", fn.Hits, fn.Name)
+		}
+		fmt.Fprintf(&b, "FNF:%d\nFNH:%d\n", s.Functions, s.FunctionsHit)
+		for _, br := range f.Branches {
+			if br.Taken == 0 && br.NotTaken == 0 {
+				fmt.Fprintf(&b, "BRDA:%d,%d,0,-\nBRDA:%d,%d,1,-\n", br.Line, br.Block, br.Line, br.Block)
+				continue
+			}
+			fmt.Fprintf(&b, "BRDA:%d,%d,0,%d\nBRDA:%d,%d,1,%d\n", br.Line, br.Block, br.Taken, br.Line, br.Block, br.NotTaken)
+		}
+		fmt.Fprintf(&b, "BRF:%d\nBRH:%d\n", s.Branches, s.BranchesHit)
+		for _, l := range f.Lines {
+			fmt.Fprintf(&b, "DA:%d,%d\n", l.Line, l.Hits)
+		}
+		fmt.Fprintf(&b, "LF:%d\nLH:%d\n", s.Lines, s.LinesHit)
+		b.WriteString("end_of_record\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,145 @@
+package sourcemap
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/compiler"
+	"github.com/laizy/web3/jsonrpc"
+	"github.com/laizy/web3/jsonrpc/transport/transporttest"
+	"github.com/laizy/web3/utils/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverageTracer(t *testing.T) {
+	local := transporttest.NewDevSimulated()
+	client := jsonrpc.NewClientWithTransport(local)
+
+	box := deploy(t, local, boxArtifact(t))
+	vault := deploy(t, local, vaultArtifact(t, box))
+
+	project, err := NewProject(map[string]*compiler.Artifact{
+		"contracts/Box.sol:Box":     boxArtifact(t),
+		"contracts/Vault.sol:Vault": vaultArtifact(t, box),
+	}, map[string]string{
+		"contracts/Box.sol":   boxSol,
+		"contracts/Vault.sol": vaultSol,
+	})
+	require.NoError(t, err)
+	tracer := NewCoverageTracer(project)
+	local.Executor.CallTracer = tracer
+
+	for _, to := range []web3.Address{vault, vault, box} {
+		_, err = client.Eth().Call(&web3.CallMsg{From: owner, To: &to}, web3.Latest)
+		require.Error(t, err)
+	}
+
+	code, err := hexutil.Decode(boxArtifact(t).BinRuntime)
+	require.NoError(t, err)
+	hits := tracer.Hits(code, false)
+	require.Equal(t, uint64(3), hits[0])
+	require.Equal(t, uint64(3), hits[90])
+	require.Nil(t, tracer.Hits(code, true))
+
+	coverage := tracer.Coverage()
+	require.Len(t, coverage.Files, 2)
+	require.Equal(t, []*LineCoverage{{Line: 5, Hits: 3}, {Line: 9, Hits: 3}}, coverage.File("contracts/Box.sol").Lines)
+	require.Equal(t, []*BranchCoverage{{Line: 9, Block: 0, Taken: 0, NotTaken: 2}}, coverage.File("contracts/Vault.sol").Branches)
+	require.Equal(t, &Summary{Lines: 3, LinesHit: 3, Branches: 2, BranchesHit: 1, Functions: 3, FunctionsHit: 3}, coverage.Summary())
+	require.Equal(t, `contracts/Box.sol: lines 100.0% (2/2), branches 100.0% (0/0), functions 100.0% (2/2)
+contracts/Vault.sol: lines 100.0% (1/1), branches 50.0% (1/2), functions 100.0% (1/1)
+total: lines 100.0% (3/3), branches 50.0% (1/2), functions 100.0% (3/3)`, coverage.String())
+
+	var buf bytes.Buffer
+	require.NoError(t, coverage.WriteLCOV(&buf))
+	require.Equal(t, `TN:
+SF:contracts/Box.sol
+FN:4,Box.fail
+FN:8,Box.check
+FNDA:3,Box.fail
+FNDA:3,Box.check
+FNF:2
+FNH:2
+BRF:0
+BRH:0
+DA:5,3
+DA:9,3
+LF:2
+LH:2
+end_of_record
+TN:
+SF:contracts/Vault.sol
+FN:8,Vault.run
+FNDA:2,Vault.run
+FNF:1
+FNH:1
+BRDA:9,0,0,0
+BRDA:9,0,1,2
+BRF:2
+BRH:1
+DA:9,2
+LF:1
+LH:1
+end_of_record
+`, buf.String())
+}
+
+func TestCoverageTracer_NotExecuted(t *testing.T) {
+	project, err := NewProject(map[string]*compiler.Artifact{
+		"contracts/Vault.sol:Vault": vaultArtifact(t, web3.Address{}),
+	}, map[string]string{
+		"contracts/Vault.sol": vaultSol,
+	})
+	require.NoError(t, err)
+
+	coverage := NewCoverageTracer(project).Coverage()
+	require.Equal(t, &Summary{Lines: 1, Branches: 2, Functions: 1}, coverage.Summary())
+
+	var buf bytes.Buffer
+	require.NoError(t, coverage.WriteLCOV(&buf))
+	require.Contains(t, buf.String(), "BRDA:9,0,0,-\nBRDA:9,0,1,-\n")
+	require.Contains(t, buf.String(), "DA:9,0\n")
+}
+
+const storeSol = `pragma solidity ^0.8.0;
+
+contract Store {
+    struct Item { uint a; string s; }
+    Item item;
+
+    function set(uint a) external {
+        item = Item({a: a, s: "{"});
+    }
+
+    function set(uint a, uint b) external {
+        if (a > b) {
+            item.a = a;
+        }
+    }
+}
+`
+
+func TestCoverageTracer_Blocks(t *testing.T) {
+	// a statement with braces, the blocks and the definitions of the contract and the
+	// functions, the lines of the definitions are not covered
+	runtime := "5b5b5b5b5b5b00"
+	artifact := compiler.NewArtifact(`[]`, initCode(runtime), runtime)
+	artifact.Sources = []string{"contracts/Store.sol"}
+	artifact.DeployedSourceMap = srcmap(t, 0, storeSol,
+		storeSol[indexOf(t, storeSol, "contract"):len(storeSol)-1],
+		storeSol[indexOf(t, storeSol, "function set(uint a)"):indexOf(t, storeSol, "    function set(uint a, uint b)")-2],
+		`item = Item({a: a, s: "{"});`,
+		"{\n            item.a = a;\n        }",
+		"if (a > b) {\n            item.a = a;\n        }",
+		"item.a = a;",
+		"item.a = a;",
+	)
+	project, err := NewProject(map[string]*compiler.Artifact{"contracts/Store.sol:Store": artifact},
+		map[string]string{"contracts/Store.sol": storeSol})
+	require.NoError(t, err)
+
+	coverage := NewCoverageTracer(project).Coverage().File("contracts/Store.sol")
+	require.Equal(t, []*LineCoverage{{Line: 8}, {Line: 12}, {Line: 13}}, coverage.Lines)
+	require.Equal(t, []*FunctionCoverage{{Name: "Store.set:7", Line: 7}, {Name: "Store.set:11", Line: 11}}, coverage.Functions)
+}
@@ -0,0 +1,294 @@
+package sourcemap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/compiler"
+	"github.com/laizy/web3/crypto"
+	"github.com/laizy/web3/hardhat"
+	"github.com/laizy/web3/registry"
+)
+
+// Project maps the code executed by the evm to the contracts and the sources of a
+// compilation
+type Project struct {
+	contracts []*Contract
+	sources   map[string]*Source
+	errors    *registry.ErrorRegistry
+
+	// matched caches the contract of the code by code hash, nil if unknown, the tracers of
+	// concurrent executions share it
+	lock    sync.RWMutex
+	matched map[web3.Hash]*Contract
+}
+
+// Contract is a compiled contract with the source maps of its creation and runtime code
+type Contract struct {
+	// Name is the fully qualified name, ie. 'contracts/Token.sol:Token'
+	Name     string
+	Artifact *compiler.Artifact
+
+	creation *code
+	runtime  *code
+}
+
+type code struct {
+	bin []byte
+	// mask is set on the bytes that differ between deployments: the library addresses
+	// and the immutable variables
+	mask    []bool
+	entries []*Entry
+	indexes []int
+	sources []*Source
+}
+
+// Location is the position of an instruction in the sources
+type Location struct {
+	// Contract is the fully qualified name of the executed contract
+	Contract string
+	File     string
+	// Start and Length are the source range in bytes
+	Start  int
+	Length int
+	// Line and Column start at 1
+	Line   int
+	Column int
+	// Scope is the name of the contract that contains the instruction in the source,
+	// a base contract or a library of the executed contract
+	Scope string
+	// Function is empty outside of the functions and the modifiers
+	Function string
+	Jump     byte
+}
+
+// FunctionName returns the function as 'Contract.function'
+func (l *Location) FunctionName() string {
+	switch {
+	case l.Function == "":
+		return l.Scope
+	case l.Scope == "":
+		return l.Function
+	}
+	return l.Scope + "." + l.Function
+}
+
+func (l *Location) String() string {
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
+// NewProject returns the project of the artifacts keyed by 'source:name' and the source
+// contents keyed by source name
+func NewProject(artifacts map[string]*compiler.Artifact, sources map[string]string) (*Project, error) {
+	p := &Project{
+		sources: map[string]*Source{},
+		errors:  registry.NewErrorRegistry(),
+		matched: map[web3.Hash]*Contract{},
+	}
+	for name, content := range sources {
+		p.sources[name] = NewSource(name, content)
+	}
+	names := make([]string, 0, len(artifacts))
+	for name := range artifacts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c, err := p.newContract(name, artifacts[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		p.contracts = append(p.contracts, c)
+	}
+	return p, nil
+}
+
+// NewProjectFromBuildInfo returns the project of the hardhat build infos
+func NewProjectFromBuildInfo(infos ...*hardhat.BuildInfo) (*Project, error) {
+	artifacts := map[string]*compiler.Artifact{}
+	sources := map[string]string{}
+	for _, info := range infos {
+		res, err := info.Artifacts()
+		if err != nil {
+			return nil, err
+		}
+		for name, artifact := range res {
+			artifacts[name] = artifact
+		}
+		for name, src := range info.Input.Sources {
+			sources[name] = src.Content
+		}
+	}
+	return NewProject(artifacts, sources)
+}
+
+func (p *Project) newContract(name string, artifact *compiler.Artifact) (*Contract, error) {
+	if artifact.Abi != "" {
+		if abiVal, err := abi.NewABI(artifact.Abi); err == nil {
+			p.errors.RegisterFromAbi(abiVal)
+		}
+	}
+	var sources []*Source
+	for _, src := range artifact.Sources {
+		sources = append(sources, p.sources[src])
+	}
+	creation, err := newCode(artifact.Bin, artifact.SourceMap, sources, artifact.LinkReferences, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creation code: %v", err)
+	}
+	runtime, err := newCode(artifact.BinRuntime, artifact.DeployedSourceMap, sources, artifact.DeployedLinkReferences, artifact.ImmutableReferences)
+	if err != nil {
+		return nil, fmt.Errorf("runtime code: %v", err)
+	}
+	return &Contract{Name: name, Artifact: artifact, creation: creation, runtime: runtime}, nil
+}
+
+// placeholderRegexp matches the library placeholders of the unlinked bytecode
+var placeholderRegexp = regexp.MustCompile(`__.{36}__`)
+
+func newCode(bin string, srcmap string, sources []*Source, links compiler.LinkReferences, immutables map[string][]compiler.LinkReference) (*code, error) {
+	bin = strings.TrimPrefix(bin, "0x")
+	mask := make([]bool, len(bin)/2)
+	for _, loc := range placeholderRegexp.FindAllStringIndex(bin, -1) {
+		for i := loc[0] / 2; i < loc[1]/2 && i < len(mask); i++ {
+			mask[i] = true
+		}
+	}
+	data, err := hex.DecodeString(placeholderRegexp.ReplaceAllLiteralString(bin, strings.Repeat("0", 40)))
+	if err != nil {
+		return nil, err
+	}
+	var refs []compiler.LinkReference
+	for _, libs := range links {
+		for _, r := range libs {
+			refs = append(refs, r...)
+		}
+	}
+	for _, r := range immutables {
+		refs = append(refs, r...)
+	}
+	for _, r := range refs {
+		for i := r.Start; i < r.Start+r.Length && i < len(mask); i++ {
+			mask[i] = true
+		}
+	}
+	entries, err := Decode(srcmap)
+	if err != nil {
+		return nil, err
+	}
+	return &code{bin: data, mask: mask, entries: entries, indexes: instructionIndexes(data), sources: sources}, nil
+}
+
+// matches returns whether the code is the compiled one, the creation code is followed by
+// the constructor arguments
+func (c *code) matches(data []byte, prefix bool) bool {
+	if len(c.bin) == 0 || len(data) < len(c.bin) || (!prefix && len(data) != len(c.bin)) {
+		return false
+	}
+	for i, b := range c.bin {
+		if b != data[i] && !c.mask[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *code) entry(pc uint64) *Entry {
+	if pc >= uint64(len(c.indexes)) {
+		return nil
+	}
+	index := c.indexes[pc]
+	if index >= len(c.entries) {
+		return nil
+	}
+	return c.entries[index]
+}
+
+// Match returns the contract of the code executed by the evm, or nil if the code is not
+// in the project
+func (p *Project) Match(data []byte, create bool) *Contract {
+	hash := crypto.Keccak256Hash(data)
+	if create {
+		// the constructor arguments change the hash of the creation code
+		hash[0] ^= 0xff
+	}
+	p.lock.RLock()
+	c, ok := p.matched[hash]
+	p.lock.RUnlock()
+	if ok {
+		return c
+	}
+	var res *Contract
+	for _, c := range p.contracts {
+		if create && c.creation.matches(data, true) || !create && c.runtime.matches(data, false) {
+			// prefer the longest creation code if one is the prefix of another
+			if res == nil || len(c.creation.bin) > len(res.creation.bin) {
+				res = c
+			}
+		}
+	}
+	p.lock.Lock()
+	p.matched[hash] = res
+	p.lock.Unlock()
+	return res
+}
+
+// Contracts returns the contracts of the project
+func (p *Project) Contracts() []*Contract {
+	return p.contracts
+}
+
+// Source returns the source file by name
+func (p *Project) Source(name string) (*Source, bool) {
+	src, ok := p.sources[name]
+	return src, ok
+}
+
+// Entry returns the source map entry of the instruction, nil if the instruction has no
+// source map
+func (c *Contract) Entry(pc uint64, create bool) *Entry {
+	if create {
+		return c.creation.entry(pc)
+	}
+	return c.runtime.entry(pc)
+}
+
+// Location returns the position of the instruction in the sources, nil if the code of
+// the instruction is generated by the compiler or the source is unknown
+func (c *Contract) Location(pc uint64, create bool) *Location {
+	code := c.runtime
+	if create {
+		code = c.creation
+	}
+	entry := code.entry(pc)
+	if entry == nil {
+		return nil
+	}
+	return c.location(entry, code)
+}
+
+func (c *Contract) location(entry *Entry, code *code) *Location {
+	if entry.File < 0 || entry.File >= len(code.sources) || code.sources[entry.File] == nil {
+		return nil
+	}
+	src := code.sources[entry.File]
+	line, column := src.Position(entry.Start)
+	scope, function := src.Function(entry.Start)
+	return &Location{
+		Contract: c.Name,
+		File:     src.Name,
+		Start:    entry.Start,
+		Length:   entry.Length,
+		Line:     line,
+		Column:   column,
+		Scope:    scope,
+		Function: function,
+		Jump:     entry.Jump,
+	}
+}
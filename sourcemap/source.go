@@ -0,0 +1,151 @@
+package sourcemap
+
+import (
+	"sort"
+	"strings"
+)
+
+// Source is a source file of a compilation
+type Source struct {
+	Name    string
+	Content string
+
+	// lines are the offsets of the line starts
+	lines  []int
+	scopes []*scope
+}
+
+// scope is the body of a contract or a function
+type scope struct {
+	contract bool
+	name     string
+	start    int
+	end      int
+}
+
+// NewSource returns the source file with its lines and the functions of its contracts
+func NewSource(name, content string) *Source {
+	src := &Source{Name: name, Content: content, lines: []int{0}}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			src.lines = append(src.lines, i+1)
+		}
+	}
+	src.scopes = parseScopes(content)
+	return src
+}
+
+// Position returns the line and the column of the offset in bytes, starting at 1
+func (s *Source) Position(offset int) (int, int) {
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset }) - 1
+	if line < 0 {
+		line = 0
+	}
+	return line + 1, offset - s.lines[line] + 1
+}
+
+// Function returns the contract and the function that contain the offset, the function
+// is empty outside of the functions and the modifiers
+func (s *Source) Function(offset int) (string, string) {
+	var contract, function string
+	for _, sc := range s.scopes {
+		if sc.start > offset || offset >= sc.end {
+			continue
+		}
+		if sc.contract {
+			contract = sc.name
+		} else {
+			function = sc.name
+		}
+	}
+	return contract, function
+}
+
+var scopeKeywords = map[string]bool{
+	"contract":    true,
+	"library":     true,
+	"interface":   true,
+	"function":    false,
+	"modifier":    false,
+	"constructor": false,
+	"fallback":    false,
+	"receive":     false,
+}
+
+// parseScopes finds the bodies of the contracts and the functions, the comments and
+// the strings are skipped
+func parseScopes(content string) []*scope {
+	var (
+		res     []*scope
+		braces  []*scope
+		pending *scope
+		expect  bool // the next identifier is the name of pending
+	)
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := strings.Index(content[i+2:], "*/")
+			if end == -1 {
+				return res
+			}
+			i += end + 3
+		case c == '"' || c == '\'':
+			for i++; i < len(content) && content[i] != c; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+		case isIdentStart(c):
+			start := i
+			for i+1 < len(content) && isIdentPart(content[i+1]) {
+				i++
+			}
+			word := content[start : i+1]
+			if expect {
+				pending.name = word
+				expect = false
+				continue
+			}
+			contract, ok := scopeKeywords[word]
+			if !ok || pending != nil {
+				continue
+			}
+			pending = &scope{contract: contract, name: word, start: start}
+			expect = word == "contract" || word == "library" || word == "interface" ||
+				word == "function" || word == "modifier"
+		case c == '(' && expect:
+			// a function type, ie. 'function (uint) external f'
+			pending, expect = nil, false
+		case c == ';':
+			pending, expect = nil, false
+		case c == '{':
+			braces = append(braces, pending)
+			pending, expect = nil, false
+		case c == '}':
+			if len(braces) == 0 {
+				continue
+			}
+			if sc := braces[len(braces)-1]; sc != nil {
+				sc.end = i + 1
+				res = append(res, sc)
+			}
+			braces = braces[:len(braces)-1]
+		}
+	}
+	// the outer scopes first
+	sort.SliceStable(res, func(i, j int) bool { return res[i].start < res[j].start })
+	return res
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
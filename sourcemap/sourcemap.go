@@ -0,0 +1,90 @@
+package sourcemap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/laizy/web3/evm"
+)
+
+const (
+	// JumpIn is a jump into a function
+	JumpIn = 'i'
+	// JumpOut is a jump out of a function
+	JumpOut = 'o'
+	// JumpNone is any other instruction
+	JumpNone = '-'
+)
+
+// Entry is the source range of an instruction in a solc source map, File is the index
+// of the source file or -1 for the code generated by the compiler
+type Entry struct {
+	Start         int
+	Length        int
+	File          int
+	Jump          byte
+	ModifierDepth int
+}
+
+// Decode decodes a compressed source map, 's:l:f:j:m' entries separated by ';' where
+// the empty and missing fields are the ones of the previous entry
+func Decode(srcmap string) ([]*Entry, error) {
+	if srcmap == "" {
+		return nil, nil
+	}
+	items := strings.Split(srcmap, ";")
+	res := make([]*Entry, len(items))
+	prev := Entry{Start: -1, Length: -1, File: -1, Jump: JumpNone}
+	for i, item := range items {
+		entry := prev
+		for j, field := range strings.Split(item, ":") {
+			if field == "" {
+				continue
+			}
+			if j == 3 {
+				if len(field) != 1 {
+					return nil, fmt.Errorf("invalid jump '%s' of entry %d", field, i)
+				}
+				entry.Jump = field[0]
+				continue
+			}
+			val, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field '%s' of entry %d", field, i)
+			}
+			switch j {
+			case 0:
+				entry.Start = val
+			case 1:
+				entry.Length = val
+			case 2:
+				entry.File = val
+			case 4:
+				entry.ModifierDepth = val
+			}
+		}
+		res[i] = &entry
+		prev = entry
+	}
+	return res, nil
+}
+
+// instructionIndexes returns the index of the instruction at each position of the code,
+// the push data have the index of their push
+func instructionIndexes(code []byte) []int {
+	res := make([]int, len(code))
+	index := 0
+	for pc := 0; pc < len(code); pc++ {
+		res[pc] = index
+		if op := evm.OpCode(code[pc]); op >= evm.PUSH1 && op <= evm.PUSH32 {
+			size := int(op-evm.PUSH1) + 1
+			for i := 1; i <= size && pc+i < len(code); i++ {
+				res[pc+i] = index
+			}
+			pc += size
+		}
+		index++
+	}
+	return res
+}
@@ -0,0 +1,101 @@
+package sourcemap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	entries, err := Decode("1:2:1;:9;2:1:2;;:::o;3::0:i:1")
+	require.NoError(t, err)
+	require.Equal(t, []*Entry{
+		{Start: 1, Length: 2, File: 1, Jump: JumpNone},
+		{Start: 1, Length: 9, File: 1, Jump: JumpNone},
+		{Start: 2, Length: 1, File: 2, Jump: JumpNone},
+		{Start: 2, Length: 1, File: 2, Jump: JumpNone},
+		{Start: 2, Length: 1, File: 2, Jump: JumpOut},
+		{Start: 3, Length: 1, File: 0, Jump: JumpIn, ModifierDepth: 1},
+	}, entries)
+
+	entries, err = Decode("")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	_, err = Decode("1:2:x")
+	require.Error(t, err)
+	_, err = Decode("1:2:1:io")
+	require.Error(t, err)
+}
+
+func TestInstructionIndexes(t *testing.T) {
+	// PUSH1 0x80 PUSH2 0x0102 ADD PUSH32 (truncated)
+	code := []byte{0x60, 0x80, 0x61, 0x01, 0x02, 0x01, 0x7f, 0x00}
+	require.Equal(t, []int{0, 0, 1, 1, 1, 2, 3, 3}, instructionIndexes(code))
+}
+
+const boxSource = `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+interface IBox {
+    function fail() external;
+}
+
+/* contract Commented { function hidden() {} } */
+contract Box is IBox {
+    string constant name = "{ not a scope }";
+    function (uint) external callback;
+
+    modifier onlyOwner() {
+        _;
+    }
+
+    constructor() {}
+
+    function fail() external override onlyOwner {
+        check();
+    }
+
+    function check() internal pure {
+        revert("nope");
+    }
+
+    receive() external payable {}
+}
+`
+
+func TestSource(t *testing.T) {
+	src := NewSource("contracts/Box.sol", boxSource)
+
+	line, column := src.Position(indexOf(t, boxSource, `revert("nope")`))
+	require.Equal(t, 24, line)
+	require.Equal(t, 9, column)
+	line, column = src.Position(0)
+	require.Equal(t, 1, line)
+	require.Equal(t, 1, column)
+
+	cases := map[string][2]string{
+		`revert("nope")`:      {"Box", "check"},
+		`check();`:            {"Box", "fail"},
+		`_;`:                  {"Box", "onlyOwner"},
+		`constructor() {}`:    {"Box", "constructor"},
+		`receive() external`:  {"Box", "receive"},
+		`string constant`:     {"Box", ""},
+		`function fail() ext`: {"IBox", ""},
+		`pragma`:              {"", ""},
+		`hidden`:              {"", ""},
+	}
+	for snippet, expected := range cases {
+		scope, function := src.Function(indexOf(t, boxSource, snippet))
+		require.Equal(t, expected, [2]string{scope, function}, snippet)
+	}
+}
+
+func indexOf(t *testing.T, content, snippet string) int {
+	i := strings.Index(content, snippet)
+	if i == -1 {
+		t.Fatalf("%s not found", snippet)
+	}
+	return i
+}
@@ -0,0 +1,291 @@
+package sourcemap
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/evm"
+	"github.com/laizy/web3/evm/errors"
+	"github.com/laizy/web3/utils/common/hexutil"
+)
+
+// StackTracer is an evm.Tracer that keeps the call stack of the execution, with the
+// internal function calls of the contracts of the project, to render the solidity stack
+// trace of a failed transaction
+type StackTracer struct {
+	project *Project
+	create  bool
+	frames  []*frame
+	nextID  int
+	failure *failure
+	trace   *StackTrace
+}
+
+type frame struct {
+	id       int
+	address  web3.Address
+	contract *Contract
+	create   bool
+	pc       uint64
+	op       evm.OpCode
+	// last is the position of the last instruction with a source location, the code
+	// generated by the compiler has none
+	last    uint64
+	hasLast bool
+	// calls are the positions of the jumps into the internal functions
+	calls []uint64
+}
+
+type failure struct {
+	ids    []int
+	frames []*StackFrame
+	data   []byte
+	err    error
+}
+
+// NewStackTracer returns the tracer of the contracts of the project, the code of the
+// other contracts is traced by address and pc
+func NewStackTracer(project *Project) *StackTracer {
+	return &StackTracer{project: project}
+}
+
+// StackTrace returns the stack trace of the last transaction, or nil if it succeeded
+func (t *StackTracer) StackTrace() *StackTrace {
+	return t.trace
+}
+
+// CaptureStart implements the evm.Tracer interface, the tracer is reset for every
+// transaction
+func (t *StackTracer) CaptureStart(from web3.Address, to web3.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.create = create
+	t.frames = nil
+	t.failure = nil
+	t.trace = nil
+}
+
+// CaptureState implements the evm.Tracer interface
+func (t *StackTracer) CaptureState(env *evm.EVM, pc uint64, op evm.OpCode, gas, cost uint64, memory *evm.Memory,
+	stack *evm.Stack, rStack *evm.ReturnStack, rData []byte, contract *evm.Contract, depth int, err error) {
+	f := t.enter(contract, depth)
+	f.step(pc, op)
+	switch {
+	case err != nil:
+		t.fail(nil, err)
+	case op == evm.REVERT:
+		offset, size := stack.Back(0), stack.Back(1)
+		var data []byte
+		if offset.IsUint64() && size.IsUint64() {
+			data = memory.GetCopy(int64(offset.Uint64()), int64(size.Uint64()))
+		}
+		t.fail(data, errors.ErrExecutionReverted)
+	}
+}
+
+// CaptureFault implements the evm.Tracer interface
+func (t *StackTracer) CaptureFault(env *evm.EVM, pc uint64, op evm.OpCode, gas, cost uint64, memory *evm.Memory,
+	stack *evm.Stack, rStack *evm.ReturnStack, contract *evm.Contract, depth int, err error) {
+	// the reverts are captured before the execution of the opcode with their data
+	if err != errors.ErrExecutionReverted {
+		t.enter(contract, depth)
+		t.fail(nil, err)
+	}
+}
+
+// CaptureEnd implements the evm.Tracer interface
+func (t *StackTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+	if err == nil {
+		return
+	}
+	if t.failure == nil {
+		// the failures outside of the interpreter, ie. a code too large to be deployed
+		t.fail(output, err)
+	}
+	t.trace = &StackTrace{
+		Frames:     t.failure.frames,
+		ReturnData: t.failure.data,
+		Err:        t.failure.err,
+		Reason:     t.project.reason(t.failure.data, t.failure.err),
+	}
+}
+
+// enter returns the frame of the contract, the frames of the returned calls are removed
+func (t *StackTracer) enter(contract *evm.Contract, depth int) *frame {
+	if len(t.frames) > depth {
+		t.frames = t.frames[:depth]
+	}
+	if len(t.frames) < depth {
+		create := t.create
+		if len(t.frames) != 0 {
+			op := t.frames[len(t.frames)-1].op
+			create = op == evm.CREATE || op == evm.CREATE2
+		}
+		t.nextID++
+		t.frames = append(t.frames, &frame{
+			id:       t.nextID,
+			address:  contract.Address(),
+			contract: t.project.Match(contract.Code, create),
+			create:   create,
+		})
+	}
+	return t.frames[len(t.frames)-1]
+}
+
+func (f *frame) step(pc uint64, op evm.OpCode) {
+	f.pc, f.op = pc, op
+	if f.contract == nil {
+		return
+	}
+	entry := f.contract.Entry(pc, f.create)
+	if entry == nil {
+		return
+	}
+	if entry.File >= 0 {
+		f.last, f.hasLast = pc, true
+	}
+	if op == evm.JUMP {
+		switch entry.Jump {
+		case JumpIn:
+			f.calls = append(f.calls, pc)
+		case JumpOut:
+			if len(f.calls) != 0 {
+				f.calls = f.calls[:len(f.calls)-1]
+			}
+		}
+	}
+}
+
+// fail records the failure of the current call, unless it propagates the failure of the
+// call that it made
+func (t *StackTracer) fail(data []byte, err error) {
+	ids := make([]int, len(t.frames))
+	for i, f := range t.frames {
+		ids[i] = f.id
+	}
+	if prev := t.failure; prev != nil && len(prev.ids) > len(ids) && equalIDs(prev.ids[:len(ids)], ids) &&
+		prev.err == err && bytes.Equal(prev.data, data) {
+		return
+	}
+	var frames []*StackFrame
+	for i := len(t.frames) - 1; i >= 0; i-- {
+		frames = append(frames, t.frames[i].stackFrames()...)
+	}
+	t.failure = &failure{ids: ids, frames: frames, data: data, err: err}
+}
+
+func equalIDs(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stackFrames returns the current instruction and the internal calls of the frame
+func (f *frame) stackFrames() []*StackFrame {
+	newFrame := func(pc uint64) *StackFrame {
+		res := &StackFrame{Address: f.address, Create: f.create, PC: pc}
+		if f.contract != nil {
+			res.Contract = f.contract.Name
+			res.Location = f.contract.Location(pc, f.create)
+		}
+		return res
+	}
+
+	pc := f.pc
+	if f.contract != nil && f.contract.Location(pc, f.create) == nil && f.hasLast {
+		pc = f.last
+	}
+	res := []*StackFrame{newFrame(pc)}
+	for i := len(f.calls) - 1; i >= 0; i-- {
+		call := newFrame(f.calls[i])
+		// the jumps from the dispatcher into the functions are not calls
+		if call.Location == nil || call.Location.Function == "" {
+			continue
+		}
+		if last := res[len(res)-1]; last.Location != nil && last.Location.Start == call.Location.Start {
+			continue
+		}
+		res = append(res, call)
+	}
+	return res
+}
+
+// StackFrame is a call of the stack trace
+type StackFrame struct {
+	Address web3.Address
+	// Contract is the fully qualified name of the contract, empty if the code is not in
+	// the project
+	Contract string
+	Create   bool
+	PC       uint64
+	// Location is nil if the instruction has no source location
+	Location *Location
+}
+
+func (f *StackFrame) String() string {
+	if f.Location != nil {
+		return fmt.Sprintf("at %s (%s)", f.Location.FunctionName(), f.Location)
+	}
+	name := "<unrecognized-contract>"
+	if f.Contract != "" {
+		name = f.Contract[strings.LastIndex(f.Contract, ":")+1:]
+	}
+	if f.Create {
+		return fmt.Sprintf("at %s.constructor (pc %d)", name, f.PC)
+	}
+	return fmt.Sprintf("at %s %s (pc %d)", name, f.Address, f.PC)
+}
+
+// StackTrace is the solidity stack trace of a failed transaction
+type StackTrace struct {
+	// Frames are the calls from the failure to the transaction, with the internal
+	// function calls
+	Frames     []*StackFrame
+	ReturnData []byte
+	Err        error
+	// Reason describes the failure, ie. "reverted with reason string 'not owner'"
+	Reason string
+}
+
+// String renders the stack trace like hardhat
+func (s *StackTrace) String() string {
+	lines := []string{"Error: VM Exception while processing transaction: " + s.Reason}
+	for _, f := range s.Frames {
+		lines = append(lines, "    "+f.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reason describes the revert data with the errors of the contracts of the project
+func (p *Project) reason(data []byte, err error) string {
+	if err != errors.ErrExecutionReverted {
+		return err.Error()
+	}
+	if len(data) == 0 {
+		return "reverted without a reason"
+	}
+	for _, e := range abi.DefaultError() {
+		if len(data) < 4 || !bytes.Equal(data[:4], e.ID()) {
+			continue
+		}
+		val, decodeErr := abi.Decode(e.Inputs, data[4:])
+		if decodeErr != nil {
+			break
+		}
+		arg := val.(map[string]interface{})[abi.NameToKey("", 0)]
+		if e.Name == "Panic" {
+			return fmt.Sprintf("reverted with panic code 0x%x", arg)
+		}
+		return fmt.Sprintf("reverted with reason string '%v'", arg)
+	}
+	if custom, parseErr := p.errors.ParseError(data); parseErr == nil {
+		return fmt.Sprintf("reverted with custom error '%s'", custom)
+	}
+	return fmt.Sprintf("reverted with an unrecognized custom error (return data: %s)", hexutil.Encode(data))
+}
@@ -0,0 +1,218 @@
+package sourcemap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/compiler"
+	"github.com/laizy/web3/evm/errors"
+	"github.com/laizy/web3/jsonrpc"
+	"github.com/laizy/web3/jsonrpc/transport"
+	"github.com/laizy/web3/jsonrpc/transport/transporttest"
+	"github.com/laizy/web3/utils/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+const boxSol = `pragma solidity ^0.8.0;
+
+contract Box {
+    function fail() external {
+        check();
+    }
+
+    function check() internal pure {
+        revert("nope");
+    }
+}
+`
+
+const vaultSol = `pragma solidity ^0.8.0;
+
+import "./Box.sol";
+
+contract Vault {
+    Box box;
+
+    function run() external {
+        box.fail();
+    }
+}
+`
+
+var sourceList = []string{"contracts/Box.sol", "contracts/Vault.sol"}
+
+// srcmap maps the instructions to the snippets of the source, the jumps are set after
+// a '|' in the snippet
+func srcmap(t *testing.T, file int, content string, snippets ...string) string {
+	items := make([]string, len(snippets))
+	for i, snippet := range snippets {
+		jump := "-"
+		if parts := strings.Split(snippet, "|"); len(parts) == 2 {
+			snippet, jump = parts[0], parts[1]
+		}
+		items[i] = fmt.Sprintf("%d:%d:%d:%s", indexOf(t, content, snippet), len(snippet), file, jump)
+	}
+	return strings.Join(items, ";")
+}
+
+func repeat(snippet string, n int) []string {
+	res := make([]string, n)
+	for i := range res {
+		res[i] = snippet
+	}
+	return res
+}
+
+// initCode returns the creation code that deploys the runtime code
+func initCode(runtime string) string {
+	return hex.EncodeToString(transporttest.InitCode(web3.Hex2Bytes(runtime)))
+}
+
+func boxArtifact(t *testing.T) *compiler.Artifact {
+	revertData := "08c379a0" + strings.Repeat("0", 56)
+	runtime := "600356" + // PUSH1 3 JUMP into check
+		"5b" + // JUMPDEST
+		"7f" + revertData + "600052" + // mstore(0, selector)
+		"6020600452" + // mstore(4, 0x20)
+		"6004602452" + // mstore(36, 4)
+		"7f" + hex.EncodeToString([]byte("nope")) + strings.Repeat("0", 56) + "604452" + // mstore(68, "nope")
+		"60646000fd" // revert(0, 100)
+	artifact := compiler.NewArtifact(`[]`, initCode(runtime), runtime)
+	artifact.Sources = sourceList
+	artifact.DeployedSourceMap = srcmap(t, 0, boxSol, append([]string{
+		"check()", "check()|i",
+	}, repeat(`revert("nope")`, 14)...)...)
+	return artifact
+}
+
+func vaultArtifact(t *testing.T, box web3.Address) *compiler.Artifact {
+	runtime := "6000600060006000600073" + hex.EncodeToString(box[:]) + "5af1" + // call(gas, box, 0, 0, 0, 0, 0)
+		"602e57" + // PUSH1 ok JUMPI
+		"3d600060003e3d6000fd" + // revert with the return data
+		"5b00" // ok: JUMPDEST STOP
+	artifact := compiler.NewArtifact(`[]`, initCode(runtime), runtime)
+	artifact.Sources = sourceList
+	artifact.DeployedSourceMap = srcmap(t, 1, vaultSol, repeat("box.fail()", 19)...)
+	return artifact
+}
+
+func deploy(t *testing.T, local *transport.Local, artifact *compiler.Artifact) web3.Address {
+	code, err := hexutil.Decode(artifact.BinRuntime)
+	require.NoError(t, err)
+	addr, err := transporttest.DeployCode(local, owner, code)
+	require.NoError(t, err)
+	return addr
+}
+
+var owner = transporttest.DevAccount
+
+func TestStackTracer(t *testing.T) {
+	local := transporttest.NewDevSimulated()
+	client := jsonrpc.NewClientWithTransport(local)
+
+	box := deploy(t, local, boxArtifact(t))
+	vault := deploy(t, local, vaultArtifact(t, box))
+
+	project, err := NewProject(map[string]*compiler.Artifact{
+		"contracts/Box.sol:Box":     boxArtifact(t),
+		"contracts/Vault.sol:Vault": vaultArtifact(t, box),
+	}, map[string]string{
+		"contracts/Box.sol":   boxSol,
+		"contracts/Vault.sol": vaultSol,
+	})
+	require.NoError(t, err)
+	tracer := NewStackTracer(project)
+	local.Executor.CallTracer = tracer
+
+	_, err = client.Eth().Call(&web3.CallMsg{From: owner, To: &vault}, web3.Latest)
+	require.Error(t, err)
+
+	trace := tracer.StackTrace()
+	require.NotNil(t, trace)
+	require.Equal(t, "reverted with reason string 'nope'", trace.Reason)
+	require.Equal(t, `Error: VM Exception while processing transaction: reverted with reason string 'nope'
+    at Box.check (contracts/Box.sol:9:9)
+    at Box.fail (contracts/Box.sol:5:9)
+    at Vault.run (contracts/Vault.sol:9:9)`, trace.String())
+	require.Equal(t, box, trace.Frames[0].Address)
+	require.Equal(t, "contracts/Box.sol:Box", trace.Frames[0].Contract)
+	require.Equal(t, vault, trace.Frames[2].Address)
+
+	// the code that is not in the project is traced by address
+	other, err := NewProject(nil, nil)
+	require.NoError(t, err)
+	tracer = NewStackTracer(other)
+	local.Executor.CallTracer = tracer
+	_, err = client.Eth().Call(&web3.CallMsg{From: owner, To: &vault}, web3.Latest)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(`Error: VM Exception while processing transaction: reverted with reason string 'nope'
+    at <unrecognized-contract> %s (pc 90)
+    at <unrecognized-contract> %s (pc 32)`, box, vault), tracer.StackTrace().String())
+
+	// no trace for the successful calls
+	_, err = client.Eth().Call(&web3.CallMsg{From: owner, To: &owner}, web3.Latest)
+	require.NoError(t, err)
+	require.Nil(t, tracer.StackTrace())
+}
+
+func TestProject_Match(t *testing.T) {
+	artifact := boxArtifact(t)
+	artifact.BinRuntime = artifact.BinRuntime[:12] + "__$0123456789abcdef0123456789abcdef01$__" + artifact.BinRuntime[52:]
+	project, err := NewProject(map[string]*compiler.Artifact{"contracts/Box.sol:Box": artifact}, nil)
+	require.NoError(t, err)
+
+	code, err := hexutil.Decode(boxArtifact(t).BinRuntime)
+	require.NoError(t, err)
+	require.NotNil(t, project.Match(code, false))
+	require.Nil(t, project.Match(code[1:], false))
+
+	// the creation code is followed by the constructor arguments
+	bin, err := hexutil.Decode(artifact.Bin + strings.Repeat("00", 32))
+	require.NoError(t, err)
+	require.NotNil(t, project.Match(bin, true))
+	require.Nil(t, project.Match(bin, false))
+
+	c := project.Contracts()[0]
+	require.Nil(t, c.Location(0, false))
+	require.Equal(t, uint8(JumpIn), c.Entry(2, false).Jump)
+
+	// the tracers of concurrent executions share the project
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			project.Match(append(code, byte(i)), false)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestProject_Reason(t *testing.T) {
+	artifact := boxArtifact(t)
+	artifact.Abi = `[{"type": "error", "name": "NotOwner", "inputs": [{"name": "caller", "type": "address"}]}]`
+	project, err := NewProject(map[string]*compiler.Artifact{"contracts/Box.sol:Box": artifact}, nil)
+	require.NoError(t, err)
+
+	panicData := append(hexutil.MustDecode("0x4e487b71"), web3.BytesToHash([]byte{0x11}).Bytes()...)
+	customData := append(abi.MustNewABI(artifact.Abi).Errors["NotOwner"].ID(), web3.BytesToHash(owner[:]).Bytes()...)
+	cases := []struct {
+		data   []byte
+		err    error
+		reason string
+	}{
+		{nil, errors.ErrExecutionReverted, "reverted without a reason"},
+		{panicData, errors.ErrExecutionReverted, "reverted with panic code 0x11"},
+		{customData, errors.ErrExecutionReverted, "reverted with custom error 'NotOwner(" + owner.String() + ")'"},
+		{[]byte{1, 2, 3, 4}, errors.ErrExecutionReverted, "reverted with an unrecognized custom error (return data: 0x01020304)"},
+		{nil, errors.ErrOutOfGas, "out of gas"},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.reason, project.reason(c.data, c.err))
+	}
+}
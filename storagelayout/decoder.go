@@ -0,0 +1,501 @@
+package storagelayout
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/compiler"
+	"github.com/laizy/web3/crypto"
+	"github.com/laizy/web3/utils/common/hexutil"
+)
+
+// defaultMaxLength is the max number of elements of a dynamic array read at once
+const defaultMaxLength = 1024
+
+var zero = big.NewInt(0)
+
+// Decoder reads the state variables of a contract from its storage with the storage
+// layout of the compiler. The variables are selected by a path like the solidity
+// expression, ie. 'balances[0x5aAe...]', 'config.owner' or 'items[3].price'.
+type Decoder struct {
+	layout *compiler.StorageLayout
+	reader *cachedReader
+
+	// MaxLength is the max number of elements of the dynamic arrays read as a whole,
+	// the longer arrays have to be read by index
+	MaxLength int
+}
+
+// NewDecoder returns the decoder of the storage layout, the slots are read once
+func NewDecoder(layout *compiler.StorageLayout, reader Reader) *Decoder {
+	return &Decoder{layout: layout, reader: newCachedReader(reader), MaxLength: defaultMaxLength}
+}
+
+// Location is the position of a value in the storage
+type Location struct {
+	Slot *big.Int
+	// Offset is the position in bytes of the value in the slot, from the right
+	Offset int
+	// Type is the type id of the storage layout
+	Type string
+}
+
+// Hash returns the slot as a hash
+func (l *Location) Hash() web3.Hash {
+	return web3.BytesToHash(l.Slot.Bytes())
+}
+
+// Label returns the solidity type of the value
+func (d *Decoder) Label(loc *Location) string {
+	if typ, ok := d.layout.Types[loc.Type]; ok {
+		return typ.Label
+	}
+	return loc.Type
+}
+
+// Read returns the value of the path, see Value for the go types
+func (d *Decoder) Read(path string) (interface{}, error) {
+	loc, err := d.Locate(path)
+	if err != nil {
+		return nil, err
+	}
+	return d.Value(loc)
+}
+
+// Locate returns the location of the path, the dynamic array lengths are read to check
+// the indexes
+func (d *Decoder) Locate(path string) (*Location, error) {
+	name, accessors, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	var loc *Location
+	for _, v := range d.layout.Storage {
+		if v.Label == name {
+			loc, err = slotLocation(v, zero)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if loc == nil {
+		return nil, fmt.Errorf("variable %s not found", name)
+	}
+
+	for _, acc := range accessors {
+		typ, err := d.typ(loc.Type)
+		if err != nil {
+			return nil, err
+		}
+		if acc.member != "" {
+			loc, err = d.member(loc, typ, acc.member)
+		} else {
+			loc, err = d.index(loc, typ, acc.key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return loc, nil
+}
+
+func (d *Decoder) typ(id string) (*compiler.StorageType, error) {
+	typ, ok := d.layout.Types[id]
+	if !ok {
+		return nil, fmt.Errorf("type %s not found", id)
+	}
+	return typ, nil
+}
+
+func slotLocation(v *compiler.StorageSlot, base *big.Int) (*Location, error) {
+	slot, ok := new(big.Int).SetString(v.Slot, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid slot %s of %s", v.Slot, v.Label)
+	}
+	return &Location{Slot: slot.Add(slot, base), Offset: v.Offset, Type: v.Type}, nil
+}
+
+func (d *Decoder) member(loc *Location, typ *compiler.StorageType, name string) (*Location, error) {
+	if len(typ.Members) == 0 {
+		return nil, fmt.Errorf("%s is not a struct", typ.Label)
+	}
+	for _, m := range typ.Members {
+		if m.Label == name {
+			return slotLocation(m, loc.Slot)
+		}
+	}
+	return nil, fmt.Errorf("member %s of %s not found", name, typ.Label)
+}
+
+var staticArrayRegexp = regexp.MustCompile(`\)(\d+)_storage$`)
+
+func (d *Decoder) index(loc *Location, typ *compiler.StorageType, key string) (*Location, error) {
+	switch {
+	case typ.Encoding == "mapping":
+		keyType, err := d.typ(typ.Key)
+		if err != nil {
+			return nil, err
+		}
+		data, err := encodeKey(keyType.Label, key)
+		if err != nil {
+			return nil, err
+		}
+		slot := crypto.Keccak256(data, common32(loc.Slot))
+		return &Location{Slot: new(big.Int).SetBytes(slot), Type: typ.Value}, nil
+
+	case typ.Encoding == "dynamic_array":
+		index, err := parseIndex(key)
+		if err != nil {
+			return nil, err
+		}
+		length, err := d.length(loc)
+		if err != nil {
+			return nil, err
+		}
+		if index >= length {
+			return nil, fmt.Errorf("index %d out of range of %d elements", index, length)
+		}
+		return d.element(dataSlot(loc.Slot), typ.Base, index)
+
+	case typ.Base != "":
+		index, err := parseIndex(key)
+		if err != nil {
+			return nil, err
+		}
+		length, err := staticLength(loc.Type)
+		if err != nil {
+			return nil, err
+		}
+		if index >= length {
+			return nil, fmt.Errorf("index %d out of range of %d elements", index, length)
+		}
+		return d.element(loc.Slot, typ.Base, index)
+	}
+	return nil, fmt.Errorf("%s can not be indexed", typ.Label)
+}
+
+// element returns the location of the element of an array, the elements of less than
+// 32 bytes are packed
+func (d *Decoder) element(start *big.Int, baseID string, index uint64) (*Location, error) {
+	base, err := d.typ(baseID)
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.ParseUint(base.NumberOfBytes, 10, 64)
+	if err != nil || size == 0 {
+		return nil, fmt.Errorf("invalid size of %s", base.Label)
+	}
+	slot := new(big.Int).Set(start)
+	if size < 32 {
+		perSlot := 32 / size
+		slot.Add(slot, new(big.Int).SetUint64(index/perSlot))
+		return &Location{Slot: slot, Offset: int(index%perSlot) * int(size), Type: baseID}, nil
+	}
+	slots := (size + 31) / 32
+	slot.Add(slot, new(big.Int).Mul(new(big.Int).SetUint64(index), new(big.Int).SetUint64(slots)))
+	return &Location{Slot: slot, Type: baseID}, nil
+}
+
+func staticLength(id string) (uint64, error) {
+	match := staticArrayRegexp.FindStringSubmatch(id)
+	if match == nil {
+		return 0, fmt.Errorf("unknown length of %s", id)
+	}
+	return strconv.ParseUint(match[1], 10, 64)
+}
+
+// dataSlot returns the first slot of the elements of a dynamic array or a long string
+func dataSlot(slot *big.Int) *big.Int {
+	return new(big.Int).SetBytes(crypto.Keccak256(common32(slot)))
+}
+
+func (d *Decoder) word(slot *big.Int) (web3.Hash, error) {
+	return d.reader.GetStorage(web3.BytesToHash(common32(slot)))
+}
+
+func (d *Decoder) length(loc *Location) (uint64, error) {
+	word, err := d.word(loc.Slot)
+	if err != nil {
+		return 0, err
+	}
+	length := new(big.Int).SetBytes(word[:])
+	if !length.IsUint64() {
+		return 0, fmt.Errorf("invalid length %s", length)
+	}
+	return length.Uint64(), nil
+}
+
+// Value returns the value at the location. The integers are returned as *big.Int, the
+// addresses and contracts as web3.Address, the fixed bytes and bytes as []byte, the
+// strings as string, the arrays as []interface{} and the structs as map[string]interface{}
+// without the mappings.
+func (d *Decoder) Value(loc *Location) (interface{}, error) {
+	typ, err := d.typ(loc.Type)
+	if err != nil {
+		return nil, err
+	}
+	switch typ.Encoding {
+	case "mapping":
+		return nil, fmt.Errorf("%s can not be read without a key", typ.Label)
+
+	case "bytes":
+		data, err := d.bytes(loc.Slot)
+		if err != nil {
+			return nil, err
+		}
+		if typ.Label == "string" {
+			return string(data), nil
+		}
+		return data, nil
+
+	case "dynamic_array":
+		length, err := d.length(loc)
+		if err != nil {
+			return nil, err
+		}
+		if length > uint64(d.MaxLength) {
+			return nil, fmt.Errorf("%s has %d elements, read them by index", typ.Label, length)
+		}
+		return d.array(dataSlot(loc.Slot), typ.Base, length)
+	}
+
+	switch {
+	case len(typ.Members) != 0:
+		res := map[string]interface{}{}
+		for _, m := range typ.Members {
+			memberLoc, err := slotLocation(m, loc.Slot)
+			if err != nil {
+				return nil, err
+			}
+			if memberTyp, err := d.typ(m.Type); err == nil && memberTyp.Encoding == "mapping" {
+				res[m.Label] = nil
+				continue
+			}
+			res[m.Label], err = d.Value(memberLoc)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+
+	case typ.Base != "":
+		length, err := staticLength(loc.Type)
+		if err != nil {
+			return nil, err
+		}
+		return d.array(loc.Slot, typ.Base, length)
+	}
+
+	size, err := strconv.Atoi(typ.NumberOfBytes)
+	if err != nil || size <= 0 || size > 32 || loc.Offset+size > 32 {
+		return nil, fmt.Errorf("invalid size of %s", typ.Label)
+	}
+	word, err := d.word(loc.Slot)
+	if err != nil {
+		return nil, err
+	}
+	return decodeValue(typ.Label, word[32-loc.Offset-size:32-loc.Offset]), nil
+}
+
+func (d *Decoder) array(start *big.Int, base string, length uint64) ([]interface{}, error) {
+	res := make([]interface{}, 0, length)
+	for i := uint64(0); i < length; i++ {
+		loc, err := d.element(start, base, i)
+		if err != nil {
+			return nil, err
+		}
+		val, err := d.Value(loc)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, val)
+	}
+	return res, nil
+}
+
+// bytes reads a string or bytes, kept in the slot if shorter than 32 bytes or in the
+// consecutive slots from keccak(slot) otherwise
+func (d *Decoder) bytes(slot *big.Int) ([]byte, error) {
+	word, err := d.word(slot)
+	if err != nil {
+		return nil, err
+	}
+	if word[31]&1 == 0 {
+		length := int(word[31] / 2)
+		if length > 31 {
+			return nil, fmt.Errorf("invalid short bytes length %d", length)
+		}
+		return append([]byte{}, word[:length]...), nil
+	}
+
+	length := new(big.Int).SetBytes(word[:])
+	length.Rsh(length, 1)
+	if !length.IsUint64() || length.Uint64() > uint64(d.MaxLength)*32 {
+		return nil, fmt.Errorf("bytes of %s bytes are too long", length)
+	}
+	res := make([]byte, 0, length.Uint64()+31)
+	start := dataSlot(slot)
+	for i := uint64(0); uint64(len(res)) < length.Uint64(); i++ {
+		word, err := d.word(new(big.Int).Add(start, new(big.Int).SetUint64(i)))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, word[:]...)
+	}
+	return res[:length.Uint64()], nil
+}
+
+func decodeValue(label string, data []byte) interface{} {
+	switch {
+	case label == "bool":
+		return data[len(data)-1] != 0
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract "):
+		return web3.BytesToAddress(data)
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "enum "):
+		return new(big.Int).SetBytes(data)
+	case strings.HasPrefix(label, "int"):
+		val := new(big.Int).SetBytes(data)
+		if data[0]&0x80 != 0 {
+			val.Sub(val, new(big.Int).Lsh(big.NewInt(1), uint(len(data)*8)))
+		}
+		return val
+	}
+	// fixed bytes and function pointers
+	return append([]byte{}, data...)
+}
+
+// encodeKey encodes a mapping key, the value types are padded to 32 bytes
+func encodeKey(label string, key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	switch {
+	case label == "string":
+		if unquoted, err := strconv.Unquote(key); err == nil {
+			key = unquoted
+		} else if len(key) >= 2 && key[0] == '\'' && key[len(key)-1] == '\'' {
+			key = key[1 : len(key)-1]
+		}
+		return []byte(key), nil
+	case label == "bytes":
+		return hexutil.Decode(key)
+	case label == "bool":
+		switch key {
+		case "true":
+			return common32(big.NewInt(1)), nil
+		case "false":
+			return common32(big.NewInt(0)), nil
+		}
+		return nil, fmt.Errorf("invalid bool key %s", key)
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract "):
+		data, err := hexutil.Decode(key)
+		if err != nil || len(data) != 20 {
+			return nil, fmt.Errorf("invalid address key %s", key)
+		}
+		return web3.BytesToHash(data).Bytes(), nil
+	case strings.HasPrefix(label, "bytes"):
+		data, err := hexutil.Decode(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 32 {
+			return nil, fmt.Errorf("invalid %s key %s", label, key)
+		}
+		res := make([]byte, 32)
+		copy(res, data)
+		return res, nil
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "int") || strings.HasPrefix(label, "enum "):
+		val, ok := new(big.Int).SetString(key, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s key %s", label, key)
+		}
+		if val.Sign() < 0 {
+			// two's complement
+			val.Add(val, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return common32(val), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", label)
+}
+
+func common32(val *big.Int) []byte {
+	res := make([]byte, 32)
+	return val.FillBytes(res)
+}
+
+func parseIndex(key string) (uint64, error) {
+	index, err := strconv.ParseUint(strings.TrimSpace(key), 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid index %s", key)
+	}
+	return index, nil
+}
+
+type accessor struct {
+	member string
+	key    string
+}
+
+// parsePath splits a path like 'a.b[1]["c"]' into the variable name and the accessors
+func parsePath(path string) (string, []*accessor, error) {
+	path = strings.TrimSpace(path)
+	end := strings.IndexAny(path, ".[")
+	if end == -1 {
+		end = len(path)
+	}
+	name := path[:end]
+	if name == "" {
+		return "", nil, fmt.Errorf("invalid path '%s'", path)
+	}
+
+	var res []*accessor
+	rest := path[end:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return "", nil, fmt.Errorf("invalid path '%s'", path)
+			}
+			res = append(res, &accessor{member: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := closingBracket(rest)
+			if end == -1 {
+				return "", nil, fmt.Errorf("invalid path '%s'", path)
+			}
+			res = append(res, &accessor{key: rest[1:end]})
+			rest = rest[end+1:]
+		default:
+			return "", nil, fmt.Errorf("invalid path '%s'", path)
+		}
+	}
+	return name, res, nil
+}
+
+// closingBracket returns the index of the ']' that closes the key, skipping quoted strings
+func closingBracket(str string) int {
+	quote := byte(0)
+	for i := 1; i < len(str); i++ {
+		switch c := str[i]; {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ']':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,236 @@
+package storagelayout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/compiler"
+	"github.com/laizy/web3/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// state is a fake evm.StateDB of a single contract
+type state map[web3.Hash]web3.Hash
+
+func (s state) GetState(addr web3.Address, slot web3.Hash) web3.Hash {
+	return s[slot]
+}
+
+func (s state) set(slot []byte, word []byte) {
+	s[web3.BytesToHash(slot)] = web3.BytesToHash(word)
+}
+
+func slotOf(i int64) []byte {
+	return common32(big.NewInt(i))
+}
+
+func slotAdd(slot []byte, i int64) []byte {
+	return common32(new(big.Int).Add(new(big.Int).SetBytes(slot), big.NewInt(i)))
+}
+
+func readLayout(t *testing.T) *compiler.StorageLayout {
+	buf, err := ioutil.ReadFile("testdata/vault_layout.json")
+	require.NoError(t, err)
+	var layout *compiler.StorageLayout
+	require.NoError(t, json.Unmarshal(buf, &layout))
+	return layout
+}
+
+var (
+	owner  = web3.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	holder = web3.HexToAddress("0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359")
+	long   = []byte("a value longer than thirty one bytes, in two slots")
+)
+
+// vaultState returns the storage of
+//
+//	contract Vault {
+//	    struct Config { address owner; uint96 fee; string name; }
+//	    struct Item { uint256 price; uint32 qty; }
+//	    uint128 a; uint64 b; bool flag;
+//	    address owner;
+//	    mapping(address => uint256) balances;
+//	    Config config;
+//	    Item[] items;
+//	    string short;
+//	    bytes long;
+//	    uint16[3] small;
+//	    int8 neg;
+//	    mapping(string => mapping(uint256 => bool)) nested;
+//	}
+func vaultState() state {
+	s := state{}
+
+	packed := make([]byte, 32)
+	packed[31] = 0x02
+	packed[30] = 0x01 // a = 0x0102
+	packed[15] = 7    // b = 7
+	packed[7] = 1     // flag = true
+	s.set(slotOf(0), packed)
+
+	s.set(slotOf(1), owner.Bytes())
+	s.set(crypto.Keccak256(web3.BytesToHash(holder.Bytes()).Bytes(), slotOf(2)), big.NewInt(1000).Bytes())
+
+	config := make([]byte, 32)
+	copy(config[12:], owner.Bytes())
+	config[11] = 30 // fee = 30
+	s.set(slotOf(3), config)
+	name := make([]byte, 32)
+	copy(name, "vault")
+	name[31] = 5 * 2
+	s.set(slotOf(4), name)
+
+	s.set(slotOf(5), big.NewInt(4).Bytes())
+	items := crypto.Keccak256(slotOf(5))
+	for i := int64(0); i < 4; i++ {
+		s.set(slotAdd(items, 2*i), big.NewInt(100*(i+1)).Bytes())
+		s.set(slotAdd(items, 2*i+1), big.NewInt(i+1).Bytes())
+	}
+
+	short := make([]byte, 32)
+	copy(short, "hi")
+	short[31] = 2 * 2
+	s.set(slotOf(6), short)
+
+	s.set(slotOf(7), big.NewInt(int64(len(long))*2+1).Bytes())
+	data := crypto.Keccak256(slotOf(7))
+	s.set(data, long[:32])
+	s.set(slotAdd(data, 1), append(append([]byte{}, long[32:]...), make([]byte, 64-len(long))...))
+
+	small := make([]byte, 32)
+	small[31], small[29], small[27] = 1, 2, 3
+	s.set(slotOf(8), small)
+
+	neg := make([]byte, 32)
+	neg[31] = 0xfe // -2
+	s.set(slotOf(9), neg)
+
+	nested := crypto.Keccak256([]byte("abc"), slotOf(10))
+	s.set(crypto.Keccak256(slotOf(5), nested), big.NewInt(1).Bytes())
+	return s
+}
+
+func TestDecoder_Read(t *testing.T) {
+	decoder := NewDecoder(readLayout(t), NewStateReader(vaultState(), web3.Address{}))
+
+	cases := []struct {
+		path string
+		val  interface{}
+	}{
+		{"a", big.NewInt(0x0102)},
+		{"b", big.NewInt(7)},
+		{"flag", true},
+		{"owner", owner},
+		{"balances[" + holder.String() + "]", big.NewInt(1000)},
+		{"balances[" + owner.String() + "]", big.NewInt(0)},
+		{"config.owner", owner},
+		{"config.fee", big.NewInt(30)},
+		{"config.name", "vault"},
+		{"items[0].price", big.NewInt(100)},
+		{"items[3].price", big.NewInt(400)},
+		{"items[3].qty", big.NewInt(4)},
+		{"short", "hi"},
+		{"long", long},
+		{"small[2]", big.NewInt(3)},
+		{"small", []interface{}{big.NewInt(1), big.NewInt(2), big.NewInt(3)}},
+		{"neg", big.NewInt(-2)},
+		{`nested["abc"][5]`, true},
+		{`nested['abc'][0x05]`, true},
+		{`nested["abd"][5]`, false},
+		{"config", map[string]interface{}{"owner": owner, "fee": big.NewInt(30), "name": "vault"}},
+		{"items[1]", map[string]interface{}{"price": big.NewInt(200), "qty": big.NewInt(2)}},
+	}
+	for _, c := range cases {
+		val, err := decoder.Read(c.path)
+		require.NoError(t, err, c.path)
+		if num, ok := c.val.(*big.Int); ok {
+			require.Equal(t, num.String(), val.(*big.Int).String(), c.path)
+			continue
+		}
+		require.Equal(t, c.val, val, c.path)
+	}
+
+	items, err := decoder.Read("items")
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+}
+
+func TestDecoder_Locate(t *testing.T) {
+	decoder := NewDecoder(readLayout(t), NewStateReader(vaultState(), web3.Address{}))
+
+	loc, err := decoder.Locate("b")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), loc.Slot.Int64())
+	require.Equal(t, 16, loc.Offset)
+
+	loc, err = decoder.Locate("small[1]")
+	require.NoError(t, err)
+	require.Equal(t, int64(8), loc.Slot.Int64())
+	require.Equal(t, 2, loc.Offset)
+	require.Equal(t, "uint16", decoder.Label(loc))
+
+	loc, err = decoder.Locate("items[2].qty")
+	require.NoError(t, err)
+	expected := new(big.Int).SetBytes(crypto.Keccak256(slotOf(5)))
+	require.Equal(t, expected.Add(expected, big.NewInt(5)), loc.Slot)
+
+	for _, path := range []string{
+		"missing",
+		"items[4]",
+		"small[3]",
+		"owner.x",
+		"config.missing",
+		"balances",
+		"balances[0x01]",
+		"items[",
+		"a..b",
+	} {
+		_, err := decoder.Read(path)
+		require.Error(t, err, path)
+	}
+}
+
+func TestDecoder_Dump(t *testing.T) {
+	decoder := NewDecoder(readLayout(t), NewStateReader(vaultState(), web3.Address{}))
+
+	vars, err := decoder.Dump()
+	require.NoError(t, err)
+	require.Len(t, vars, 12)
+
+	require.Equal(t, "a", vars[0].Name)
+	require.Equal(t, "uint128", vars[0].Type)
+	require.Equal(t, "contracts/Vault.sol:Vault", vars[0].Contract)
+	require.Equal(t, big.NewInt(0x0102), vars[0].Value)
+
+	require.Equal(t, "balances", vars[4].Name)
+	require.Nil(t, vars[4].Value)
+	require.NoError(t, vars[4].Err)
+
+	require.Equal(t, "long", vars[8].Name)
+	require.Equal(t, long, vars[8].Value)
+
+	decoder.MaxLength = 2
+	vars, err = decoder.Dump()
+	require.NoError(t, err)
+	require.Error(t, vars[6].Err)
+}
+
+type failingReader struct{}
+
+func (failingReader) GetStorage(slot web3.Hash) (web3.Hash, error) {
+	return web3.Hash{}, fmt.Errorf("connection refused")
+}
+
+func TestDecoder_ReaderError(t *testing.T) {
+	decoder := NewDecoder(readLayout(t), failingReader{})
+
+	_, err := decoder.Read("owner")
+	require.Error(t, err)
+
+	_, err = decoder.Dump()
+	require.EqualError(t, err, "connection refused")
+}
@@ -0,0 +1,41 @@
+package storagelayout
+
+// Variable is a state variable of a contract
+type Variable struct {
+	// Contract is the contract that declares the variable, ie. 'contracts/Token.sol:Token'
+	Contract string
+	Name     string
+	// Type is the solidity type of the variable
+	Type     string
+	Location *Location
+	// Value is nil for the mappings, which can not be enumerated, or if Err is set
+	Value interface{}
+	// Err is the error reading the value, ie. an array too long
+	Err error
+}
+
+// Dump reads all the state variables of the contract in declaration order, the reader
+// errors are returned and the rest are kept in the variables
+func (d *Decoder) Dump() ([]*Variable, error) {
+	res := make([]*Variable, 0, len(d.layout.Storage))
+	for _, v := range d.layout.Storage {
+		loc, err := slotLocation(v, zero)
+		if err != nil {
+			return nil, err
+		}
+		variable := &Variable{
+			Contract: v.Contract,
+			Name:     v.Label,
+			Type:     d.Label(loc),
+			Location: loc,
+		}
+		if typ, ok := d.layout.Types[v.Type]; !ok || typ.Encoding != "mapping" {
+			variable.Value, variable.Err = d.Value(loc)
+			if variable.Err != nil && d.reader.err != nil {
+				return nil, d.reader.err
+			}
+		}
+		res = append(res, variable)
+	}
+	return res, nil
+}
@@ -0,0 +1,69 @@
+package storagelayout
+
+import (
+	"github.com/laizy/web3"
+	"github.com/laizy/web3/jsonrpc"
+)
+
+// Reader reads the storage slots of a contract
+type Reader interface {
+	GetStorage(slot web3.Hash) (web3.Hash, error)
+}
+
+type rpcReader struct {
+	eth   *jsonrpc.Eth
+	addr  web3.Address
+	block web3.BlockNumber
+}
+
+// NewRPCReader returns the reader of the contract storage at the block through the jsonrpc
+func NewRPCReader(eth *jsonrpc.Eth, addr web3.Address, block web3.BlockNumber) Reader {
+	return &rpcReader{eth: eth, addr: addr, block: block}
+}
+
+func (self *rpcReader) GetStorage(slot web3.Hash) (web3.Hash, error) {
+	return self.eth.GetStorage(self.addr, slot, self.block)
+}
+
+// StateGetter is the part of evm.StateDB used to read the storage
+type StateGetter interface {
+	GetState(web3.Address, web3.Hash) web3.Hash
+}
+
+type stateReader struct {
+	state StateGetter
+	addr  web3.Address
+}
+
+// NewStateReader returns the reader of the contract storage in an evm.StateDB
+func NewStateReader(state StateGetter, addr web3.Address) Reader {
+	return &stateReader{state: state, addr: addr}
+}
+
+func (self *stateReader) GetStorage(slot web3.Hash) (web3.Hash, error) {
+	return self.state.GetState(self.addr, slot), nil
+}
+
+// cachedReader reads every slot once and keeps the last error of the reader
+type cachedReader struct {
+	reader Reader
+	cache  map[web3.Hash]web3.Hash
+	err    error
+}
+
+func newCachedReader(reader Reader) *cachedReader {
+	return &cachedReader{reader: reader, cache: map[web3.Hash]web3.Hash{}}
+}
+
+func (self *cachedReader) GetStorage(slot web3.Hash) (web3.Hash, error) {
+	if val, ok := self.cache[slot]; ok {
+		return val, nil
+	}
+	val, err := self.reader.GetStorage(slot)
+	if err != nil {
+		self.err = err
+		return web3.Hash{}, err
+	}
+	self.cache[slot] = val
+	return val, nil
+}
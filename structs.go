@@ -283,6 +283,13 @@ func (self *Receipt) Thin() *ThinReceipt {
 	}
 }
 
+// ParseEvents parses the events of the receipt logs with the given parser
+func (self *Receipt) ParseEvents(p LogParser) {
+	for _, log := range self.Logs {
+		log.ParseEventWith(p)
+	}
+}
+
 func (self *Receipt) AddStorageLogs(logs []*StorageLog) {
 	for ind, log := range logs {
 		l := &Log{
@@ -315,7 +322,12 @@ type Log struct {
 }
 
 func (self *Log) ParseEvent() {
-	parsed, err := GetParser().ParseLog(self)
+	self.ParseEventWith(GetParser())
+}
+
+// ParseEventWith parses the event with the given parser instead of the global one
+func (self *Log) ParseEventWith(p LogParser) {
+	parsed, err := p.ParseLog(self)
 	if err == nil {
 		self.Event = parsed
 	}
@@ -84,6 +84,31 @@ func (t *Block) MarshalJSON() ([]byte, error) {
 		o.Set("uncles", uncles)
 	}
 
+	// transactions, full objects if present or the hashes otherwise
+	if len(t.Transactions) != 0 {
+		txns := a.NewArray()
+		for indx, txn := range t.Transactions {
+			data, err := txn.MarshalJSON()
+			if err != nil {
+				defaultArena.Put(a)
+				return nil, err
+			}
+			val, err := fastjson.ParseBytes(data)
+			if err != nil {
+				defaultArena.Put(a)
+				return nil, err
+			}
+			txns.SetArrayItem(indx, val)
+		}
+		o.Set("transactions", txns)
+	} else if len(t.TransactionsHashes) != 0 {
+		hashes := a.NewArray()
+		for indx, hash := range t.TransactionsHashes {
+			hashes.SetArrayItem(indx, a.NewString(hash.String()))
+		}
+		o.Set("transactions", hashes)
+	}
+
 	res := o.MarshalTo(nil)
 	defaultArena.Put(a)
 	return res, nil
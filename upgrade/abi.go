@@ -0,0 +1,200 @@
+package upgrade
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/laizy/web3/abi"
+)
+
+// CheckABI compares the abis of two versions of a contract. The functions and events
+// are matched by signature, a signature not found in the new abi is reported as changed
+// if the name is still there and as removed otherwise. The constructor is ignored.
+func CheckABI(oldAbi, newAbi *abi.ABI) Report {
+	var report Report
+
+	for _, sig := range methodSigs(oldAbi) {
+		m := oldAbi.MethodsBySig[sig]
+		nm, ok := newAbi.MethodsBySig[sig]
+		if !ok {
+			report = append(report, missingMethod(m, newAbi))
+			continue
+		}
+		if outputs, newOutputs := typeList(m.Outputs), typeList(nm.Outputs); outputs != newOutputs {
+			report = append(report, &Finding{
+				Kind:     MethodChanged,
+				Severity: Error,
+				Name:     sig,
+				Old:      outputs,
+				New:      newOutputs,
+				Message:  fmt.Sprintf("outputs changed from %s to %s", outputs, newOutputs),
+			})
+		}
+		if f := checkMutability(sig, m.StateMutability, nm.StateMutability); f != nil {
+			report = append(report, f)
+		}
+	}
+
+	if oldAbi.Fallback != nil && newAbi.Fallback == nil {
+		report = append(report, &Finding{Kind: MethodRemoved, Severity: Error, Name: "fallback()", Old: "fallback()", Message: "removed"})
+	}
+	if oldAbi.Receive != nil && newAbi.Receive == nil {
+		report = append(report, &Finding{Kind: MethodRemoved, Severity: Error, Name: "receive()", Old: "receive()", Message: "removed"})
+	}
+
+	for _, sig := range eventSigs(oldAbi) {
+		e := oldAbi.EventsBySig[sig]
+		ne, ok := newAbi.EventsBySig[sig]
+		if !ok {
+			report = append(report, missingEvent(e, newAbi))
+			continue
+		}
+		if e.Anonymous != ne.Anonymous || indexed(e) != indexed(ne) {
+			report = append(report, &Finding{
+				Kind:     EventChanged,
+				Severity: Error,
+				Name:     sig,
+				Old:      eventDesc(e),
+				New:      eventDesc(ne),
+				Message:  "indexed inputs or anonymity changed, the logs have another layout",
+			})
+		}
+	}
+	return report
+}
+
+func missingMethod(m *abi.Method, newAbi *abi.ABI) *Finding {
+	sig := m.Sig()
+	var sigs []string
+	for newSig, nm := range newAbi.MethodsBySig {
+		if nm.Name == m.Name {
+			sigs = append(sigs, newSig)
+		}
+	}
+	if len(sigs) == 0 {
+		return &Finding{
+			Kind:     MethodRemoved,
+			Severity: Error,
+			Name:     sig,
+			Old:      sig,
+			Message:  "removed",
+		}
+	}
+	sort.Strings(sigs)
+	return &Finding{
+		Kind:     MethodChanged,
+		Severity: Error,
+		Name:     sig,
+		Old:      sig,
+		New:      strings.Join(sigs, ", "),
+		Message:  fmt.Sprintf("inputs changed to %s, the selector is different", strings.Join(sigs, ", ")),
+	}
+}
+
+func missingEvent(e *abi.Event, newAbi *abi.ABI) *Finding {
+	sig := e.Sig()
+	var sigs []string
+	for newSig, ne := range newAbi.EventsBySig {
+		if ne.Name == e.Name {
+			sigs = append(sigs, newSig)
+		}
+	}
+	if len(sigs) == 0 {
+		return &Finding{
+			Kind:     EventRemoved,
+			Severity: Error,
+			Name:     sig,
+			Old:      sig,
+			Message:  "removed",
+		}
+	}
+	sort.Strings(sigs)
+	return &Finding{
+		Kind:     EventChanged,
+		Severity: Error,
+		Name:     sig,
+		Old:      sig,
+		New:      strings.Join(sigs, ", "),
+		Message:  fmt.Sprintf("inputs changed to %s, the topic is different", strings.Join(sigs, ", ")),
+	}
+}
+
+// checkMutability reports the state mutability changes that break the callers: a view
+// function that writes fails in a static call, and a payable function that no longer is
+// reverts the calls with value
+func checkMutability(sig string, oldMutability, newMutability string) *Finding {
+	if oldMutability == newMutability {
+		return nil
+	}
+	severity := Warning
+	readonly := func(m string) bool { return m == "view" || m == "pure" }
+	if (readonly(oldMutability) && !readonly(newMutability)) || oldMutability == "payable" {
+		severity = Error
+	}
+	return &Finding{
+		Kind:     MethodChanged,
+		Severity: severity,
+		Name:     sig,
+		Old:      oldMutability,
+		New:      newMutability,
+		Message:  fmt.Sprintf("state mutability changed from %s to %s", oldMutability, newMutability),
+	}
+}
+
+// typeList returns the types of the arguments, the raw type of the argument list is
+// only 'tuple'
+func typeList(t *abi.Type) string {
+	if t == nil {
+		return "()"
+	}
+	types := make([]string, len(t.TupleElems()))
+	for i, elem := range t.TupleElems() {
+		types[i] = elem.Elem.String()
+	}
+	return "(" + strings.Join(types, ",") + ")"
+}
+
+func indexed(e *abi.Event) string {
+	res := make([]byte, len(e.Inputs.TupleElems()))
+	for i, elem := range e.Inputs.TupleElems() {
+		res[i] = '0'
+		if elem.Indexed {
+			res[i] = '1'
+		}
+	}
+	return string(res)
+}
+
+func eventDesc(e *abi.Event) string {
+	inputs := make([]string, len(e.Inputs.TupleElems()))
+	for i, elem := range e.Inputs.TupleElems() {
+		inputs[i] = elem.Elem.String()
+		if elem.Indexed {
+			inputs[i] += " indexed"
+		}
+	}
+	res := e.Name + "(" + strings.Join(inputs, ",") + ")"
+	if e.Anonymous {
+		res += " anonymous"
+	}
+	return res
+}
+
+func methodSigs(a *abi.ABI) []string {
+	res := make([]string, 0, len(a.MethodsBySig))
+	for sig := range a.MethodsBySig {
+		res = append(res, sig)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func eventSigs(a *abi.ABI) []string {
+	res := make([]string, 0, len(a.EventsBySig))
+	for sig := range a.EventsBySig {
+		res = append(res, sig)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,116 @@
+package upgrade
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/compiler"
+)
+
+// Kind is the kind of incompatibility of a finding
+type Kind string
+
+const (
+	// VariableInserted is a state variable inserted before the end of the storage
+	VariableInserted Kind = "variable-inserted"
+	// VariableRemoved is a state variable of the old layout that is not in the new one
+	VariableRemoved Kind = "variable-removed"
+	// VariableRenamed is a state variable with a new name in the same location
+	VariableRenamed Kind = "variable-renamed"
+	// VariableReordered is a state variable declared in a different order
+	VariableReordered Kind = "variable-reordered"
+	// VariableMoved is a state variable moved to another slot by other changes
+	VariableMoved Kind = "variable-moved"
+	// VariableRetyped is a state variable with a new type
+	VariableRetyped Kind = "variable-retyped"
+	// GapMisuse is a storage gap that is not shrunk by the size of the variables
+	// added in its place, or that is not a static array
+	GapMisuse Kind = "gap-misuse"
+
+	// MethodRemoved is a function of the old abi that is not in the new one
+	MethodRemoved Kind = "method-removed"
+	// MethodChanged is a function with new inputs, outputs or state mutability
+	MethodChanged Kind = "method-changed"
+	// EventRemoved is an event of the old abi that is not in the new one
+	EventRemoved Kind = "event-removed"
+	// EventChanged is an event with new inputs, indexed inputs or anonymity
+	EventChanged Kind = "event-changed"
+)
+
+// Severity is how unsafe a finding is for the upgrade
+type Severity string
+
+const (
+	// Error breaks the storage or the callers of the contract
+	Error Severity = "error"
+	// Warning is likely safe but has to be reviewed
+	Warning Severity = "warning"
+)
+
+// Finding is an incompatibility between two versions of a contract
+type Finding struct {
+	Kind     Kind
+	Severity Severity
+	// Name is the state variable with the contract that declares it but not its source,
+	// ie. 'Token.balances', or the signature of the function or event of the old version
+	// (of the new one if inserted)
+	Name string
+	// Old and New describe the old and the new version, empty if there is none
+	Old string
+	New string
+	// Message explains the finding
+	Message string
+}
+
+func (f *Finding) Error() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Name, f.Message)
+}
+
+// Report is the list of findings of a check
+type Report []*Finding
+
+// Errors returns the findings with an error severity
+func (r Report) Errors() Report {
+	var res Report
+	for _, f := range r {
+		if f.Severity == Error {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// Err returns the error findings as an error, or nil if the upgrade is safe
+func (r Report) Err() error {
+	errs := r.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, f := range errs {
+		msgs[i] = f.Error()
+	}
+	return fmt.Errorf("unsafe upgrade: %s", strings.Join(msgs, "\n"))
+}
+
+// CheckArtifacts compares the storage layouts and the abis of two versions of a
+// contract, ie. from hardhat build infos or solc outputs
+func CheckArtifacts(oldArtifact, newArtifact *compiler.Artifact) (Report, error) {
+	if oldArtifact.StorageLayout == nil || newArtifact.StorageLayout == nil {
+		return nil, fmt.Errorf("artifact without storage layout")
+	}
+	report, err := CheckStorage(oldArtifact.StorageLayout, newArtifact.StorageLayout)
+	if err != nil {
+		return nil, err
+	}
+	oldAbi, err := abi.NewABI(oldArtifact.Abi)
+	if err != nil {
+		return nil, fmt.Errorf("invalid abi of the old artifact: %v", err)
+	}
+	newAbi, err := abi.NewABI(newArtifact.Abi)
+	if err != nil {
+		return nil, fmt.Errorf("invalid abi of the new artifact: %v", err)
+	}
+	return append(report, CheckABI(oldAbi, newAbi)...), nil
+}
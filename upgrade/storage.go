@@ -0,0 +1,318 @@
+package upgrade
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/laizy/web3/compiler"
+)
+
+// gapPrefix is the name prefix of the storage gaps, ie. 'uint256[50] private __gap'
+const gapPrefix = "__gap"
+
+var bytes32 = big.NewInt(32)
+
+// variable is a state variable with its position in bytes in the storage
+type variable struct {
+	name  string
+	index int
+	slot  *compiler.StorageSlot
+	start *big.Int
+	end   *big.Int
+	// desc describes the type without the ast ids, which change between compilations
+	desc string
+	typ  *compiler.StorageType
+}
+
+func (v *variable) isGap() bool {
+	return strings.HasPrefix(v.slot.Label, gapPrefix)
+}
+
+func (v *variable) String() string {
+	return fmt.Sprintf("%s at slot %s offset %d", v.desc, v.slot.Slot, v.slot.Offset)
+}
+
+// endSlot returns the first slot after the variable
+func (v *variable) endSlot() *big.Int {
+	end := new(big.Int).Add(v.end, big.NewInt(31))
+	return end.Div(end, bytes32)
+}
+
+func variables(layout *compiler.StorageLayout) ([]*variable, error) {
+	res := make([]*variable, 0, len(layout.Storage))
+	for i, s := range layout.Storage {
+		typ, ok := layout.Types[s.Type]
+		if !ok {
+			return nil, fmt.Errorf("type %s of %s not found", s.Type, s.Label)
+		}
+		slot, ok := new(big.Int).SetString(s.Slot, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid slot %s of %s", s.Slot, s.Label)
+		}
+		size, ok := new(big.Int).SetString(typ.NumberOfBytes, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid size %s of %s", typ.NumberOfBytes, typ.Label)
+		}
+		start := slot.Mul(slot, bytes32)
+		start.Add(start, big.NewInt(int64(s.Offset)))
+		res = append(res, &variable{
+			name:  contractName(s.Contract) + "." + s.Label,
+			index: i,
+			slot:  s,
+			start: start,
+			end:   new(big.Int).Add(start, size),
+			desc:  typeDesc(layout, s.Type),
+			typ:   typ,
+		})
+	}
+	return res, nil
+}
+
+// contractName returns the name of the contract without the source, so that the
+// contracts can be moved to other files
+func contractName(fqName string) string {
+	return fqName[strings.LastIndex(fqName, ":")+1:]
+}
+
+// typeDesc describes a type by its labels and its layout
+func typeDesc(layout *compiler.StorageLayout, id string) string {
+	typ, ok := layout.Types[id]
+	if !ok {
+		return id
+	}
+	switch {
+	case typ.Encoding == "mapping":
+		return "mapping(" + typeDesc(layout, typ.Key) + " => " + typeDesc(layout, typ.Value) + ")"
+	case typ.Encoding == "dynamic_array":
+		return typeDesc(layout, typ.Base) + "[]"
+	case typ.Base != "":
+		// the static arrays are the only inplace types with a base
+		length := strings.TrimSuffix(typ.Label[strings.LastIndex(typ.Label, "[")+1:], "]")
+		return typeDesc(layout, typ.Base) + "[" + length + "]"
+	case len(typ.Members) != 0:
+		members := make([]string, len(typ.Members))
+		for i, m := range typ.Members {
+			members[i] = fmt.Sprintf("%s %s@%s:%d", typeDesc(layout, m.Type), m.Label, m.Slot, m.Offset)
+		}
+		return typ.Label + "{" + strings.Join(members, "; ") + "}"
+	}
+	return typ.Label
+}
+
+func isAddress(desc string) bool {
+	return desc == "address" || desc == "address payable" || strings.HasPrefix(desc, "contract ")
+}
+
+// CheckStorage compares the storage layouts of two versions of a contract. The variables
+// are matched by contract and name, the new ones can only be appended at the end of the
+// storage or take the place of a storage gap shrunk by the same size.
+func CheckStorage(oldLayout, newLayout *compiler.StorageLayout) (Report, error) {
+	oldVars, err := variables(oldLayout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid old layout: %v", err)
+	}
+	newVars, err := variables(newLayout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid new layout: %v", err)
+	}
+
+	newByName := map[string]*variable{}
+	for _, v := range newVars {
+		newByName[v.name] = v
+	}
+	matched := map[*variable]*variable{}
+	used := map[*variable]bool{}
+	var pairs []*variable
+	for _, v := range oldVars {
+		if nv, ok := newByName[v.name]; ok {
+			matched[v] = nv
+			used[nv] = true
+			pairs = append(pairs, v)
+		}
+	}
+	reordered := reorderedVariables(pairs, matched)
+
+	var report Report
+	oldEnd := new(big.Int)
+	for _, v := range oldVars {
+		if v.end.Cmp(oldEnd) > 0 {
+			oldEnd = v.end
+		}
+		nv, ok := matched[v]
+		switch {
+		case v.isGap():
+			if f := checkGap(v, nv, newVars); f != nil {
+				report = append(report, f)
+			}
+			continue
+
+		case !ok:
+			if renamed := renamedVariable(v, newVars, used); renamed != nil {
+				used[renamed] = true
+				report = append(report, &Finding{
+					Kind:     VariableRenamed,
+					Severity: Warning,
+					Name:     v.name,
+					Old:      v.String(),
+					New:      renamed.name + ": " + renamed.String(),
+					Message:  fmt.Sprintf("renamed to %s", renamed.name),
+				})
+				continue
+			}
+			report = append(report, &Finding{
+				Kind:     VariableRemoved,
+				Severity: Error,
+				Name:     v.name,
+				Old:      v.String(),
+				Message:  "removed, the following variables take its place",
+			})
+			continue
+		}
+
+		switch {
+		case reordered[v]:
+			report = append(report, &Finding{
+				Kind:     VariableReordered,
+				Severity: Error,
+				Name:     v.name,
+				Old:      v.String(),
+				New:      nv.String(),
+				Message:  fmt.Sprintf("declared in a different order, moved from slot %s offset %d to slot %s offset %d", v.slot.Slot, v.slot.Offset, nv.slot.Slot, nv.slot.Offset),
+			})
+		case v.start.Cmp(nv.start) != 0:
+			report = append(report, &Finding{
+				Kind:     VariableMoved,
+				Severity: Error,
+				Name:     v.name,
+				Old:      v.String(),
+				New:      nv.String(),
+				Message:  fmt.Sprintf("moved from slot %s offset %d to slot %s offset %d", v.slot.Slot, v.slot.Offset, nv.slot.Slot, nv.slot.Offset),
+			})
+		}
+		if v.desc != nv.desc {
+			severity := Error
+			if isAddress(v.desc) && isAddress(nv.desc) {
+				severity = Warning
+			}
+			report = append(report, &Finding{
+				Kind:     VariableRetyped,
+				Severity: severity,
+				Name:     v.name,
+				Old:      v.String(),
+				New:      nv.String(),
+				Message:  fmt.Sprintf("type changed from %s to %s", v.desc, nv.desc),
+			})
+		}
+	}
+
+	var oldGaps []*variable
+	for _, v := range oldVars {
+		if v.isGap() {
+			oldGaps = append(oldGaps, v)
+		}
+	}
+	for _, nv := range newVars {
+		if nv.isGap() && (nv.typ.Base == "" || nv.typ.Encoding != "inplace") {
+			report = append(report, &Finding{
+				Kind:     GapMisuse,
+				Severity: Error,
+				Name:     nv.name,
+				New:      nv.String(),
+				Message:  "a storage gap has to be a static array",
+			})
+		}
+		if used[nv] || nv.start.Cmp(oldEnd) >= 0 || inGap(nv, oldGaps) {
+			continue
+		}
+		report = append(report, &Finding{
+			Kind:     VariableInserted,
+			Severity: Error,
+			Name:     nv.name,
+			New:      nv.String(),
+			Message:  "inserted before the end of the storage, only the storage gaps can be replaced",
+		})
+	}
+	return report, nil
+}
+
+// reorderedVariables returns the matched variables that are not in the longest sequence
+// declared in the same order in both layouts
+func reorderedVariables(pairs []*variable, matched map[*variable]*variable) map[*variable]bool {
+	length := make([]int, len(pairs))
+	prev := make([]int, len(pairs))
+	best := -1
+	for i, v := range pairs {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if matched[pairs[j]].index < matched[v].index && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+		if best == -1 || length[i] > length[best] {
+			best = i
+		}
+	}
+	inOrder := map[*variable]bool{}
+	for i := best; i != -1; i = prev[i] {
+		inOrder[pairs[i]] = true
+	}
+	res := map[*variable]bool{}
+	for _, v := range pairs {
+		if !inOrder[v] {
+			res[v] = true
+		}
+	}
+	return res
+}
+
+// renamedVariable returns the new variable of the same type in the place of the
+// removed one
+func renamedVariable(v *variable, newVars []*variable, used map[*variable]bool) *variable {
+	for _, nv := range newVars {
+		if !used[nv] && !nv.isGap() && nv.start.Cmp(v.start) == 0 && nv.desc == v.desc {
+			return nv
+		}
+	}
+	return nil
+}
+
+// checkGap checks that the gap still ends in the same slot, the variables added in its
+// place have to shrink it by their size
+func checkGap(gap *variable, newGap *variable, newVars []*variable) *Finding {
+	if newGap != nil {
+		if gap.endSlot().Cmp(newGap.endSlot()) == 0 {
+			return nil
+		}
+		return &Finding{
+			Kind:     GapMisuse,
+			Severity: Error,
+			Name:     gap.name,
+			Old:      gap.String(),
+			New:      newGap.String(),
+			Message:  fmt.Sprintf("the gap ends before slot %s instead of slot %s, its size has to be reduced by the slots of the variables added before it", newGap.endSlot(), gap.endSlot()),
+		}
+	}
+	// the gap is fully replaced if the new variables end with it
+	for _, nv := range newVars {
+		if nv.start.Cmp(gap.start) >= 0 && nv.endSlot().Cmp(gap.endSlot()) == 0 {
+			return nil
+		}
+	}
+	return &Finding{
+		Kind:     GapMisuse,
+		Severity: Error,
+		Name:     gap.name,
+		Old:      gap.String(),
+		Message:  "the gap is removed without being replaced by variables of the same size",
+	}
+}
+
+func inGap(v *variable, gaps []*variable) bool {
+	for _, gap := range gaps {
+		if v.start.Cmp(gap.start) >= 0 && v.end.Cmp(gap.end) <= 0 {
+			return true
+		}
+	}
+	return false
+}
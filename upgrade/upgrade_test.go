@@ -0,0 +1,290 @@
+package upgrade
+
+import (
+	"testing"
+
+	"github.com/laizy/web3/abi"
+	"github.com/laizy/web3/compiler"
+	"github.com/stretchr/testify/require"
+)
+
+var storageTypes = map[string]*compiler.StorageType{
+	"t_address":                      {Encoding: "inplace", Label: "address", NumberOfBytes: "20"},
+	"t_bool":                         {Encoding: "inplace", Label: "bool", NumberOfBytes: "1"},
+	"t_uint128":                      {Encoding: "inplace", Label: "uint128", NumberOfBytes: "16"},
+	"t_uint256":                      {Encoding: "inplace", Label: "uint256", NumberOfBytes: "32"},
+	"t_contract(IERC20)12":           {Encoding: "inplace", Label: "contract IERC20", NumberOfBytes: "20"},
+	"t_array(t_uint256)50_storage":   {Encoding: "inplace", Label: "uint256[50]", NumberOfBytes: "1600", Base: "t_uint256"},
+	"t_array(t_uint256)48_storage":   {Encoding: "inplace", Label: "uint256[48]", NumberOfBytes: "1536", Base: "t_uint256"},
+	"t_mapping(t_address,t_uint256)": {Encoding: "mapping", Label: "mapping(address => uint256)", NumberOfBytes: "32", Key: "t_address", Value: "t_uint256"},
+}
+
+func stateVar(label, slot string, offset int, typ string) *compiler.StorageSlot {
+	return &compiler.StorageSlot{Contract: "contracts/Box.sol:Box", Label: label, Slot: slot, Offset: offset, Type: typ}
+}
+
+func layout(vars ...*compiler.StorageSlot) *compiler.StorageLayout {
+	return &compiler.StorageLayout{Storage: vars, Types: storageTypes}
+}
+
+func kinds(report Report) []Kind {
+	res := []Kind{}
+	for _, f := range report {
+		res = append(res, f.Kind)
+	}
+	return res
+}
+
+func TestCheckStorage(t *testing.T) {
+	base := layout(
+		stateVar("owner", "0", 0, "t_address"),
+		stateVar("paused", "0", 20, "t_bool"),
+		stateVar("balances", "1", 0, "t_mapping(t_address,t_uint256)"),
+		stateVar("total", "2", 0, "t_uint256"),
+	)
+
+	cases := []struct {
+		name     string
+		layout   *compiler.StorageLayout
+		expected []Kind
+	}{
+		{
+			"same",
+			base,
+			[]Kind{},
+		},
+		{
+			"appended",
+			layout(
+				stateVar("owner", "0", 0, "t_address"),
+				stateVar("paused", "0", 20, "t_bool"),
+				stateVar("balances", "1", 0, "t_mapping(t_address,t_uint256)"),
+				stateVar("total", "2", 0, "t_uint256"),
+				stateVar("cap", "3", 0, "t_uint256"),
+			),
+			[]Kind{},
+		},
+		{
+			"moved to another file",
+			&compiler.StorageLayout{Storage: []*compiler.StorageSlot{
+				{Contract: "contracts/v2/Box.sol:Box", Label: "owner", Slot: "0", Offset: 0, Type: "t_address"},
+				{Contract: "contracts/v2/Box.sol:Box", Label: "paused", Slot: "0", Offset: 20, Type: "t_bool"},
+				{Contract: "contracts/v2/Box.sol:Box", Label: "balances", Slot: "1", Offset: 0, Type: "t_mapping(t_address,t_uint256)"},
+				{Contract: "contracts/v2/Box.sol:Box", Label: "total", Slot: "2", Offset: 0, Type: "t_uint256"},
+			}, Types: storageTypes},
+			[]Kind{},
+		},
+		{
+			"inserted",
+			layout(
+				stateVar("owner", "0", 0, "t_address"),
+				stateVar("paused", "0", 20, "t_bool"),
+				stateVar("cap", "1", 0, "t_uint256"),
+				stateVar("balances", "2", 0, "t_mapping(t_address,t_uint256)"),
+				stateVar("total", "3", 0, "t_uint256"),
+			),
+			[]Kind{VariableMoved, VariableMoved, VariableInserted},
+		},
+		{
+			"removed",
+			layout(
+				stateVar("owner", "0", 0, "t_address"),
+				stateVar("balances", "1", 0, "t_mapping(t_address,t_uint256)"),
+				stateVar("total", "2", 0, "t_uint256"),
+			),
+			[]Kind{VariableRemoved},
+		},
+		{
+			"reordered",
+			layout(
+				stateVar("owner", "0", 0, "t_address"),
+				stateVar("paused", "0", 20, "t_bool"),
+				stateVar("total", "1", 0, "t_uint256"),
+				stateVar("balances", "2", 0, "t_mapping(t_address,t_uint256)"),
+			),
+			[]Kind{VariableMoved, VariableReordered},
+		},
+		{
+			"retyped",
+			layout(
+				stateVar("owner", "0", 0, "t_contract(IERC20)12"),
+				stateVar("paused", "0", 20, "t_bool"),
+				stateVar("balances", "1", 0, "t_mapping(t_address,t_uint256)"),
+				stateVar("total", "2", 0, "t_uint128"),
+			),
+			[]Kind{VariableRetyped, VariableRetyped},
+		},
+		{
+			"renamed",
+			layout(
+				stateVar("admin", "0", 0, "t_address"),
+				stateVar("paused", "0", 20, "t_bool"),
+				stateVar("balances", "1", 0, "t_mapping(t_address,t_uint256)"),
+				stateVar("total", "2", 0, "t_uint256"),
+			),
+			[]Kind{VariableRenamed},
+		},
+	}
+	for _, c := range cases {
+		report, err := CheckStorage(base, c.layout)
+		require.NoError(t, err, c.name)
+		require.Equal(t, c.expected, kinds(report), c.name)
+	}
+
+	report, err := CheckStorage(base, cases[6].layout)
+	require.NoError(t, err)
+	require.Equal(t, Warning, report[0].Severity)
+	require.Equal(t, "Box.owner", report[0].Name)
+	require.Equal(t, Error, report[1].Severity)
+	require.Equal(t, "type changed from uint256 to uint128", report[1].Message)
+	require.Len(t, report.Errors(), 1)
+	require.Error(t, report.Err())
+
+	report, err = CheckStorage(base, cases[1].layout)
+	require.NoError(t, err)
+	require.NoError(t, report.Err())
+}
+
+func TestCheckStorage_Gap(t *testing.T) {
+	base := layout(
+		stateVar("owner", "0", 0, "t_address"),
+		stateVar("__gap", "1", 0, "t_array(t_uint256)50_storage"),
+		stateVar("total", "51", 0, "t_uint256"),
+	)
+
+	cases := []struct {
+		name     string
+		layout   *compiler.StorageLayout
+		expected []Kind
+	}{
+		{
+			"gap shrunk",
+			layout(
+				stateVar("owner", "0", 0, "t_address"),
+				stateVar("cap", "1", 0, "t_uint256"),
+				stateVar("limit", "2", 0, "t_uint256"),
+				stateVar("__gap", "3", 0, "t_array(t_uint256)48_storage"),
+				stateVar("total", "51", 0, "t_uint256"),
+			),
+			[]Kind{},
+		},
+		{
+			"gap not shrunk",
+			layout(
+				stateVar("owner", "0", 0, "t_address"),
+				stateVar("cap", "1", 0, "t_uint256"),
+				stateVar("__gap", "2", 0, "t_array(t_uint256)50_storage"),
+				stateVar("total", "52", 0, "t_uint256"),
+			),
+			[]Kind{GapMisuse, VariableMoved},
+		},
+		{
+			"gap removed",
+			layout(
+				stateVar("owner", "0", 0, "t_address"),
+				stateVar("total", "1", 0, "t_uint256"),
+			),
+			[]Kind{GapMisuse, VariableMoved},
+		},
+		{
+			"gap not an array",
+			layout(
+				stateVar("owner", "0", 0, "t_address"),
+				stateVar("__gap", "1", 0, "t_uint256"),
+			),
+			[]Kind{GapMisuse, VariableRemoved, GapMisuse},
+		},
+	}
+	for _, c := range cases {
+		report, err := CheckStorage(base, c.layout)
+		require.NoError(t, err, c.name)
+		require.Equal(t, c.expected, kinds(report), c.name)
+	}
+
+	_, err := CheckStorage(base, layout(stateVar("owner", "0", 0, "t_unknown")))
+	require.Error(t, err)
+}
+
+const tokenAbi = `[
+	{"type": "function", "name": "transfer", "stateMutability": "nonpayable",
+		"inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "uint256"}],
+		"outputs": [{"name": "", "type": "bool"}]},
+	{"type": "function", "name": "balanceOf", "stateMutability": "view",
+		"inputs": [{"name": "owner", "type": "address"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "deposit", "stateMutability": "payable", "inputs": [], "outputs": []},
+	{"type": "function", "name": "burn", "stateMutability": "nonpayable",
+		"inputs": [{"name": "amount", "type": "uint256"}], "outputs": []},
+	{"type": "event", "name": "Transfer", "anonymous": false, "inputs": [
+		{"name": "from", "type": "address", "indexed": true},
+		{"name": "to", "type": "address", "indexed": true},
+		{"name": "value", "type": "uint256", "indexed": false}]},
+	{"type": "event", "name": "Approval", "anonymous": false, "inputs": [
+		{"name": "owner", "type": "address", "indexed": true},
+		{"name": "spender", "type": "address", "indexed": true},
+		{"name": "value", "type": "uint256", "indexed": false}]},
+	{"type": "fallback", "stateMutability": "nonpayable"}
+]`
+
+const tokenAbiV2 = `[
+	{"type": "function", "name": "transfer", "stateMutability": "nonpayable",
+		"inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "uint256"}],
+		"outputs": []},
+	{"type": "function", "name": "balanceOf", "stateMutability": "nonpayable",
+		"inputs": [{"name": "owner", "type": "address"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "deposit", "stateMutability": "nonpayable", "inputs": [], "outputs": []},
+	{"type": "function", "name": "burn", "stateMutability": "nonpayable",
+		"inputs": [{"name": "amount", "type": "uint256"}, {"name": "from", "type": "address"}], "outputs": []},
+	{"type": "function", "name": "mint", "stateMutability": "nonpayable",
+		"inputs": [{"name": "amount", "type": "uint256"}], "outputs": []},
+	{"type": "event", "name": "Transfer", "anonymous": false, "inputs": [
+		{"name": "from", "type": "address", "indexed": true},
+		{"name": "to", "type": "address", "indexed": true},
+		{"name": "value", "type": "uint256", "indexed": true}]}
+]`
+
+func TestCheckABI(t *testing.T) {
+	oldAbi := abi.MustNewABI(tokenAbi)
+	require.Empty(t, CheckABI(oldAbi, oldAbi))
+
+	report := CheckABI(oldAbi, abi.MustNewABI(tokenAbiV2))
+	findings := map[string]*Finding{}
+	for _, f := range report {
+		findings[string(f.Kind)+" "+f.Name] = f
+	}
+	require.Len(t, findings, len(report))
+
+	require.Contains(t, findings, "method-changed transfer(address,uint256)")
+	require.Contains(t, findings, "method-changed balanceOf(address)")
+	require.Equal(t, Error, findings["method-changed balanceOf(address)"].Severity)
+	require.Contains(t, findings, "method-changed deposit()")
+	require.Equal(t, "burn(uint256,address)", findings["method-changed burn(uint256)"].New)
+	require.Contains(t, findings, "method-removed fallback()")
+	require.Contains(t, findings, "event-changed Transfer(address,address,uint256)")
+	require.Contains(t, findings, "event-removed Approval(address,address,uint256)")
+	require.Len(t, report, 7)
+
+	// the new functions and the less restrictive mutability are compatible
+	report = CheckABI(abi.MustNewABI(tokenAbiV2), abi.MustNewABI(`[
+		{"type": "function", "name": "deposit", "stateMutability": "payable", "inputs": [], "outputs": []}
+	]`))
+	for _, f := range report {
+		if f.Name == "deposit()" {
+			require.Equal(t, Warning, f.Severity)
+		}
+	}
+}
+
+func TestCheckArtifacts(t *testing.T) {
+	oldArtifact := &compiler.Artifact{Abi: tokenAbi, StorageLayout: layout(stateVar("owner", "0", 0, "t_address"))}
+	newArtifact := &compiler.Artifact{Abi: tokenAbiV2, StorageLayout: layout(stateVar("owner", "0", 0, "t_uint256"))}
+
+	report, err := CheckArtifacts(oldArtifact, newArtifact)
+	require.NoError(t, err)
+	require.Equal(t, VariableRetyped, report[0].Kind)
+	require.Len(t, report, 8)
+
+	_, err = CheckArtifacts(oldArtifact, &compiler.Artifact{Abi: tokenAbiV2})
+	require.Error(t, err)
+}